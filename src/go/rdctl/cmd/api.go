@@ -19,6 +19,7 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -148,3 +149,14 @@ func displayAPICallResult(result []byte, errorPacket *client.APIError, err error
 	os.Exit(1)
 	return nil
 }
+
+// displayAPIError reports an error returned by the sdk package like
+// displayAPICallResult, so that errors from the server are shown the same
+// way by every command.
+func displayAPIError(err error) error {
+	var apiError *client.APIError
+	if errors.As(err, &apiError) {
+		return displayAPICallResult([]byte(apiError.Body), apiError, nil)
+	}
+	return err
+}
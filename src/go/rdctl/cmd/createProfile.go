@@ -20,16 +20,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/plist"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/reg"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 )
 
 const plistFormat = "plist"
@@ -104,13 +102,11 @@ func createProfile(ctx context.Context) (string, error) {
 			// This should have been caught in validateProfileFormatFlags
 			return "", fmt.Errorf(`no input format specified: must specify exactly one input format of "--input FILE|-", "--body|-b STRING", or "--from-settings"`)
 		}
-		connectionInfo, err2 := config.GetConnectionInfo(false)
+		rdClient, err2 := sdk.Connect()
 		if err2 != nil {
-			return "", fmt.Errorf("failed to get connection info: %w", err2)
+			return "", err2
 		}
-		rdClient := client.NewRDClient(connectionInfo)
-		command := client.VersionCommand("", "settings")
-		output, err = client.ProcessRequestForUtility(rdClient.DoRequest(ctx, http.MethodGet, command))
+		output, err = rdClient.GetSettingsJSON(ctx)
 	}
 	if err != nil {
 		return "", err
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var diagnosticsCmd = &cobra.Command{
+	Use:   "diagnostics",
+	Short: "Collect diagnostic information about Rancher Desktop",
+}
+
+func init() {
+	rootCmd.AddCommand(diagnosticsCmd)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/diagnostics"
+)
+
+var diagnosticsBundleSettings struct {
+	File    string
+	Include []string
+	Exclude []string
+}
+
+var diagnosticsBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Collect logs, settings and state into an archive for a support ticket",
+	Long:  diagnosticsBundleLongHelp(),
+	Example: `  rdctl diagnostics bundle -f rd-diagnostics.tar.gz
+  rdctl diagnostics bundle --include vm-logs --exclude docker-config,kubeconfig`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		components, err := selectDiagnosticsComponents(diagnosticsBundleSettings.Include, diagnosticsBundleSettings.Exclude)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return writeDiagnosticsBundle(cmd, diagnosticsBundleSettings.File, components)
+	},
+}
+
+func init() {
+	diagnosticsCmd.AddCommand(diagnosticsBundleCmd)
+	diagnosticsBundleCmd.Flags().StringVarP(&diagnosticsBundleSettings.File, "file", "f", "", `the archive to write (- for standard output); defaults to a timestamped file in the current directory`)
+	diagnosticsBundleCmd.Flags().StringSliceVar(&diagnosticsBundleSettings.Include, "include", nil, "comma-separated components to collect in addition to the default ones")
+	diagnosticsBundleCmd.Flags().StringSliceVar(&diagnosticsBundleSettings.Exclude, "exclude", nil, "comma-separated components not to collect")
+}
+
+func diagnosticsBundleLongHelp() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("Collect diagnostic information into a gzipped tar archive that can be\n")
+	_, _ = builder.WriteString("attached to a support ticket. The archive holds a manifest.json listing the\n")
+	_, _ = builder.WriteString("files collected for each component, and why any couldn't be collected.\n")
+	_, _ = builder.WriteString("\n")
+	_, _ = builder.WriteString("The components are, with those not collected by default marked with *:\n")
+	for _, component := range diagnostics.Components {
+		marker := " "
+		if !component.Default {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(&builder, "  %-14s%s %s\n", component.Name, marker, component.Description)
+	}
+	return builder.String()
+}
+
+// Returns the default components, with those in include added and those in
+// exclude removed, in the order they are collected.
+func selectDiagnosticsComponents(include, exclude []string) ([]diagnostics.Component, error) {
+	for _, name := range slices.Concat(include, exclude) {
+		if !slices.ContainsFunc(diagnostics.Components, func(component diagnostics.Component) bool {
+			return component.Name == name
+		}) {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+		if slices.Contains(include, name) && slices.Contains(exclude, name) {
+			return nil, fmt.Errorf("component %q can't be both included and excluded", name)
+		}
+	}
+	var result []diagnostics.Component
+	for _, component := range diagnostics.Components {
+		if (component.Default || slices.Contains(include, component.Name)) && !slices.Contains(exclude, component.Name) {
+			result = append(result, component)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no components to collect")
+	}
+	return result, nil
+}
+
+func writeDiagnosticsBundle(cmd *cobra.Command, outputPath string, components []diagnostics.Component) (err error) {
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("rancher-desktop-diagnostics-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	root := "rancher-desktop-diagnostics"
+	var writer io.Writer = os.Stdout
+	if outputPath != "-" {
+		root = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(outputPath), ".tgz"), ".tar.gz")
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create bundle: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("failed to write bundle: %w", closeErr)
+			}
+			if err != nil {
+				_ = os.Remove(outputPath)
+			}
+		}()
+		writer = file
+	}
+	manifest, err := diagnostics.Write(cmd.Context(), writer, root, components)
+	if err != nil {
+		return err
+	}
+	for _, component := range manifest.Components {
+		if component.Error != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s was not fully collected: %s\n", component.Name, component.Error)
+		}
+	}
+	if outputPath != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote diagnostics bundle %s.\n", outputPath)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/command"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/doctor"
+)
+
+// The exit status of `rdctl doctor` when a check fails; warnings don't
+// change it.
+const doctorExitFailed = 1
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check for common problems with Rancher Desktop",
+	Long:  doctorLongHelp(),
+	Example: `  rdctl doctor
+  rdctl doctor --fix
+  rdctl doctor --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		env, err := doctor.NewEnvironment()
+		if err != nil {
+			return err
+		}
+		report := doctor.Run(cmd.Context(), env, doctor.Checks, doctorFix)
+		err = printOutput(report, func(writer io.Writer) error {
+			printDoctorReport(writer, report)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if report.Status == doctor.StatusFail {
+			return command.NewFatalError("some checks failed", doctorExitFailed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "fix the problems that can be fixed safely")
+	doctorCmd.Flags().BoolVar(&outputJSONFormat, "json", false, `print JSON (deprecated: use "--output json")`)
+}
+
+func doctorLongHelp() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("Check for common problems with Rancher Desktop, and suggest how to fix them.\n")
+	_, _ = builder.WriteString("Each check passes, warns, or fails; the exit status is non-zero if any check\n")
+	_, _ = builder.WriteString("fails. With --fix, the problems that can be fixed without losing data are\n")
+	_, _ = builder.WriteString("fixed, and the checks are run again.\n")
+	_, _ = builder.WriteString("\n")
+	_, _ = builder.WriteString("The checks are:\n")
+	for _, check := range doctor.Checks {
+		_, _ = fmt.Fprintf(&builder, "  %-20s %s\n", check.Name, check.Description)
+	}
+	return builder.String()
+}
+
+func printDoctorReport(writer io.Writer, report doctor.Report) {
+	for _, check := range report.Checks {
+		fmt.Fprintf(writer, "[%s] %s: %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Message)
+		switch {
+		case check.Fixed:
+			fmt.Fprintln(writer, "       fixed")
+		case check.FixError != "":
+			fmt.Fprintf(writer, "       failed to fix: %s\n", check.FixError)
+		}
+		if check.Status != doctor.StatusPass && check.Fix != "" {
+			fixable := ""
+			if check.Fixable && !check.Fixed {
+				fixable = " (rdctl doctor --fix can do this)"
+			}
+			fmt.Fprintf(writer, "       fix: %s%s\n", check.Fix, fixable)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
+)
+
+// The types of events reported by `rdctl events`.
+const (
+	eventTypeState    = "state"
+	eventTypeLock     = "lock"
+	eventTypeSettings = "settings"
+)
+
+var eventTypes = []string{eventTypeState, eventTypeLock, eventTypeSettings}
+
+var eventsSettings struct {
+	Types []string
+}
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Print Rancher Desktop events as they happen",
+	Long: `Print an event as a line of JSON whenever something changes, until interrupted:
+
+  state     the backend state changes, or the application starts or stops
+            running; the current state is reported first
+  lock      the backend is locked or unlocked, such as while a snapshot is
+            being taken; the current lock is reported first
+  settings  settings change; each event lists the changed settings
+
+Rancher Desktop has no history of events, so only changes that happen while
+the command runs are reported. Changes are found by polling every second, so
+changes that are undone within a second may be missed.
+
+With --output yaml, each event is printed as a YAML document, and with
+--output template=TEMPLATE, the template is executed for each event.`,
+	Example: `  rdctl events --type state,lock`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, eventType := range eventsSettings.Types {
+			if !slices.Contains(eventTypes, eventType) {
+				return fmt.Errorf("invalid event type %q: must be one of %s", eventType, strings.Join(eventTypes, ", "))
+			}
+		}
+		if err := checkStreamOutput(); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
+		defer stop()
+		return watchEvents(ctx, eventsSettings.Types, printStreamOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().StringSliceVar(&eventsSettings.Types, "type", eventTypes, "comma-separated event types to report: "+strings.Join(eventTypes, ", "))
+}
+
+type eventHeader struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+}
+
+type stateEvent struct {
+	eventHeader
+	// False while the Rancher Desktop API can't be reached.
+	Running         bool   `json:"running"`
+	VMState         string `json:"vmState,omitempty"`
+	PreviousVMState string `json:"previousVmState,omitempty"`
+}
+
+type lockEvent struct {
+	eventHeader
+	lock.LockStatus
+}
+
+type settingsEvent struct {
+	eventHeader
+	Changes []settingsChange `json:"changes"`
+}
+
+// settingsChange describes one changed setting in a settingsEvent, or in
+// the output of `rdctl set --dry-run`.
+type settingsChange struct {
+	// The name of the setting, as used by `rdctl set`.
+	Key string `json:"key"`
+	// One of "add", "remove" or "replace".
+	Op  string `json:"op"`
+	Old any    `json:"old,omitempty"`
+	New any    `json:"new,omitempty"`
+}
+
+// eventWatcher remembers what it observed in the last poll, so that it can
+// report what changed.
+type eventWatcher struct {
+	types    []string
+	appPaths *paths.Paths
+	emit     func(event any) error
+	// Whether the first poll has been made.
+	started    bool
+	running    bool
+	vmState    string
+	lockStatus lock.LockStatus
+	// The last settings read, decoded from JSON; nil if they haven't been
+	// read yet.
+	settings any
+}
+
+// Polls Rancher Desktop, calling emit with each event, until ctx is done.
+func watchEvents(ctx context.Context, types []string, emit func(event any) error) error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	watcher := &eventWatcher{types: types, appPaths: appPaths, emit: emit}
+	err = sdk.Poll(ctx, func() (bool, error) {
+		return false, watcher.poll(ctx)
+	})
+	if ctx.Err() != nil {
+		// Interrupted by the user.
+		return nil
+	}
+	return err
+}
+
+func (watcher *eventWatcher) poll(ctx context.Context) error {
+	now := time.Now()
+	if slices.Contains(watcher.types, eventTypeLock) {
+		if err := watcher.pollLock(now); err != nil {
+			return err
+		}
+	}
+	var rdClient *sdk.Client
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil {
+		return fmt.Errorf("failed to get connection info: %w", err)
+	} else if connectionInfo != nil {
+		rdClient = sdk.NewClient(client.NewRDClient(connectionInfo))
+	}
+	running, err := watcher.pollState(ctx, rdClient, now)
+	if err != nil {
+		return err
+	}
+	if running && slices.Contains(watcher.types, eventTypeSettings) {
+		if err := watcher.pollSettings(ctx, rdClient, now); err != nil {
+			return err
+		}
+	}
+	watcher.started = true
+	return nil
+}
+
+func (watcher *eventWatcher) pollLock(now time.Time) error {
+	status, err := lock.Status(watcher.appPaths)
+	if err != nil {
+		return err
+	}
+	if watcher.started && reflect.DeepEqual(status, watcher.lockStatus) {
+		return nil
+	}
+	watcher.lockStatus = status
+	return watcher.emit(lockEvent{eventHeader{now, eventTypeLock}, status})
+}
+
+// Reports changes to the backend state, and returns whether the
+// application is running.
+func (watcher *eventWatcher) pollState(ctx context.Context, rdClient *sdk.Client, now time.Time) (bool, error) {
+	running := false
+	vmState := ""
+	if rdClient != nil {
+		state, err := rdClient.GetBackendState(ctx)
+		if err == nil {
+			running = true
+			vmState = state.VMState
+		} else if !errors.Is(err, client.ErrConnectionRefused) {
+			return false, fmt.Errorf("failed to get backend state: %w", err)
+		}
+	}
+	changed := !watcher.started || running != watcher.running || vmState != watcher.vmState
+	previousVMState := watcher.vmState
+	watcher.running = running
+	watcher.vmState = vmState
+	if !changed || !slices.Contains(watcher.types, eventTypeState) {
+		return running, nil
+	}
+	return running, watcher.emit(stateEvent{
+		eventHeader:     eventHeader{now, eventTypeState},
+		Running:         running,
+		VMState:         vmState,
+		PreviousVMState: previousVMState,
+	})
+}
+
+// Reports changes to the settings. The first settings read are only
+// remembered, to compare later ones with.
+func (watcher *eventWatcher) pollSettings(ctx context.Context, rdClient *sdk.Client, now time.Time) error {
+	contents, err := rdClient.GetSettingsJSON(ctx)
+	if errors.Is(err, client.ErrConnectionRefused) {
+		// The application stopped after the state was read.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+	var settings any
+	if err := json.Unmarshal(contents, &settings); err != nil {
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+	previous := watcher.settings
+	watcher.settings = settings
+	if previous == nil {
+		return nil
+	}
+	changes := settingsdiff.DiffDocuments(previous, settings)
+	if len(changes) == 0 {
+		return nil
+	}
+	return watcher.emit(settingsEvent{
+		eventHeader: eventHeader{now, eventTypeSettings},
+		Changes:     settingsChanges(changes),
+	})
+}
+
+// Converts changes to the settings JSON into settingsChanges.
+func settingsChanges(changes []settingsdiff.Change) []settingsChange {
+	result := make([]settingsChange, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, settingsChange{
+			Key: change.DottedPath(),
+			Op:  change.Op,
+			Old: change.Old,
+			New: change.New,
+		})
+	}
+	return result
+}
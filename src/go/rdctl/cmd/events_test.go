@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+func TestEventWatcher(t *testing.T) {
+	vmState := "STARTED"
+	settings := `{"kubernetes": {"version": "1.30.1", "enabled": true}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/backend_state":
+			_, _ = w.Write([]byte(`{"vmState": "` + vmState + `"}`))
+		case "/v1/settings":
+			_, _ = w.Write([]byte(settings))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	_, portString, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portString)
+	require.NoError(t, err)
+	rdClient := sdk.NewClient(client.NewRDClient(&config.ConnectionInfo{Host: "127.0.0.1", Port: port, User: "user", Password: "password"}))
+
+	var events []any
+	watcher := &eventWatcher{
+		types: eventTypes,
+		emit: func(event any) error {
+			events = append(events, event)
+			return nil
+		},
+	}
+	poll := func() {
+		ctx := context.Background()
+		running, err := watcher.pollState(ctx, rdClient, time.Time{})
+		require.NoError(t, err)
+		require.True(t, running)
+		require.NoError(t, watcher.pollSettings(ctx, rdClient, time.Time{}))
+		watcher.started = true
+	}
+
+	poll()
+	require.Len(t, events, 1, "the initial state should be reported")
+	assert.Equal(t, "STARTED", events[0].(stateEvent).VMState)
+
+	events = nil
+	poll()
+	assert.Empty(t, events, "nothing changed")
+
+	vmState = "STOPPING"
+	settings = `{"kubernetes": {"version": "1.29.4", "enabled": true}}`
+	poll()
+	require.Len(t, events, 2)
+	state := events[0].(stateEvent)
+	assert.Equal(t, "STOPPING", state.VMState)
+	assert.Equal(t, "STARTED", state.PreviousVMState)
+	assert.Equal(t, []settingsChange{{Key: "kubernetes.version", Op: "replace", Old: "1.30.1", New: "1.29.4"}}, events[1].(settingsEvent).Changes)
+}
@@ -20,12 +20,10 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 )
 
 // installCmd represents the 'rdctl extensions install' command
@@ -47,23 +45,18 @@ func init() {
 }
 
 func installExtension(ctx context.Context, args []string) error {
-	connectionInfo, err := config.GetConnectionInfo(false)
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return fmt.Errorf("failed to get connection info: %w", err)
+		return err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
 	imageID := args[0]
-	endpoint := fmt.Sprintf("/%s/extensions/install?id=%s", client.APIVersion, imageID)
-	// https://stackoverflow.com/questions/20847357/golang-http-client-always-escaped-the-url
-	// Looks like http.NewRequest(method, url) escapes the URL
-
-	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest(ctx, http.MethodPost, endpoint))
-	if errorPacket != nil || err != nil {
-		return displayAPICallResult(result, errorPacket, err)
+	result, err := rdClient.InstallExtension(ctx, imageID)
+	if err != nil {
+		return displayAPIError(err)
 	}
 	msg := "no output from server"
-	if result != nil {
-		msg = string(result)
+	if result != "" {
+		msg = result
 	}
 	fmt.Printf("Installing image %s: %s\n", imageID, msg)
 	return nil
@@ -20,16 +20,14 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 )
 
 // listCmd represents the list command
@@ -49,37 +47,35 @@ func init() {
 	extensionCmd.AddCommand(listCmd)
 }
 
+// An installed extension, as printed by `rdctl extension list --output json`.
+type extensionListEntry struct {
+	ID string `json:"id"`
+	sdk.Extension
+}
+
 func listExtensions(ctx context.Context) error {
-	connectionInfo, err := config.GetConnectionInfo(false)
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return fmt.Errorf("failed to get connection info: %w", err)
+		return err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
-	endpoint := fmt.Sprintf("/%s/extensions", client.APIVersion)
-	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest(ctx, http.MethodGet, endpoint))
-	if errorPacket != nil || err != nil {
-		return displayAPICallResult([]byte{}, errorPacket, err)
-	}
-	extensionList := map[string]struct {
-		Version string `json:"version"`
-	}{}
-	err = json.Unmarshal(result, &extensionList)
+	extensionList, err := rdClient.ListExtensions(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal extension list API response: %w", err)
-	}
-	if len(extensionList) == 0 {
-		fmt.Println("No extensions are installed.")
-		return nil
+		return displayAPIError(err)
 	}
-	extensionIDs := make([]string, 0, len(extensionList))
+	entries := make([]extensionListEntry, 0, len(extensionList))
 	for id, info := range extensionList {
-		extensionIDs = append(extensionIDs, fmt.Sprintf("%s:%s", id, info.Version))
-	}
-	sort.Slice(extensionIDs, func(i, j int) bool { return strings.ToLower(extensionIDs[i]) < strings.ToLower(extensionIDs[j]) })
-
-	fmt.Print("Extension IDs\n\n")
-	for _, extensionID := range extensionIDs {
-		fmt.Println(extensionID)
+		entries = append(entries, extensionListEntry{ID: id, Extension: info})
 	}
-	return nil
+	sort.Slice(entries, func(i, j int) bool { return strings.ToLower(entries[i].ID) < strings.ToLower(entries[j].ID) })
+	return printOutput(entries, func(writer io.Writer) error {
+		if len(entries) == 0 {
+			_, err := fmt.Fprintln(writer, "No extensions are installed.")
+			return err
+		}
+		fmt.Fprint(writer, "Extension IDs\n\n")
+		for _, entry := range entries {
+			fmt.Fprintf(writer, "%s:%s\n", entry.ID, entry.Version)
+		}
+		return nil
+	})
 }
@@ -21,12 +21,10 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 )
 
 var uninstallCmd = &cobra.Command{
@@ -46,20 +44,18 @@ func init() {
 }
 
 func uninstallExtension(ctx context.Context, args []string) error {
-	connectionInfo, err := config.GetConnectionInfo(false)
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return fmt.Errorf("failed to get connection info: %w", err)
+		return err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
 	imageID := args[0]
-	endpoint := fmt.Sprintf("/%s/extensions/uninstall?id=%s", client.APIVersion, imageID)
-	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest(ctx, http.MethodPost, endpoint))
-	if errorPacket != nil || err != nil {
-		return displayAPICallResult(result, errorPacket, err)
+	result, err := rdClient.UninstallExtension(ctx, imageID)
+	if err != nil {
+		return displayAPIError(err)
 	}
 	msg := "no output from server"
-	if result != nil {
-		msg = string(result)
+	if result != "" {
+		msg = result
 	}
 	fmt.Printf("Uninstalling image %s: %s\n", imageID, msg)
 	return nil
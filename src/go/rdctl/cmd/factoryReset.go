@@ -39,6 +39,10 @@ Use the --remove-kubernetes-cache=BOOLEAN flag to also remove the cached Kuberne
 			return err
 		}
 		cmd.SilenceUsage = true
+		// The factory reset shuts the backend down, so don't restart it.
+		if err := snapshotFirstIfRequested(cmd, "rdctl factory-reset", false); err != nil {
+			return err
+		}
 		return performFactoryReset(cmd.Context(), removeKubernetesCache)
 	},
 }
@@ -46,4 +50,5 @@ Use the --remove-kubernetes-cache=BOOLEAN flag to also remove the cached Kuberne
 func init() {
 	rootCmd.AddCommand(factoryResetCmd)
 	factoryResetCmd.Flags().BoolVar(&removeKubernetesCache, "remove-kubernetes-cache", false, "If specified, also removes the cached Kubernetes images.")
+	addSnapshotFirstFlag(factoryResetCmd)
 }
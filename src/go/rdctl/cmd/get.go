@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get SETTING...",
+	Short: "Print selected settings",
+	Long: `Print the values of the given settings. Settings are named by their dotted
+path in the output of "rdctl list-settings", such as kubernetes.version, or by
+the names of the "rdctl set" flags, such as virtual-machine.memory-in-gb.
+
+With one setting, its value is printed on its own; table output prints strings
+without quotes, and other values as JSON. With several settings, table output
+prints a line for each setting, and other formats print an object keyed by
+the dotted paths of the settings.`,
+	Example: `  rdctl get kubernetes.version
+  rdctl get kubernetes.enabled virtual-machine.memory-in-gb -o json`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeSettingNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		paths := make([][]string, len(args))
+		for i, name := range args {
+			path, err := sdk.ResolveSettingPath(name)
+			if err != nil {
+				return err
+			}
+			paths[i] = path
+		}
+		rdClient, err := sdk.Connect()
+		if err != nil {
+			return err
+		}
+		contents, err := rdClient.GetSettingsJSON(cmd.Context())
+		if err != nil {
+			return err
+		}
+		settings, err := output.DecodeJSON(contents)
+		if err != nil {
+			return fmt.Errorf("failed to parse settings: %w", err)
+		}
+		values := make([]any, len(paths))
+		for i, path := range paths {
+			value, ok := sdk.LookupSetting(settings, path)
+			if !ok {
+				return fmt.Errorf("setting %q is not set", args[i])
+			}
+			values[i] = value
+		}
+		return printSettingValues(paths, values)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+}
+
+// Completes the names of the settings not given yet.
+func completeSettingNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	names := slices.DeleteFunc(sdk.SettingNames(), func(name string) bool {
+		return slices.Contains(args, name)
+	})
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func printSettingValues(paths [][]string, values []any) error {
+	var result any = values[0]
+	if len(values) > 1 {
+		object := make(map[string]any, len(values))
+		for i, path := range paths {
+			object[strings.Join(path, ".")] = values[i]
+		}
+		result = object
+	}
+	return printOutput(result, func(output io.Writer) error {
+		if len(values) == 1 {
+			text, err := settingValueText(values[0])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(output, text)
+			return err
+		}
+		writer := tabwriter.NewWriter(output, 0, 4, 1, ' ', 0)
+		for i, path := range paths {
+			text, err := settingValueText(values[i])
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(writer, "%s:\t%s\n", strings.Join(path, "."), text); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+// Returns value as text: strings as they are, and other values as JSON.
+func settingValueText(value any) (string, error) {
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	contents, err := json.Marshal(value)
+	return string(contents), err
+}
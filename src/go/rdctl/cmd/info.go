@@ -17,9 +17,9 @@ limitations under the License.
 package cmd
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
@@ -34,8 +34,7 @@ import (
 )
 
 var infoSettings struct {
-	Field  string
-	Output string
+	Field string
 }
 
 // infoCmd represents the `rdctl info` command
@@ -49,10 +48,6 @@ var infoCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(infoCmd)
 	infoCmd.Flags().StringVarP(&infoSettings.Field, "field", "f", "", "return only a specific field")
-	infoCmd.Flags().VarP(&enumValue{
-		val:     "text",
-		allowed: []string{"text", "json"},
-	}, "output", "o", "output format")
 }
 
 // Generates help text for each field available.
@@ -113,11 +108,11 @@ func doInfoCommand(cmd *cobra.Command, args []string) error {
 			field := typ.Field(i)
 			tag := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
 			if tag == infoSettings.Field {
-				_, err := fmt.Println(value.Field(i).Interface())
-				if err != nil {
+				fieldValue := value.Field(i).Interface()
+				return printOutput(fieldValue, func(writer io.Writer) error {
+					_, err := fmt.Fprintln(writer, fieldValue)
 					return err
-				}
-				return nil
+				})
 			}
 		}
 
@@ -140,15 +135,8 @@ func doInfoCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	switch cmd.Flags().Lookup("output").Value.String() {
-	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(result); err != nil {
-			return err
-		}
-	default:
-		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', 0)
+	return printOutput(result, func(output io.Writer) error {
+		writer := tabwriter.NewWriter(output, 0, 4, 1, ' ', 0)
 		value := reflect.ValueOf(result)
 		for i := range value.NumField() {
 			field := value.Type().Field(i)
@@ -160,10 +148,6 @@ func doInfoCommand(cmd *cobra.Command, args []string) error {
 				return err
 			}
 		}
-		if err := writer.Flush(); err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return writer.Flush()
+	})
 }
@@ -18,20 +18,18 @@ package cmd
 
 import (
 	"context"
-	"fmt"
-	"net/http"
+	"encoding/json"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 )
 
 // listSettingsCmd represents the listSettings command
 var listSettingsCmd = &cobra.Command{
 	Use:   "list-settings",
 	Short: "Lists the current settings.",
-	Long:  `Lists the current settings in JSON format.`,
+	Long:  `Lists the current settings in JSON format, or in the format chosen with --output.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
@@ -41,8 +39,7 @@ var listSettingsCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(result))
-		return nil
+		return printOutput(json.RawMessage(result), nil)
 	},
 }
 
@@ -51,11 +48,9 @@ func init() {
 }
 
 func getListSettings(ctx context.Context) ([]byte, error) {
-	connectionInfo, err := config.GetConnectionInfo(false)
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return []byte{}, fmt.Errorf("failed to get connection info: %w", err)
+		return []byte{}, err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
-	command := client.VersionCommand("", "settings")
-	return client.ProcessRequestForUtility(rdClient.DoRequest(ctx, http.MethodGet, command))
+	return rdClient.GetSettingsJSON(ctx)
 }
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/logs"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+var logsSettings struct {
+	Follow bool
+	Since  string
+	Grep   string
+	VM     bool
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [component...]",
+	Short: "Show the logs of Rancher Desktop components",
+	Long:  logsLongHelp(),
+	Example: `  rdctl logs lima --since 10m
+  rdctl logs kubernetes container-engine --vm --follow
+  rdctl logs --grep 'level=error'`,
+	ValidArgsFunction: completeLogComponents,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var since time.Time
+		if logsSettings.Since != "" {
+			var err error
+			if since, err = logs.ParseSince(logsSettings.Since, time.Now()); err != nil {
+				return err
+			}
+		}
+		var pattern *regexp.Regexp
+		if logsSettings.Grep != "" {
+			var err error
+			if pattern, err = regexp.Compile(logsSettings.Grep); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", logsSettings.Grep, err)
+			}
+		}
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		sources, err := logs.Sources(appPaths, args, logsSettings.VM)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
+		defer stop()
+		return showLogs(ctx, os.Stdout, sources, since, pattern, logsSettings.Follow)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVarP(&logsSettings.Follow, "follow", "f", false, "keep printing lines as they are logged, until interrupted")
+	logsCmd.Flags().StringVar(&logsSettings.Since, "since", "", "only show lines logged since a time, or a duration before now (e.g. 10m)")
+	logsCmd.Flags().StringVar(&logsSettings.Grep, "grep", "", "only show lines matching a regular expression")
+	logsCmd.Flags().BoolVar(&logsSettings.VM, "vm", false, "include logs in the VM, which must be running")
+}
+
+func logsLongHelp() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("Show the logs of the given components, or of every log file if none are\n")
+	_, _ = builder.WriteString("given, merged in the order they were logged. When several logs are shown,\n")
+	_, _ = builder.WriteString("each line starts with the name of its log. Lines without a timestamp are\n")
+	_, _ = builder.WriteString("taken to be logged with the line before them; with --since, lines before\n")
+	_, _ = builder.WriteString("the first timestamp in a log are not shown.\n")
+	_, _ = builder.WriteString("\n")
+	_, _ = builder.WriteString("The components are, with those that have logs in the VM marked with *:\n")
+	for _, component := range logs.Components {
+		marker := " "
+		if len(component.VMFiles) > 0 {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(&builder, "  %-17s%s %s\n", component.Name, marker, component.Description)
+	}
+	_, _ = builder.WriteString("\n")
+	_, _ = builder.WriteString("Any other log file in the logs directory can be named without \".log\".\n")
+	return builder.String()
+}
+
+func completeLogComponents(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, component := range logs.Components {
+		if !slices.Contains(args, component.Name) {
+			names = append(names, component.Name+"\t"+component.Description)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Prints the lines logged so far by the sources, merged by time, and then,
+// if follow is true, the lines logged after that as they arrive.
+func showLogs(ctx context.Context, output io.Writer, sources []logs.Source, since time.Time, pattern *regexp.Regexp, follow bool) error {
+	// Lines are prefixed by the name of their log if there may be more than
+	// one, padded to the longest name of a log that isn't empty.
+	prefix := len(sources) > 1
+	width := 0
+	writer := bufio.NewWriter(output)
+	printEntry := func(entry logs.Entry) {
+		if pattern != nil && !pattern.MatchString(entry.Line) {
+			return
+		}
+		if prefix {
+			fmt.Fprintf(writer, "%-*s | %s\n", width, entry.Source, entry.Line)
+		} else {
+			fmt.Fprintln(writer, entry.Line)
+		}
+	}
+
+	histories := make([][]logs.Entry, 0, len(sources))
+	for _, source := range sources {
+		entries, err := source.Read(ctx)
+		if err != nil {
+			logrus.Warnf("Failed to read %s: %s", source.Name(), err)
+			continue
+		}
+		if len(entries) > 0 {
+			width = max(width, len(source.Name()))
+		}
+		histories = append(histories, entries)
+	}
+	for _, entry := range logs.Merge(histories) {
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		printEntry(entry)
+	}
+	if err := writer.Flush(); err != nil || !follow {
+		return err
+	}
+
+	entries := make(chan logs.Entry)
+	var wg sync.WaitGroup
+	// The followers are stopped before waiting for them to finish.
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := source.Follow(ctx, entries); err != nil {
+				logrus.Warnf("Failed to follow %s: %s", source.Name(), err)
+			}
+		}()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-entries:
+			printEntry(entry)
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
@@ -19,9 +17,7 @@ var pathsCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to construct Paths: %w", err)
 		}
-		encoder := json.NewEncoder(os.Stdout)
-		err = encoder.Encode(paths)
-		if err != nil {
+		if err := printOutput(paths, nil); err != nil {
 			return fmt.Errorf("failed to output paths: %w", err)
 		}
 		return nil
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profiles"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named sets of settings",
+	Long: `Manage profiles: named sets of settings that can be applied together, such as
+one with Kubernetes enabled and containerd for platform work, and one with
+Kubernetes disabled and moby for application work. Profiles are stored in
+the Rancher Desktop config directory.
+
+These are unrelated to the deployment profiles made by "rdctl create-profile".`,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+}
+
+func newProfileManager() (*profiles.Manager, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	return profiles.NewManager(appPaths), nil
+}
+
+func completeProfileNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	manager, err := newProfileManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	list, err := manager.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	for _, profile := range list {
+		if !slices.Contains(args, profile.Name) {
+			names = append(names, profile.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+var profileApplySettings struct {
+	DryRun bool
+	Yes    bool
+}
+
+// The result of `rdctl profile apply`, as printed with --output.
+type profileApplyResult struct {
+	*settingsDryRun
+	// The status reported by the server; empty if nothing was changed.
+	Status string `json:"status"`
+}
+
+var profileApplyCmd = &cobra.Command{
+	Use:   "apply <name>...",
+	Short: "Apply the settings in profiles",
+	Long: `Apply the settings in one or more profiles with a single settings update;
+settings in later profiles override those in earlier ones.
+
+The settings that would change are shown first. If the changes would reset
+the VM, discarding its containers and images, you are asked to confirm them
+unless --yes is given. Use --dry-run to only show what would change, and
+--snapshot-first to take a snapshot first if the VM would be reset.`,
+	Example: `  rdctl profile apply platform
+  rdctl profile apply app big-vm --yes`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeProfileNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		settings, err := mergeProfiles(args)
+		if err != nil {
+			return err
+		}
+		return applyProfiles(cmd, args, settings)
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileApplyCmd)
+	addSnapshotFirstFlag(profileApplyCmd)
+	profileApplyCmd.Flags().BoolVar(&profileApplySettings.DryRun, "dry-run", false, "show what would change without changing anything")
+	profileApplyCmd.Flags().BoolVarP(&profileApplySettings.Yes, "yes", "y", false, "apply changes that reset the VM without asking")
+}
+
+// Returns the settings in the named profiles, with those in later profiles
+// overriding those in earlier ones.
+func mergeProfiles(names []string) (*options.ServerSettingsForJSON, error) {
+	manager, err := newProfileManager()
+	if err != nil {
+		return nil, err
+	}
+	var result *options.ServerSettingsForJSON
+	for i, name := range names {
+		settings, err := manager.Settings(name)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = settings
+			continue
+		}
+		if *settings.Version != *result.Version {
+			return nil, fmt.Errorf("profile %q has settings for version %d, and can't be combined with profile %q, for version %d",
+				name, *settings.Version, names[i-1], *result.Version)
+		}
+		if result, err = sdk.MergeSettings(result, settings); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func applyProfiles(cmd *cobra.Command, names []string, settings *options.ServerSettingsForJSON) error {
+	rdClient, err := sdk.Connect()
+	if err != nil {
+		return err
+	}
+	plan, err := planSettingsChanges(cmd.Context(), rdClient, settings)
+	if err != nil {
+		return err
+	}
+	if profileApplySettings.DryRun {
+		return printOutput(plan, plan.printTable)
+	}
+	if len(plan.Changes) == 0 {
+		return printOutput(profileApplyResult{settingsDryRun: plan}, func(writer io.Writer) error {
+			fmt.Fprintln(writer, "No settings would change.")
+			return nil
+		})
+	}
+	if isTableOutput() {
+		if err := plan.printTable(os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	description := fmt.Sprintf("profile %q", names[0])
+	if len(names) > 1 {
+		description = fmt.Sprintf("profiles %q", strings.Join(names, ", "))
+	}
+	if plan.Reset && !profileApplySettings.Yes {
+		confirmed, err := confirm(fmt.Sprintf("Applying %s will reset the VM, discarding its containers and images. Continue?", description))
+		if err != nil {
+			return fmt.Errorf("applying %s would reset the VM: %w (use --yes to apply it anyway)", description, err)
+		}
+		if !confirmed {
+			return fmt.Errorf("not applying %s", description)
+		}
+	}
+	if plan.Reset {
+		if should, err := shouldSnapshotFirst(cmd); err != nil {
+			return err
+		} else if should {
+			if err := createSnapshotFirst(cmd.Context(), "rdctl profile apply", true); err != nil {
+				return err
+			}
+		}
+	}
+
+	status, err := rdClient.UpdateSettings(cmd.Context(), settings)
+	if err != nil {
+		return err
+	}
+	return printOutput(profileApplyResult{settingsDryRun: plan, Status: status}, func(writer io.Writer) error {
+		if len(status) > 0 {
+			fmt.Fprintf(writer, "Status: %s.\n", status)
+		}
+		return nil
+	})
+}
+
+// Asks a yes or no question on the terminal; it is an error if standard
+// input isn't one.
+func confirm(question string) (bool, error) {
+	if !isTerminal(os.Stdin) {
+		return false, errors.New("can't ask for confirmation, as standard input is not a terminal")
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
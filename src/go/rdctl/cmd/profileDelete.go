@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var profileDeleteCmd = &cobra.Command{
+	Use:               "delete <name>...",
+	Aliases:           []string{"rm"},
+	Short:             "Delete profiles",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeProfileNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		manager, err := newProfileManager()
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, name := range args {
+			errs = append(errs, manager.Delete(name))
+		}
+		return errors.Join(errs...)
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileDeleteCmd)
+}
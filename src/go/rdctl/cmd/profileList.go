@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var profileListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List profiles",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		manager, err := newProfileManager()
+		if err != nil {
+			return err
+		}
+		list, err := manager.List()
+		if err != nil {
+			return err
+		}
+		return printOutput(list, func(output io.Writer) error {
+			if len(list) == 0 {
+				fmt.Fprintln(output, "No profiles have been saved.")
+				return nil
+			}
+			writer := tabwriter.NewWriter(output, 0, 4, 4, ' ', 0)
+			fmt.Fprintf(writer, "NAME\tMODIFIED\tSETTINGS\n")
+			for _, profile := range list {
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", profile.Name, profile.Modified.Local().Format(time.DateTime), strings.Join(profile.SettingNames(), ", "))
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+)
+
+var profileSaveSettings struct {
+	FromFile string
+	Force    bool
+}
+
+var profileSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save settings as a profile",
+	Long: `Save the settings given as flags, as with "rdctl set", or in a JSON or YAML
+file given with --from-file, as a profile. Settings given as flags override
+the ones in the file. Only the given settings are saved; applying the
+profile leaves the other settings as they are.`,
+	Example: `  rdctl profile save platform --kubernetes.enabled --container-engine.name containerd
+  rdctl profile save app --kubernetes.enabled=false --container-engine.name moby
+  rdctl profile save big-vm --from-file big-vm.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := options.UpdateFieldsForJSON(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		if profileSaveSettings.FromFile != "" {
+			settings, err = addSettingsFromFile(profileSaveSettings.FromFile, settings)
+			if err != nil {
+				return err
+			}
+		}
+		if settings == nil {
+			return fmt.Errorf("%s command: no settings to save were given", cmd.Name())
+		}
+		cmd.SilenceUsage = true
+		manager, err := newProfileManager()
+		if err != nil {
+			return err
+		}
+		return manager.Save(args[0], settings, profileSaveSettings.Force)
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileSaveCmd)
+	options.UpdateCommonStartAndSetCommands(profileSaveCmd)
+	profileSaveCmd.Flags().StringVar(&profileSaveSettings.FromFile, "from-file", "", "JSON or YAML file with the settings to save (- for standard input)")
+	profileSaveCmd.Flags().BoolVar(&profileSaveSettings.Force, "force", false, "replace the profile if it already exists")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var profileShowCmd = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Show the settings in a profile",
+	Long:              `Show the settings in a profile, as YAML unless another format is chosen with --output.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProfileNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		manager, err := newProfileManager()
+		if err != nil {
+			return err
+		}
+		profile, err := manager.Get(args[0])
+		if err != nil {
+			return err
+		}
+		return printOutput(profile.Settings, func(writer io.Writer) error {
+			encoder := yaml.NewEncoder(writer)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(profile.Settings); err != nil {
+				return err
+			}
+			return encoder.Close()
+		})
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileShowCmd)
+}
@@ -17,18 +17,14 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/factoryreset"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shutdown"
 )
 
@@ -48,7 +44,10 @@ Some reset options are combinations of others:
   * --factory includes --vm and --k8s (but not --cache)
   * --vm includes --k8s
 
-At least one option must be specified.`,
+At least one option must be specified.
+
+Use --snapshot-first to take a snapshot before resetting the VM or
+Kubernetes, so that the reset can be undone with "rdctl snapshot restore".`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
@@ -60,20 +59,33 @@ At least one option must be specified.`,
 			return fmt.Errorf("no reset options specified. Use --help to see available options")
 		}
 
+		if factoryReset || vmReset || k8sReset {
+			operation := "rdctl reset --k8s"
+			if factoryReset {
+				operation = "rdctl reset --factory"
+			} else if vmReset {
+				operation = "rdctl reset --vm"
+			}
+			// A factory reset shuts the backend down, so don't restart it.
+			if err := snapshotFirstIfRequested(cmd, operation, !factoryReset); err != nil {
+				return err
+			}
+		}
+
 		// Handle factory reset (includes VM, K8s and possibly cache reset)
 		if factoryReset {
 			return performFactoryReset(cmd.Context(), cacheReset)
 		}
 		if vmReset || k8sReset {
-			resetType := "wipe"
+			resetType := sdk.ResetVM
 			if !vmReset {
-				resetType = "fast"
+				resetType = sdk.ResetKubernetes
 			}
 			result, err := doReset(cmd.Context(), resetType)
 			if err != nil {
 				return err
 			}
-			fmt.Println(string(result))
+			fmt.Println(result)
 		}
 		// Handle cache reset if requested (and not already handled by factory reset)
 		if cacheReset {
@@ -84,11 +96,6 @@ At least one option must be specified.`,
 	},
 }
 
-// resetPayload defines the payload structure for reset requests
-type resetPayload struct {
-	Mode string `json:"mode"`
-}
-
 // performFactoryReset performs a factory reset with the given context and cache removal option
 func performFactoryReset(ctx context.Context, removeCache bool) error {
 	pathsCfg, err := paths.GetPaths()
@@ -104,28 +111,12 @@ func performFactoryReset(ctx context.Context, removeCache bool) error {
 }
 
 // doReset performs a reset with the specified mode
-func doReset(ctx context.Context, mode string) ([]byte, error) {
-	connectionInfo, err := config.GetConnectionInfo(false)
+func doReset(ctx context.Context, mode sdk.ResetMode) (string, error) {
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return []byte{}, fmt.Errorf("failed to get connection info: %w", err)
+		return "", err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
-	command := client.VersionCommand("", "k8s_reset")
-
-	payload := resetPayload{
-		Mode: mode,
-	}
-	jsonBuffer, err := json.Marshal(payload)
-	if err != nil {
-		return []byte{}, err
-	}
-	buf := bytes.NewBuffer(jsonBuffer)
-	result, err := client.ProcessRequestForUtility(rdClient.DoRequestWithPayload(ctx, http.MethodPut, command, buf))
-	if err != nil {
-		return result, err
-	}
-
-	return result, err
+	return rdClient.Reset(ctx, mode)
 }
 
 func init() {
@@ -134,4 +125,5 @@ func init() {
 	resetCmd.Flags().BoolVar(&k8sReset, "k8s", false, "Delete deployed Kubernetes workloads")
 	resetCmd.Flags().BoolVar(&cacheReset, "cache", false, "Delete cached Kubernetes images")
 	resetCmd.Flags().BoolVar(&factoryReset, "factory", false, "Delete VM and show first-run dialog on next start")
+	addSnapshotFirstFlag(resetCmd)
 }
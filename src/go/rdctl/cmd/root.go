@@ -18,40 +18,136 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/command"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 )
 
+// The format chosen with the global --output flag.
+var outputFormat output.Format
+
+// Set by the --json flag of some commands, a deprecated alias of
+// "--output json". Commands that print a value at a time, such as
+// `rdctl snapshot list`, still print one JSON object per line with it, and
+// errors are printed as JSON, as they were before --output was added.
+var outputJSONFormat bool
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:               "rdctl",
 	Short:             "A CLI for Rancher Desktop",
 	Long:              `The eventual goal of this CLI is to enable any UI-based operation to be done from the command-line as well.`,
-	PersistentPreRunE: config.PersistentPreRunE,
+	PersistentPreRunE: persistentPreRunE,
+}
+
+func persistentPreRunE(cmd *cobra.Command, args []string) error {
+	if err := applyJSONFlag(); err != nil {
+		return err
+	}
+	return config.PersistentPreRunE(cmd, args)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var fatalError command.FatalError
+		if errors.As(err, &fatalError) {
+			os.Exit(fatalError.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
+	mainCommand := ""
 	if len(os.Args) > 1 {
-		mainCommand := os.Args[1]
+		mainCommand = os.Args[1]
 		if mainCommand == "-h" || mainCommand == "help" || mainCommand == "--help" {
 			if len(os.Args) > 2 {
 				mainCommand = os.Args[2]
 			}
 		}
-		if mainCommand == "shell" || mainCommand == "version" || mainCommand == "completion" {
+		if mainCommand == "shell" || mainCommand == "completion" {
 			return
 		}
 	}
-	config.DefineGlobalFlags(rootCmd)
+	// The version command doesn't talk to the server, but it still takes
+	// --output.
+	if mainCommand != "version" {
+		config.DefineGlobalFlags(rootCmd)
+	}
+	rootCmd.PersistentFlags().VarP(&outputFormat, "output", "o", "output format of commands that print data: json, yaml, table, or template=TEMPLATE for a Go template")
+	_ = rootCmd.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return output.Formats, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	})
+}
+
+// The usage of the --json flag, for commands that print a single value.
+const jsonFlagUsage = `print JSON, and report errors as JSON (deprecated: use "--output json")`
+
+// Makes --json choose JSON output, like "--output json".
+func applyJSONFlag() error {
+	if !outputJSONFormat {
+		return nil
+	}
+	if name := outputFormat.Name(); name != "" && name != output.JSON {
+		return fmt.Errorf(`"--json" can't be used with "--output %s"`, name)
+	}
+	return outputFormat.Set(output.JSON)
+}
+
+// Prints value to standard output in the format chosen with --output; see
+// output.Format.Print.
+func printOutput(value any, table func(writer io.Writer) error) error {
+	return outputFormat.Print(os.Stdout, value, table)
+}
+
+// Reports whether printOutput prints tables, so that a command with a table
+// can print other messages for people to read without breaking the output.
+func isTableOutput() bool {
+	name := outputFormat.Name()
+	return name == "" || name == output.Table
+}
+
+// Returns an error if --output asks for a format that printStreamOutput
+// can't print, so that commands can fail before they start streaming.
+func checkStreamOutput() error {
+	if outputFormat.Name() == output.Table {
+		return output.ErrNoTable
+	}
+	return nil
+}
+
+// Prints one of a stream of values, such as events, in the format chosen
+// with --output. JSON is printed one value per line, YAML as one document
+// per value, and templates are executed once per value.
+func printStreamOutput(value any) error {
+	switch outputFormat.Name() {
+	case "", output.JSON:
+		return printJSON(value)
+	case output.YAML:
+		if _, err := fmt.Println("---"); err != nil {
+			return err
+		}
+	}
+	return printOutput(value, nil)
+}
+
+// Prints value as compact JSON on a line of its own.
+func printJSON(value any) error {
+	jsonBuffer, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonBuffer))
+	return nil
 }
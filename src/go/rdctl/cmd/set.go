@@ -17,23 +17,63 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
+	"maps"
+	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
 )
 
+var setSettings struct {
+	FromFile string
+	DryRun   bool
+}
+
+// The result of `rdctl set`, as printed with --output.
+type setResult struct {
+	// The status reported by the server, such as "reconfiguring".
+	Status string `json:"status"`
+}
+
+// The result of `rdctl set --dry-run`, as printed with --output.
+type settingsDryRun struct {
+	Changes []settingsChange `json:"changes"`
+	// Whether the backend would restart, and whether it would be reset.
+	Restart bool               `json:"restart"`
+	Reset   bool               `json:"reset"`
+	Reasons sdk.RestartReasons `json:"reasons"`
+	// The changes, for printing as a diff.
+	diff []settingsdiff.Change
+}
+
 // setCmd represents the set command
 var setCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Update selected fields in the Rancher Desktop UI and restart the backend.",
-	Long:  `Update selected fields in the Rancher Desktop UI and restart the backend.`,
+	Long: `Update selected fields in the Rancher Desktop UI and restart the backend.
+
+Use --from-file to read the settings to change from a JSON or YAML file (- for
+standard input) holding a partial settings object, such as:
+
+  kubernetes:
+    version: 1.30.1
+  virtualMachine:
+    memoryInGB: 8
+
+Settings given as flags override the ones in the file. Use --dry-run to show
+which settings would change, and whether the backend would restart, without
+changing anything.
+
+Use --snapshot-first to take a snapshot first if the changes would reset the
+VM, so that they can be undone with "rdctl snapshot restore".`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
@@ -45,38 +85,165 @@ var setCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(setCmd)
 	options.UpdateCommonStartAndSetCommands(setCmd)
+	addSnapshotFirstFlag(setCmd)
+	setCmd.Flags().StringVar(&setSettings.FromFile, "from-file", "", "JSON or YAML file with the settings to change (- for standard input)")
+	setCmd.Flags().BoolVar(&setSettings.DryRun, "dry-run", false, "show what would change without changing anything")
+}
+
+// proposedSettingsNeedReset asks the server whether applying changes would
+// discard the VM.
+func proposedSettingsNeedReset(ctx context.Context, rdClient *sdk.Client, changes *options.ServerSettingsForJSON) (bool, error) {
+	reasons, err := rdClient.ProposeSettings(ctx, changes)
+	if err != nil {
+		return false, err
+	}
+	return reasons.NeedsReset(), nil
 }
 
 func doSetCommand(cmd *cobra.Command) error {
-	connectionInfo, err := config.GetConnectionInfo(false)
+	rdClient, err := sdk.Connect()
 	if err != nil {
-		return fmt.Errorf("failed to get connection info: %w", err)
+		return err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
 
 	changedSettings, err := options.UpdateFieldsForJSON(cmd.Flags())
 	if err != nil {
 		cmd.SilenceUsage = true
 		return err
-	} else if changedSettings == nil {
+	}
+	if setSettings.FromFile != "" {
+		cmd.SilenceUsage = true
+		changedSettings, err = addSettingsFromFile(setSettings.FromFile, changedSettings)
+		if err != nil {
+			return err
+		}
+	}
+	if changedSettings == nil {
 		return fmt.Errorf("%s command: no settings to change were given", cmd.Name())
 	}
 	cmd.SilenceUsage = true
-	jsonBuffer, err := json.Marshal(changedSettings)
-	if err != nil {
+
+	if setSettings.DryRun {
+		return printSettingsDryRun(cmd.Context(), rdClient, changedSettings)
+	}
+
+	if should, err := shouldSnapshotFirst(cmd); err != nil {
 		return err
+	} else if should {
+		needsReset, err := proposedSettingsNeedReset(cmd.Context(), rdClient, changedSettings)
+		if err != nil {
+			return err
+		}
+		if needsReset {
+			if err := createSnapshotFirst(cmd.Context(), "rdctl set", true); err != nil {
+				return err
+			}
+		}
 	}
 
-	command := client.VersionCommand("", "settings")
-	buf := bytes.NewBuffer(jsonBuffer)
-	result, err := client.ProcessRequestForUtility(rdClient.DoRequestWithPayload(cmd.Context(), http.MethodPut, command, buf))
+	result, err := rdClient.UpdateSettings(cmd.Context(), changedSettings)
 	if err != nil {
 		return err
 	}
-	if len(result) > 0 {
-		fmt.Printf("Status: %s.\n", string(result))
+	return printOutput(setResult{Status: result}, func(writer io.Writer) error {
+		if len(result) > 0 {
+			fmt.Fprintf(writer, "Status: %s.\n", result)
+		} else {
+			fmt.Fprintf(writer, "Operation successfully returned with no output.")
+		}
+		return nil
+	})
+}
+
+// Returns the settings in the file at path, overridden by flagSettings
+// unless it is nil.
+func addSettingsFromFile(path string, flagSettings *options.ServerSettingsForJSON) (*options.ServerSettingsForJSON, error) {
+	var contents []byte
+	var err error
+	if path == "-" {
+		contents, err = io.ReadAll(os.Stdin)
 	} else {
-		fmt.Printf("Operation successfully returned with no output.")
+		contents, err = os.ReadFile(path)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+	fileSettings, err := sdk.ParseSettings(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if flagSettings == nil {
+		return fileSettings, nil
+	}
+	// The flags are in the current format, which older settings would be
+	// migrated from after merging.
+	if *fileSettings.Version != *flagSettings.Version {
+		return nil, fmt.Errorf("%s: settings for version %d can't be combined with settings given as flags", path, *fileSettings.Version)
+	}
+	return sdk.MergeSettings(fileSettings, flagSettings)
+}
+
+// Prints which settings applying changes would change, and whether the
+// backend would restart, without changing anything.
+func printSettingsDryRun(ctx context.Context, rdClient *sdk.Client, changes *options.ServerSettingsForJSON) error {
+	plan, err := planSettingsChanges(ctx, rdClient, changes)
+	if err != nil {
+		return err
+	}
+	return printOutput(plan, plan.printTable)
+}
+
+// Works out which settings applying changes would change, and whether the
+// backend would restart.
+func planSettingsChanges(ctx context.Context, rdClient *sdk.Client, changes *options.ServerSettingsForJSON) (*settingsDryRun, error) {
+	currentJSON, err := rdClient.GetSettingsJSON(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var current any
+	if err := json.Unmarshal(currentJSON, &current); err != nil {
+		return nil, fmt.Errorf("failed to parse current settings: %w", err)
+	}
+	partial, err := sdk.SettingsDocument(changes)
+	if err != nil {
+		return nil, err
+	}
+	diff := settingsdiff.DiffDocuments(current, sdk.MergeDocuments(current, partial))
+	reasons, err := rdClient.ProposeSettings(ctx, changes)
+	if err != nil {
+		return nil, err
+	}
+	if reasons == nil {
+		reasons = sdk.RestartReasons{}
+	}
+	return &settingsDryRun{
+		Changes: settingsChanges(diff),
+		Restart: len(reasons) > 0,
+		Reset:   reasons.NeedsReset(),
+		Reasons: reasons,
+		diff:    diff,
+	}, nil
+}
+
+func (plan *settingsDryRun) printTable(writer io.Writer) error {
+	printChanges(writer, "settings", plan.diff)
+	printRestartReasons(writer, plan.Reasons)
 	return nil
 }
+
+// Describes the restart that applying settings would cause.
+func printRestartReasons(writer io.Writer, reasons sdk.RestartReasons) {
+	if len(reasons) == 0 {
+		fmt.Fprintln(writer, "The backend would not need to restart.")
+		return
+	}
+	if reasons.NeedsReset() {
+		fmt.Fprintln(writer, "The backend would restart, and be reset (discarding the VM), because of:")
+	} else {
+		fmt.Fprintln(writer, "The backend would restart because of:")
+	}
+	keys := slices.Sorted(maps.Keys(reasons))
+	for _, key := range keys {
+		fmt.Fprintf(writer, "  %s (%s)\n", key, reasons[key].Severity)
+	}
+}
@@ -19,13 +19,13 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shutdown"
 )
 
@@ -65,9 +65,11 @@ func doShutdown(ctx context.Context, shutdownSettings *shutdownSettingsStruct, i
 	var output []byte
 	connectionInfo, err := config.GetConnectionInfo(true)
 	if err == nil && connectionInfo != nil {
-		rdClient := client.NewRDClient(connectionInfo)
-		command := client.VersionCommand("", "shutdown")
-		output, _ = client.ProcessRequestForUtility(rdClient.DoRequest(ctx, http.MethodPut, command))
+		rdClient := sdk.NewClient(client.NewRDClient(connectionInfo))
+		result, err := rdClient.Shutdown(ctx)
+		if err == nil {
+			output = []byte(result)
+		}
 		logrus.WithError(err).Trace("Shut down requested")
 	}
 	err = shutdown.FinishShutdown(ctx, shutdownSettings.WaitForShutdown, initiatingCommand)
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/command"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
 )
 
@@ -18,8 +22,6 @@ type errorPayloadType struct {
 	DataReset bool `json:"dataReset,omitempty"`
 }
 
-var outputJSONFormat bool
-
 var snapshotCmd = &cobra.Command{
 	Use:   "snapshot",
 	Short: "Manage Rancher Desktop snapshots",
@@ -34,6 +36,10 @@ func exitWithJSONOrErrorCondition(e error) error {
 		exitStatus := 0
 		if e != nil {
 			exitStatus = 1
+			var fatalError command.FatalError
+			if errors.As(e, &fatalError) {
+				exitStatus = fatalError.ExitCode()
+			}
 			errorPayload := errorPayloadType{
 				Error:     e.Error(),
 				DataReset: errors.Is(e, snapshot.ErrDataReset),
@@ -48,3 +54,22 @@ func exitWithJSONOrErrorCondition(e error) error {
 	}
 	return e
 }
+
+// Returns a context that is cancelled when rdctl is interrupted, at which
+// point cancelMessage is printed unless the output is JSON. The returned
+// function must be called once the context is no longer needed.
+func notifyContext(ctx context.Context, cancelMessage string) (context.Context, func()) {
+	// Ideally we would not use the deprecated syscall package,
+	// but it works well with all expected scenarios and allows us
+	// to avoid platform-specific signal handling code.
+	notifyCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
+	stopAfterFunc := context.AfterFunc(notifyCtx, func() {
+		if isTableOutput() {
+			fmt.Println(cancelMessage)
+		}
+	})
+	return notifyCtx, func() {
+		stopAfterFunc()
+		stop()
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotAutoSettings struct {
+	Enable  bool
+	Disable bool
+}
+
+// Set by --snapshot-first on commands that discard VM or Kubernetes state.
+var snapshotFirst bool
+
+var snapshotAutoCmd = &cobra.Command{
+	Use:   "auto",
+	Short: "Show or change whether snapshots are taken automatically",
+	Long: `Show or change whether a snapshot is taken automatically before rdctl
+commands that discard VM or Kubernetes state:
+
+  rdctl reset --vm, --k8s or --factory
+  rdctl factory-reset
+  rdctl set, when the changes require the VM to be reset
+
+When enabled, these commands behave as if given --snapshot-first; use
+--snapshot-first=false to skip the snapshot for a single command.
+
+Automatic snapshots are named after the time they were taken, and can be
+expired with "rdctl snapshot prune --automatic-only".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotAutoSettings.Enable && snapshotAutoSettings.Disable {
+			return fmt.Errorf(`can't specify both "--enable" and "--disable"`)
+		}
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(configureAutoSnapshots())
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotAutoCmd)
+	snapshotAutoCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotAutoCmd.Flags().BoolVar(&snapshotAutoSettings.Enable, "enable", false, "take snapshots before destructive commands")
+	snapshotAutoCmd.Flags().BoolVar(&snapshotAutoSettings.Disable, "disable", false, "stop taking snapshots before destructive commands")
+}
+
+func configureAutoSnapshots() error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	settings, err := manager.LoadAutoSnapshotSettings()
+	if err != nil {
+		return err
+	}
+	if snapshotAutoSettings.Enable || snapshotAutoSettings.Disable {
+		settings.BeforeDestructive = snapshotAutoSettings.Enable
+		if err := manager.SaveAutoSnapshotSettings(settings); err != nil {
+			return err
+		}
+	}
+	return printOutput(settings, func(writer io.Writer) error {
+		state := "disabled"
+		if settings.BeforeDestructive {
+			state = "enabled"
+		}
+		_, err := fmt.Fprintf(writer, "Automatic snapshots before destructive commands are %s.\n", state)
+		return err
+	})
+}
+
+// addSnapshotFirstFlag adds --snapshot-first to a command that discards VM
+// or Kubernetes state.
+func addSnapshotFirstFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&snapshotFirst, "snapshot-first", false,
+		`take a snapshot first (the default can be changed with "rdctl snapshot auto")`)
+}
+
+// shouldSnapshotFirst reports whether cmd should take a snapshot before
+// making destructive changes: either --snapshot-first was given, or
+// automatic snapshots are enabled and it wasn't turned off.
+func shouldSnapshotFirst(cmd *cobra.Command) (bool, error) {
+	if cmd.Flags().Changed("snapshot-first") {
+		return snapshotFirst, nil
+	}
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return false, fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	settings, err := manager.LoadAutoSnapshotSettings()
+	if err != nil {
+		return false, err
+	}
+	return settings.BeforeDestructive, nil
+}
+
+// createSnapshotFirst takes an automatic snapshot before operation, and
+// waits for the backend to start again so that the operation can proceed.
+// If restart is false, the backend is left stopped instead, for operations
+// such as a factory reset that would only shut it down again. The
+// destructive operation must not be attempted if this fails.
+func createSnapshotFirst(ctx context.Context, operation string, restart bool) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+
+	notifyCtx, stop := notifyContext(ctx, fmt.Sprintf("Cancelling snapshot; %s will not be run...", operation))
+	defer stop()
+	var finishProgress func()
+	manager.Progress, finishProgress = newSnapshotProgress()
+	created, err := manager.CreateAutomatic(notifyCtx, operation, restart)
+	finishProgress()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot before %s: %w", operation, err)
+	}
+	fmt.Fprintf(os.Stderr, "Created snapshot %q.\n", created.Name)
+	if err := autoPruneSnapshots(ctx, manager); err != nil {
+		logrus.Errorln(err)
+	}
+	if !restart {
+		return nil
+	}
+	return manager.WaitForStarted(notifyCtx)
+}
+
+// snapshotFirstIfRequested takes a snapshot before operation if
+// shouldSnapshotFirst says that cmd should, restarting the backend
+// afterwards as createSnapshotFirst does.
+func snapshotFirstIfRequested(cmd *cobra.Command, operation string, restart bool) error {
+	if should, err := shouldSnapshotFirst(cmd); err != nil || !should {
+		return err
+	}
+	return createSnapshotFirst(cmd.Context(), operation, restart)
+}
@@ -7,9 +7,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"os/signal"
 	"runtime"
-	"syscall"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -20,15 +19,40 @@ import (
 
 var snapshotDescription string
 var snapshotDescriptionFrom string
+var snapshotInclude []string
+var snapshotLabels []string
+var snapshotIncremental bool
+var snapshotParent string
 
 var snapshotCreateCmd = &cobra.Command{
 	Use:   "create <name>",
 	Short: "Create a snapshot",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a snapshot of the VM and settings. Optional components can be
+included with --include:
+
+  extensions  installed Rancher Desktop extensions
+  shims       containerd shims
+
+Restoring the snapshot puts back the included components, and leaves the
+others alone.
+
+Labels can be given as key=value with --label, and used to select snapshots
+with "rdctl snapshot list --selector".
+
+With --incremental, the VM disk is stored as a qcow2 overlay on the disk of
+the most recent snapshot (or the one given with --parent), which is much
+faster and smaller than a full copy on filesystems without copy-on-write
+support. An incremental snapshot can't be restored without the snapshots it
+depends on; "rdctl snapshot flatten" makes it standalone. Incremental
+snapshots are not supported on Windows.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if snapshotDescription != "" && snapshotDescriptionFrom != "" {
 			return fmt.Errorf(`can't specify more than one option from "--description" and "--description-from"`)
 		}
+		if snapshotParent != "" {
+			snapshotIncremental = true
+		}
 		cmd.SilenceUsage = true
 		if snapshotDescriptionFrom != "" {
 			var bytes []byte
@@ -49,9 +73,13 @@ var snapshotCreateCmd = &cobra.Command{
 
 func init() {
 	snapshotCmd.AddCommand(snapshotCreateCmd)
-	snapshotCreateCmd.Flags().BoolVar(&outputJSONFormat, "json", false, "output json format")
+	snapshotCreateCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
 	snapshotCreateCmd.Flags().StringVar(&snapshotDescription, "description", "", "snapshot description")
 	snapshotCreateCmd.Flags().StringVar(&snapshotDescriptionFrom, "description-from", "", "snapshot description from a file (or - for stdin)")
+	snapshotCreateCmd.Flags().StringSliceVar(&snapshotInclude, "include", nil, fmt.Sprintf("optional components to include (%s)", strings.Join(snapshot.OptionalComponents, ", ")))
+	snapshotCreateCmd.Flags().StringArrayVar(&snapshotLabels, "label", nil, "add a label, as key=value")
+	snapshotCreateCmd.Flags().BoolVar(&snapshotIncremental, "incremental", false, "store only the changes to the VM disk since the parent snapshot")
+	snapshotCreateCmd.Flags().StringVar(&snapshotParent, "parent", "", "the snapshot an incremental snapshot is based on (implies --incremental; default the most recent)")
 }
 
 func createSnapshot(ctx context.Context, args []string) error {
@@ -64,22 +92,35 @@ func createSnapshot(ctx context.Context, args []string) error {
 	if err := manager.ValidateName(name); err != nil {
 		return err
 	}
+	if err := snapshot.ValidateComponents(snapshotInclude); err != nil {
+		return err
+	}
+	labels, err := snapshot.ParseLabels(snapshotLabels)
+	if err != nil {
+		return err
+	}
 
-	// Ideally we would not use the deprecated syscall package,
-	// but it works well with all expected scenarios and allows us
-	// to avoid platform-specific signal handling code.
-	notifyCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot creation...")
 	defer stop()
-	stopAfterFunc := context.AfterFunc(notifyCtx, func() {
-		if !outputJSONFormat {
-			fmt.Println("Cancelling snapshot creation...")
-		}
-	})
-	defer stopAfterFunc()
-	_, err = manager.Create(notifyCtx, name, snapshotDescription)
+	var finishProgress func()
+	manager.Progress, finishProgress = newSnapshotProgress()
+	if snapshotIncremental {
+		_, err = manager.CreateIncremental(notifyCtx, name, snapshotDescription, snapshotParent, labels, snapshotInclude...)
+	} else {
+		_, err = manager.CreateWithLabels(notifyCtx, name, snapshotDescription, labels, snapshotInclude...)
+	}
+	finishProgress()
 	if err != nil && !errors.Is(err, runner.ErrContextDone) {
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
+	if err == nil {
+		if err := autoPruneSnapshots(ctx, manager); err != nil {
+			if outputJSONFormat {
+				return err
+			}
+			logrus.Errorln(err)
+		}
+	}
 
 	// exclude snapshots directory from time machine backups if on macOS
 	if runtime.GOOS != "darwin" {
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -8,10 +9,16 @@ import (
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
 )
 
+var snapshotDeleteRebase bool
+
 var snapshotDeleteCmd = &cobra.Command{
 	Use:   "delete <id>",
 	Short: "Delete a snapshot",
-	Args:  cobra.ExactArgs(1),
+	Long: `Delete a snapshot. Snapshots that incremental snapshots depend on are not
+deleted unless --rebase is given, in which case the dependent snapshots are
+rebased onto the parent of the deleted snapshot first (or made standalone if
+it has none).`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 		err := deleteSnapshot(cmd, args)
@@ -21,15 +28,23 @@ var snapshotDeleteCmd = &cobra.Command{
 
 func init() {
 	snapshotCmd.AddCommand(snapshotDeleteCmd)
-	snapshotDeleteCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, "output json format")
+	snapshotDeleteCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, jsonFlagUsage)
+	snapshotDeleteCmd.Flags().BoolVar(&snapshotDeleteRebase, "rebase", false, "rebase snapshots that depend on this one instead of refusing")
 }
 
-func deleteSnapshot(_ *cobra.Command, args []string) error {
+func deleteSnapshot(cmd *cobra.Command, args []string) error {
 	manager, err := snapshot.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create snapshot manager: %w", err)
 	}
-	if err = manager.Delete(args[0]); err != nil {
+	if snapshotDeleteRebase {
+		err = manager.DeleteRebasing(cmd.Context(), args[0])
+	} else {
+		err = manager.Delete(cmd.Context(), args[0])
+	}
+	if errors.Is(err, snapshot.ErrHasDependents) {
+		return fmt.Errorf("failed to delete snapshot %q: %w (use --rebase to rebase them first)", args[0], err)
+	} else if err != nil {
 		return fmt.Errorf("failed to delete snapshot %q: %w", args[0], err)
 	}
 	return nil
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+const (
+	diffFormatText      = "text"
+	diffFormatJSONPatch = "json-patch"
+)
+
+// An operation in a JSON Patch (RFC 6902) document.
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON omits the value of remove operations, which have none. Other
+// operations always have one, even if it is null.
+func (operation jsonPatchOperation) MarshalJSON() ([]byte, error) {
+	if operation.Op == settingsdiff.ChangeRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{operation.Op, operation.Path})
+	}
+	type withValue jsonPatchOperation
+	return json.Marshal(withValue(operation))
+}
+
+var snapshotDiffFormat string
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff <name>",
+	Short: "Show how a snapshot's settings differ from the current ones",
+	Long: `Compare the settings.json (and, on macOS and Linux, override.yaml) in a
+snapshot with the current working copies. Changes are shown as the
+changes that restoring the snapshot would make.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotDiffFormat != diffFormatText && snapshotDiffFormat != diffFormatJSONPatch {
+			return fmt.Errorf("invalid format %q: must be %q or %q", snapshotDiffFormat, diffFormatText, diffFormatJSONPatch)
+		}
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(diffSnapshot(args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotDiffCmd)
+	snapshotDiffCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotDiffCmd.Flags().StringVar(&snapshotDiffFormat, "format", diffFormatText, fmt.Sprintf("diff format (%q or %q)", diffFormatText, diffFormatJSONPatch))
+}
+
+func diffSnapshot(name string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	aSnapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return err
+	}
+	diffs, err := manager.Diff(aSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to compare snapshot %q: %w", name, err)
+	}
+	if snapshotDiffFormat == diffFormatJSONPatch {
+		return printJSON(jsonPatches(diffs))
+	}
+	return printOutput(diffs, func(writer io.Writer) error {
+		printDiffs(writer, diffs)
+		return nil
+	})
+}
+
+// Returns a JSON Patch document per file that turns the working copy into
+// the snapshot's copy.
+func jsonPatches(diffs []snapshot.FileDiff) map[string][]jsonPatchOperation {
+	result := map[string][]jsonPatchOperation{}
+	for _, diff := range diffs {
+		operations := []jsonPatchOperation{}
+		for _, change := range diff.Changes {
+			operations = append(operations, jsonPatchOperation{
+				Op:    change.Op,
+				Path:  change.JSONPointer(),
+				Value: change.New,
+			})
+		}
+		result[diff.File] = operations
+	}
+	return result
+}
+
+func printDiffs(writer io.Writer, diffs []snapshot.FileDiff) {
+	for _, diff := range diffs {
+		printChanges(writer, diff.File, diff.Changes)
+	}
+}
+
+// Prints the changes to the file with the given name.
+func printChanges(writer io.Writer, file string, changes []settingsdiff.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintf(writer, "%s: no changes\n", file)
+		return
+	}
+	fmt.Fprintf(writer, "%s:\n", file)
+	for _, change := range changes {
+		path := change.DottedPath()
+		if path == "" {
+			path = "(whole file)"
+		}
+		switch change.Op {
+		case settingsdiff.ChangeAdd:
+			fmt.Fprintf(writer, "  + %s: %s\n", path, formatDiffValue(change.New))
+		case settingsdiff.ChangeRemove:
+			fmt.Fprintf(writer, "  - %s: %s\n", path, formatDiffValue(change.Old))
+		default:
+			fmt.Fprintf(writer, "  ~ %s: %s -> %s\n", path, formatDiffValue(change.Old), formatDiffValue(change.New))
+		}
+	}
+}
+
+func formatDiffValue(value any) string {
+	jsonBuffer, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(jsonBuffer)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+func TestJSONPatches(t *testing.T) {
+	working := map[string]any{"replaced": true, "removed": 1, "kept": "same"}
+	saved := map[string]any{"replaced": nil, "added": nil, "kept": "same"}
+	diffs := []snapshot.FileDiff{{File: "settings.json", Changes: settingsdiff.DiffDocuments(working, saved)}}
+
+	contents, err := json.Marshal(jsonPatches(diffs))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"settings.json": [
+		{"op": "add", "path": "/added", "value": null},
+		{"op": "remove", "path": "/removed"},
+		{"op": "replace", "path": "/replaced", "value": null}
+	]}`, string(contents))
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotDuCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show how much disk space snapshots use",
+	Long: `Show the apparent size of each snapshot, and its exclusive size: the data
+that is not shared with other snapshots or the working files through
+copy-on-write clones, which is the space that deleting it would reclaim.
+The exclusive size is shown as "-" where it can't be determined.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(snapshotDiskUsage())
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotDuCmd)
+	snapshotDuCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+}
+
+func snapshotDiskUsage() error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	report, err := manager.DiskUsage()
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot disk usage: %w", err)
+	}
+	return printOutput(report, func(output io.Writer) error {
+		if len(report.Snapshots) == 0 {
+			fmt.Fprintln(os.Stderr, "No snapshots present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(output, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tAPPARENT\tEXCLUSIVE\n")
+		for _, usage := range report.Snapshots {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", usage.Name, formatBytes(usage.Apparent), formatOptionalBytes(usage.Exclusive))
+		}
+		fmt.Fprintf(writer, "TOTAL\t%s\t%s reclaimable\n", formatBytes(report.Apparent), formatOptionalBytes(report.Reclaimable))
+		return writer.Flush()
+	})
+}
+
+func formatOptionalBytes(size *int64) string {
+	if size == nil {
+		return "-"
+	}
+	return formatBytes(*size)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotEditSettings struct {
+	Description     string
+	DescriptionFrom string
+	Labels          []string
+	RemoveLabels    []string
+}
+
+var snapshotEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Change the description and labels of a snapshot",
+	Long: `Change the description and labels of a snapshot. Labels are given as
+key=value, and can be used to select snapshots with
+"rdctl snapshot list --selector".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		if flags.Changed("description") && flags.Changed("description-from") {
+			return fmt.Errorf(`can't specify more than one option from "--description" and "--description-from"`)
+		}
+		if !flags.Changed("description") && !flags.Changed("description-from") &&
+			len(snapshotEditSettings.Labels) == 0 && len(snapshotEditSettings.RemoveLabels) == 0 {
+			return fmt.Errorf(`nothing to change: specify "--description", "--description-from", "--label" or "--remove-label"`)
+		}
+		changes := snapshot.MetadataChanges{RemoveLabels: snapshotEditSettings.RemoveLabels}
+		var err error
+		if changes.SetLabels, err = snapshot.ParseLabels(snapshotEditSettings.Labels); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		if flags.Changed("description") {
+			changes.Description = &snapshotEditSettings.Description
+		} else if snapshotEditSettings.DescriptionFrom != "" {
+			var bytes []byte
+			if snapshotEditSettings.DescriptionFrom == "-" {
+				bytes, err = io.ReadAll(os.Stdin)
+			} else {
+				bytes, err = os.ReadFile(snapshotEditSettings.DescriptionFrom)
+			}
+			if err != nil {
+				return err
+			}
+			description := string(bytes)
+			changes.Description = &description
+		}
+		return exitWithJSONOrErrorCondition(editSnapshot(cmd.Context(), args[0], changes))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotEditCmd)
+	snapshotEditCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotEditCmd.Flags().StringVar(&snapshotEditSettings.Description, "description", "", "new snapshot description")
+	snapshotEditCmd.Flags().StringVar(&snapshotEditSettings.DescriptionFrom, "description-from", "", "new snapshot description from a file (or - for stdin)")
+	snapshotEditCmd.Flags().StringArrayVar(&snapshotEditSettings.Labels, "label", nil, "add or change a label, as key=value")
+	snapshotEditCmd.Flags().StringArrayVar(&snapshotEditSettings.RemoveLabels, "remove-label", nil, "remove the label with the given key")
+}
+
+func editSnapshot(ctx context.Context, name string, changes snapshot.MetadataChanges) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	if _, err := manager.Edit(ctx, name, changes); err != nil {
+		return fmt.Errorf("failed to edit snapshot %q: %w", name, err)
+	}
+	return nil
+}
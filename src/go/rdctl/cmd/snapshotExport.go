@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotExportCmd = &cobra.Command{
+	Use:   "export <name> <file>",
+	Short: "Export a snapshot to an archive file",
+	Long: `Export a snapshot to a compressed archive file. The archive contains
+a manifest with a checksum for each file, and can be imported on another
+machine with "rdctl snapshot import".`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(exportSnapshot(cmd.Context(), args[0], args[1]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotExportCmd)
+	snapshotExportCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+}
+
+func exportSnapshot(ctx context.Context, name, archivePath string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot export...")
+	defer stop()
+	err = manager.Export(notifyCtx, name, archivePath)
+	if err != nil && !errors.Is(err, runner.ErrContextDone) {
+		return fmt.Errorf("failed to export snapshot %q: %w", name, err)
+	}
+	return nil
+}
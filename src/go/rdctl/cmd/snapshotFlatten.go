@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotFlattenCmd = &cobra.Command{
+	Use:   "flatten <name>",
+	Short: "Make an incremental snapshot standalone",
+	Long: `Make an incremental snapshot standalone, by copying everything its VM disk
+is backed by into the disk. The snapshot then no longer depends on the
+snapshots it was based on, and can be exported. Snapshots that depend on it
+are unaffected.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(flattenSnapshot(cmd.Context(), args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotFlattenCmd)
+	snapshotFlattenCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+}
+
+func flattenSnapshot(ctx context.Context, name string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	if _, err := manager.Flatten(ctx, name); err != nil {
+		return fmt.Errorf("failed to flatten snapshot %q: %w", name, err)
+	}
+	return nil
+}
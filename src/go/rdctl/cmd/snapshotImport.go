@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotImportName string
+
+var snapshotImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a snapshot from an archive file",
+	Long: `Import a snapshot from an archive file created by "rdctl snapshot export".
+The archive is validated against its manifest before the snapshot is created.
+The snapshot keeps the name it was exported with unless --name is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(importSnapshot(cmd.Context(), args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotImportCmd)
+	snapshotImportCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotImportCmd.Flags().StringVar(&snapshotImportName, "name", "", "name for the imported snapshot")
+}
+
+func importSnapshot(ctx context.Context, archivePath string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	// Report on invalid names before reading the archive
+	if snapshotImportName != "" {
+		if err := manager.ValidateName(snapshotImportName); err != nil {
+			return err
+		}
+	}
+
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot import...")
+	defer stop()
+	_, err = manager.Import(notifyCtx, archivePath, snapshotImportName)
+	if err != nil && !errors.Is(err, runner.ErrContextDone) {
+		return fmt.Errorf("failed to import snapshot from %q: %w", archivePath, err)
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -38,60 +41,161 @@ var snapshotListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List snapshots",
-	Args:    cobra.NoArgs,
+	Long: `List snapshots. With --remote, list the snapshots stored in a remote added
+with "rdctl snapshot remote add" instead of the local snapshots.
+
+--selector only lists the snapshots whose labels match each of a
+comma-separated list of requirements:
+
+  key=value   the label is set to value
+  key!=value  the label is not set to value, or is not set
+  key         the label is set
+  !key        the label is not set`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotListSize && snapshotListRemote != "" {
+			return fmt.Errorf(`can't specify both "--size" and "--remote"`)
+		}
 		cmd.SilenceUsage = true
-		return exitWithJSONOrErrorCondition(listSnapshot())
+		return exitWithJSONOrErrorCondition(listSnapshot(cmd.Context()))
 	},
 }
 
+var snapshotListSize bool
+var snapshotListRemote string
+var snapshotListSelector string
+var snapshotListShowLabels bool
+
 func init() {
 	snapshotCmd.AddCommand(snapshotListCmd)
-	snapshotListCmd.Flags().BoolVar(&outputJSONFormat, "json", false, "output json format")
+	snapshotListCmd.Flags().BoolVar(&outputJSONFormat, "json", false, `print each snapshot as a line of JSON, and report errors as JSON (deprecated: use "--output json")`)
+	snapshotListCmd.Flags().BoolVar(&snapshotListSize, "size", false, "show the apparent and exclusive size of each snapshot")
+	snapshotListCmd.Flags().StringVar(&snapshotListRemote, "remote", "", "list the snapshots in the named remote")
+	snapshotListCmd.Flags().StringVarP(&snapshotListSelector, "selector", "l", "", "only list snapshots with matching labels (e.g. team=qa)")
+	snapshotListCmd.Flags().BoolVar(&snapshotListShowLabels, "show-labels", false, "show the labels of each snapshot")
 }
 
-func listSnapshot() error {
+func listSnapshot(ctx context.Context) error {
+	selector, err := snapshot.ParseSelector(snapshotListSelector)
+	if err != nil {
+		return err
+	}
 	manager, err := snapshot.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create snapshot manager: %w", err)
 	}
-	snapshots, err := manager.List(false)
-	if err != nil {
+	var snapshots []snapshot.Snapshot
+	if snapshotListRemote != "" {
+		storage, err := manager.RemoteStorage(snapshotListRemote)
+		if err != nil {
+			return err
+		}
+		if snapshots, err = storage.List(ctx); err != nil {
+			return fmt.Errorf("failed to list snapshots in %q: %w", snapshotListRemote, err)
+		}
+	} else if snapshots, err = manager.List(false); err != nil {
 		return fmt.Errorf("failed to list snapshots: %w", err)
 	}
+	snapshots = slices.DeleteFunc(snapshots, func(aSnapshot snapshot.Snapshot) bool {
+		return !selector.Matches(aSnapshot.Labels)
+	})
 	sort.Sort(SortableSnapshots(snapshots))
+	var usages map[string]snapshot.DiskUsage
+	if snapshotListSize {
+		report, err := manager.DiskUsage()
+		if err != nil {
+			return fmt.Errorf("failed to get snapshot disk usage: %w", err)
+		}
+		usages = make(map[string]snapshot.DiskUsage, len(report.Snapshots))
+		for _, usage := range report.Snapshots {
+			usages[usage.Name] = usage
+		}
+	}
+	documents, err := snapshotDocuments(snapshots, usages)
+	if err != nil {
+		return err
+	}
 	if outputJSONFormat {
-		return jsonOutput(snapshots)
+		for _, document := range documents {
+			if err := printStreamOutput(document); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	return tabularOutput(snapshots)
+	return printOutput(documents, func(writer io.Writer) error {
+		return tabularOutput(writer, snapshots, usages)
+	})
 }
 
-func jsonOutput(snapshots []snapshot.Snapshot) error {
+// Returns the JSON object describing each snapshot. If usages is not nil,
+// the objects include the apparent and exclusive size of each snapshot.
+func snapshotDocuments(snapshots []snapshot.Snapshot, usages map[string]snapshot.DiskUsage) ([]json.RawMessage, error) {
+	documents := make([]json.RawMessage, 0, len(snapshots))
 	for _, aSnapshot := range snapshots {
 		aSnapshot.ID = ""
+		aSnapshot.Files = nil
 		jsonBuffer, err := json.Marshal(aSnapshot)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if usages != nil {
+			var fields map[string]any
+			if err := json.Unmarshal(jsonBuffer, &fields); err != nil {
+				return nil, err
+			}
+			usage := usages[aSnapshot.Name]
+			fields["apparentSize"] = usage.Apparent
+			fields["exclusiveSize"] = usage.Exclusive
+			if jsonBuffer, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
 		}
-		fmt.Println(string(jsonBuffer))
+		documents = append(documents, jsonBuffer)
 	}
-	return nil
+	return documents, nil
 }
 
-func tabularOutput(snapshots []snapshot.Snapshot) error {
+func tabularOutput(output io.Writer, snapshots []snapshot.Snapshot, usages map[string]snapshot.DiskUsage) error {
 	if len(snapshots) == 0 {
 		fmt.Fprintln(os.Stderr, "No snapshots present.")
 		return nil
 	}
-	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintf(writer, "NAME\tCREATED\tDESCRIPTION\n")
+	writer := tabwriter.NewWriter(output, 0, 4, 4, ' ', 0)
+	header := []string{"NAME", "CREATED"}
+	if usages != nil {
+		header = append(header, "APPARENT", "EXCLUSIVE")
+	}
+	if snapshotListShowLabels {
+		header = append(header, "LABELS")
+	}
+	fmt.Fprintln(writer, strings.Join(append(header, "DESCRIPTION"), "\t"))
 	for _, aSnapshot := range snapshots {
-		prettyCreated := aSnapshot.Created.Format(time.RFC1123)
-		desc := truncateAtNewlineOrMaxRunes(aSnapshot.Description, tableMaxRunes)
-		fmt.Fprintf(writer, "%s\t%s\t%s\n", aSnapshot.Name, prettyCreated, desc)
+		row := []string{aSnapshot.Name, aSnapshot.Created.Format(time.RFC1123)}
+		if usages != nil {
+			usage := usages[aSnapshot.Name]
+			row = append(row, formatBytes(usage.Apparent), formatOptionalBytes(usage.Exclusive))
+		}
+		if snapshotListShowLabels {
+			row = append(row, formatLabels(aSnapshot.Labels))
+		}
+		row = append(row, truncateAtNewlineOrMaxRunes(aSnapshot.Description, tableMaxRunes))
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// Formats labels as a sorted, comma-separated list of key=value.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "<none>"
+	}
+	formatted := make([]string, 0, len(labels))
+	for key, value := range labels {
+		formatted = append(formatted, key+"="+value)
 	}
-	writer.Flush()
-	return nil
+	sort.Strings(formatted)
+	return strings.Join(formatted, ",")
 }
 
 // Truncates a string to either the first newline or a maximum number of
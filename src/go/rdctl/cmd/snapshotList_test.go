@@ -58,3 +58,8 @@ func TestTruncateToNewlineOrMaxRunes(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatLabels(t *testing.T) {
+	assert.Equal(t, "<none>", formatLabels(nil))
+	assert.Equal(t, "env=staging,team=qa", formatLabels(map[string]string{"team": "qa", "env": "staging"}))
+}
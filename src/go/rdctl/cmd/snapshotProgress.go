@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+const (
+	progressBarWidth = 30
+	// How often the progress bar is redrawn.
+	progressBarInterval = 100 * time.Millisecond
+	// How often a JSON progress event is written for a file that is still
+	// being copied.
+	progressJSONInterval = 500 * time.Millisecond
+)
+
+type progressEvent struct {
+	Type string `json:"type"`
+	snapshot.Progress
+}
+
+// snapshotProgress renders the progress of the files copied while creating or
+// restoring a snapshot.
+type snapshotProgress struct {
+	writer    io.Writer
+	json      bool
+	files     map[string]snapshot.Progress
+	reported  map[string]time.Time
+	completed map[string]bool
+	lastDrawn time.Time
+}
+
+// Returns a callback for snapshot.Manager.Progress, and a function to call
+// once the operation is done. With --json, progress is written as NDJSON
+// events on stdout; otherwise a progress bar is drawn on stderr if it is a
+// terminal. If neither applies, the callback is nil.
+func newSnapshotProgress() (snapshot.ProgressFunc, func()) {
+	progress := &snapshotProgress{
+		files:     map[string]snapshot.Progress{},
+		reported:  map[string]time.Time{},
+		completed: map[string]bool{},
+	}
+	switch {
+	case outputJSONFormat:
+		progress.writer = os.Stdout
+		progress.json = true
+	case isTerminal(os.Stderr):
+		progress.writer = os.Stderr
+	default:
+		return nil, func() {}
+	}
+	return progress.update, progress.finish
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (progress *snapshotProgress) update(update snapshot.Progress) {
+	progress.files[update.File] = update
+	if progress.json {
+		progress.writeEvent(update)
+	} else {
+		progress.drawBar(false)
+	}
+}
+
+func (progress *snapshotProgress) finish() {
+	if !progress.json && !progress.lastDrawn.IsZero() {
+		progress.drawBar(true)
+		fmt.Fprintln(progress.writer)
+	}
+}
+
+func (progress *snapshotProgress) writeEvent(update snapshot.Progress) {
+	done := update.Copied == update.Total
+	if progress.completed[update.File] {
+		return
+	}
+	lastReported, seen := progress.reported[update.File]
+	if seen && !done && time.Since(lastReported) < progressJSONInterval {
+		return
+	}
+	progress.reported[update.File] = time.Now()
+	progress.completed[update.File] = done
+	jsonBuffer, err := json.Marshal(progressEvent{Type: "progress", Progress: update})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(progress.writer, string(jsonBuffer))
+}
+
+func (progress *snapshotProgress) drawBar(force bool) {
+	if !force && time.Since(progress.lastDrawn) < progressBarInterval {
+		return
+	}
+	progress.lastDrawn = time.Now()
+	var copied, total int64
+	names := make([]string, 0, len(progress.files))
+	for name, file := range progress.files {
+		copied += file.Copied
+		total += file.Total
+		if file.Copied < file.Total {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fraction := 1.0
+	if total > 0 {
+		fraction = float64(copied) / float64(total)
+	}
+	filled := int(fraction * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	line := fmt.Sprintf("[%s] %3.0f%% %s / %s", bar, fraction*100, formatBytes(copied), formatBytes(total))
+	if len(names) > 0 {
+		line += " " + strings.Join(names, ", ")
+	}
+	// Clear the rest of the line, in case the previous one was longer.
+	fmt.Fprintf(progress.writer, "\r%s\033[K", line)
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TiB", value)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotPruneSettings struct {
+	Policy      snapshot.RetentionPolicy
+	DryRun      bool
+	SavePolicy  bool
+	ClearPolicy bool
+}
+
+type prunePayload struct {
+	DryRun     bool     `json:"dryRun"`
+	Snapshots  []string `json:"snapshots"`
+	Incomplete []string `json:"incomplete"`
+}
+
+var snapshotPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old snapshots",
+	Long: `Delete snapshots according to a retention policy, along with any incomplete
+snapshots left behind by interrupted snapshot operations.
+
+--keep-last N keeps the N most recently created snapshots.
+--older-than AGE only deletes snapshots older than AGE (for example 30d, 2w or 12h).
+When both are given, a snapshot is only deleted if neither rule keeps it.
+--automatic-only limits the rules to snapshots taken automatically before
+destructive commands (see "rdctl snapshot auto"); other snapshots are kept.
+
+If no policy is given on the command line, the saved policy is used. Use
+--save-policy to save the given policy; it is then also applied after each
+"rdctl snapshot create". Use --clear-policy to remove the saved policy.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotPruneSettings.SavePolicy && snapshotPruneSettings.ClearPolicy {
+			return fmt.Errorf(`can't specify both "--save-policy" and "--clear-policy"`)
+		}
+		if snapshotPruneSettings.SavePolicy && snapshotPruneSettings.Policy.IsEmpty() {
+			return fmt.Errorf(`"--save-policy" requires "--keep-last" or "--older-than"`)
+		}
+		if err := snapshotPruneSettings.Policy.Validate(); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(pruneSnapshots(cmd))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotPruneCmd)
+	snapshotPruneCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotPruneCmd.Flags().IntVar(&snapshotPruneSettings.Policy.KeepLast, "keep-last", 0, "keep this many of the most recent snapshots")
+	snapshotPruneCmd.Flags().StringVar(&snapshotPruneSettings.Policy.OlderThan, "older-than", "", "only delete snapshots older than this (e.g. 30d)")
+	snapshotPruneCmd.Flags().BoolVar(&snapshotPruneSettings.Policy.AutomaticOnly, "automatic-only", false, "only delete automatic snapshots")
+	snapshotPruneCmd.Flags().BoolVar(&snapshotPruneSettings.DryRun, "dry-run", false, "show what would be deleted without deleting anything")
+	snapshotPruneCmd.Flags().BoolVar(&snapshotPruneSettings.SavePolicy, "save-policy", false, "save the policy and apply it after each snapshot is created")
+	snapshotPruneCmd.Flags().BoolVar(&snapshotPruneSettings.ClearPolicy, "clear-policy", false, "remove the saved policy")
+}
+
+func pruneSnapshots(cmd *cobra.Command) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	policy := snapshotPruneSettings.Policy
+	switch {
+	case snapshotPruneSettings.ClearPolicy:
+		return manager.SaveRetentionPolicy(snapshot.RetentionPolicy{})
+	case snapshotPruneSettings.SavePolicy:
+		if err := manager.SaveRetentionPolicy(policy); err != nil {
+			return err
+		}
+	case !cmd.Flags().Changed("keep-last") && !cmd.Flags().Changed("older-than"):
+		savedPolicy, err := manager.LoadRetentionPolicy()
+		if err != nil {
+			return err
+		}
+		if savedPolicy != nil {
+			policy = *savedPolicy
+		}
+		if cmd.Flags().Changed("automatic-only") {
+			policy.AutomaticOnly = snapshotPruneSettings.Policy.AutomaticOnly
+		}
+	}
+	result, err := manager.Prune(cmd.Context(), policy, snapshotPruneSettings.DryRun)
+	if outputErr := printPruneResult(result, snapshotPruneSettings.DryRun); outputErr != nil {
+		return outputErr
+	}
+	return err
+}
+
+// autoPruneSnapshots applies the saved retention policy, if there is one.
+func autoPruneSnapshots(ctx context.Context, manager *snapshot.Manager) error {
+	policy, err := manager.LoadRetentionPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+	result, err := manager.Prune(ctx, *policy, false)
+	// The result is only reported along with the output of the command that
+	// applied the policy when that output is meant for people to read.
+	if isTableOutput() {
+		if outputErr := printPruneResult(result, false); outputErr != nil {
+			return outputErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply snapshot retention policy: %w", err)
+	}
+	return nil
+}
+
+func printPruneResult(result snapshot.PruneResult, dryRun bool) error {
+	payload := prunePayload{
+		DryRun:     dryRun,
+		Snapshots:  make([]string, 0, len(result.Snapshots)),
+		Incomplete: result.Incomplete,
+	}
+	for _, aSnapshot := range result.Snapshots {
+		payload.Snapshots = append(payload.Snapshots, aSnapshot.Name)
+	}
+	return printOutput(payload, func(writer io.Writer) error {
+		verb := "Deleted"
+		if dryRun {
+			verb = "Would delete"
+		}
+		for _, aSnapshot := range result.Snapshots {
+			if _, err := fmt.Fprintf(writer, "%s snapshot %q (created %s)\n", verb, aSnapshot.Name, aSnapshot.Created.Format(time.RFC1123)); err != nil {
+				return err
+			}
+		}
+		for _, dirName := range result.Incomplete {
+			if _, err := fmt.Fprintf(writer, "%s incomplete snapshot %s\n", verb, dirName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotPullSettings struct {
+	Remote string
+	Name   string
+}
+
+var snapshotPullCmd = &cobra.Command{
+	Use:   "pull <name> --remote <remote>",
+	Short: "Copy a snapshot from a remote",
+	Long: `Copy a snapshot from a remote added with "rdctl snapshot remote add". The
+snapshot is validated against the checksums in its archive before it is
+created. It keeps its name unless --name is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(pullSnapshot(cmd.Context(), args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotPullCmd)
+	snapshotPullCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotPullCmd.Flags().StringVar(&snapshotPullSettings.Remote, "remote", "", "name of the remote to pull from")
+	snapshotPullCmd.Flags().StringVar(&snapshotPullSettings.Name, "name", "", "name for the pulled snapshot")
+	_ = snapshotPullCmd.MarkFlagRequired("remote")
+}
+
+func pullSnapshot(ctx context.Context, name string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	storage, err := manager.RemoteStorage(snapshotPullSettings.Remote)
+	if err != nil {
+		return err
+	}
+
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot pull...")
+	defer stop()
+	_, err = manager.Pull(notifyCtx, storage, name, snapshotPullSettings.Name)
+	if err != nil && !errors.Is(err, runner.ErrContextDone) {
+		return fmt.Errorf("failed to pull snapshot %q from %q: %w", name, snapshotPullSettings.Remote, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotPushRemote string
+
+var snapshotPushCmd = &cobra.Command{
+	Use:   "push <name> --remote <remote>",
+	Short: "Copy a snapshot to a remote",
+	Long: `Copy a snapshot to a remote added with "rdctl snapshot remote add", so that
+it can be pulled on another machine with "rdctl snapshot pull". The remote
+must not already have a snapshot with the same name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(pushSnapshot(cmd.Context(), args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotPushCmd)
+	snapshotPushCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	snapshotPushCmd.Flags().StringVar(&snapshotPushRemote, "remote", "", "name of the remote to push to")
+	_ = snapshotPushCmd.MarkFlagRequired("remote")
+}
+
+func pushSnapshot(ctx context.Context, name string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	storage, err := manager.RemoteStorage(snapshotPushRemote)
+	if err != nil {
+		return err
+	}
+
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot push...")
+	defer stop()
+	err = manager.Push(notifyCtx, name, storage)
+	if err != nil && !errors.Is(err, runner.ErrContextDone) {
+		return fmt.Errorf("failed to push snapshot %q to %q: %w", name, snapshotPushRemote, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotRemoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manage remote snapshot storage",
+	Long: `Manage the remotes that snapshots can be pushed to and pulled from.
+
+A remote is either a directory, given as an absolute path or a file:/// URL,
+or a bucket on an S3-compatible object store, given as
+
+  s3://bucket[/prefix][?endpoint=URL][&region=REGION]
+
+The endpoint is needed for stores other than Amazon S3, such as MinIO. The
+credentials for S3 remotes are read from the AWS_ACCESS_KEY_ID,
+AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables; they are
+never saved.`,
+}
+
+var snapshotRemoteAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a remote",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(addSnapshotRemote(args[0], args[1]))
+	},
+}
+
+var snapshotRemoteListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List remotes",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(listSnapshotRemotes())
+	},
+}
+
+var snapshotRemoteRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a remote",
+	Long:    "Remove a remote. Snapshots stored in it are not deleted.",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(removeSnapshotRemote(args[0]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotRemoteCmd)
+	snapshotRemoteCmd.AddCommand(snapshotRemoteAddCmd, snapshotRemoteListCmd, snapshotRemoteRemoveCmd)
+	for _, command := range []*cobra.Command{snapshotRemoteAddCmd, snapshotRemoteListCmd, snapshotRemoteRemoveCmd} {
+		command.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+	}
+}
+
+func addSnapshotRemote(name, remoteURL string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	if err := manager.AddRemote(snapshot.Remote{Name: name, URL: remoteURL}); err != nil {
+		return fmt.Errorf("failed to add remote: %w", err)
+	}
+	return nil
+}
+
+func listSnapshotRemotes() error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	remotes, err := manager.Remotes()
+	if err != nil {
+		return fmt.Errorf("failed to list remotes: %w", err)
+	}
+	return printOutput(remotes, func(output io.Writer) error {
+		if len(remotes) == 0 {
+			fmt.Fprintln(os.Stderr, "No remotes configured.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(output, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tURL\n")
+		for _, remote := range remotes {
+			fmt.Fprintf(writer, "%s\t%s\n", remote.Name, remote.URL)
+		}
+		return writer.Flush()
+	})
+}
+
+func removeSnapshotRemote(name string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	if err := manager.RemoveRemote(name); err != nil {
+		return fmt.Errorf("failed to remove remote: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+var snapshotRenameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename a snapshot",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(renameSnapshot(cmd.Context(), args[0], args[1]))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotRenameCmd)
+	snapshotRenameCmd.Flags().BoolVar(&outputJSONFormat, "json", false, jsonFlagUsage)
+}
+
+func renameSnapshot(ctx context.Context, name, newName string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	if _, err := manager.Rename(ctx, name, newName); err != nil {
+		return fmt.Errorf("failed to rename snapshot %q: %w", name, err)
+	}
+	return nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os/signal"
-	"syscall"
+	"io"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
@@ -23,9 +23,14 @@ var snapshotRestoreCmd = &cobra.Command{
 	},
 }
 
+var snapshotRestoreForce bool
+var snapshotRestoreDryRun bool
+
 func init() {
 	snapshotCmd.AddCommand(snapshotRestoreCmd)
-	snapshotRestoreCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, "output json format")
+	snapshotRestoreCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, jsonFlagUsage)
+	snapshotRestoreCmd.Flags().BoolVar(&snapshotRestoreForce, "force", false, "restore even if the snapshot was created by an incompatible version")
+	snapshotRestoreCmd.Flags().BoolVar(&snapshotRestoreDryRun, "dry-run", false, "show what would change without restoring")
 }
 
 func restoreSnapshot(name string) error {
@@ -34,20 +39,51 @@ func restoreSnapshot(name string) error {
 		return fmt.Errorf("failed to create snapshot manager: %w", err)
 	}
 
-	// Ideally we would not use the deprecated syscall package,
-	// but it works well with all expected scenarios and allows us
-	// to avoid platform-specific signal handling code.
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
-	defer stop()
-	stopAfterFunc := context.AfterFunc(ctx, func() {
-		if !outputJSONFormat {
-			fmt.Println("Cancelling snapshot restoration...")
+	if aSnapshot, err := manager.Snapshot(name); err == nil {
+		warnings, err := manager.CheckCompatibility(aSnapshot)
+		if err != nil && (snapshotRestoreForce || snapshotRestoreDryRun) {
+			warnings = append(warnings, err.Error())
 		}
-	})
-	defer stopAfterFunc()
-	err = manager.Restore(ctx, name)
-	if err != nil && !errors.Is(err, runner.ErrContextDone) {
+		for _, warning := range warnings {
+			logrus.Warnln(warning)
+		}
+	}
+
+	if snapshotRestoreDryRun {
+		return planSnapshotRestore(manager, name)
+	}
+
+	ctx, stop := notifyContext(context.Background(), "Cancelling snapshot restoration...")
+	defer stop()
+	var finishProgress func()
+	manager.Progress, finishProgress = newSnapshotProgress()
+	err = manager.Restore(ctx, name, snapshotRestoreForce)
+	finishProgress()
+	if errors.Is(err, snapshot.ErrIncompatible) {
+		return fmt.Errorf("failed to restore snapshot %q: %w; use --force to restore it anyway", name, err)
+	} else if err != nil && !errors.Is(err, runner.ErrContextDone) {
 		return fmt.Errorf("failed to restore snapshot %q: %w", name, err)
 	}
 	return nil
 }
+
+// Prints what restoring a snapshot would change, without taking the backend
+// lock.
+func planSnapshotRestore(manager *snapshot.Manager, name string) error {
+	aSnapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return err
+	}
+	plan, err := manager.PlanRestore(aSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to compare snapshot %q: %w", name, err)
+	}
+	return printOutput(plan, func(writer io.Writer) error {
+		printDiffs(writer, plan.Diffs)
+		fmt.Fprintln(writer, "Files that would be replaced:")
+		for _, path := range plan.Replaced {
+			fmt.Fprintf(writer, "  %s\n", path)
+		}
+		return nil
+	})
+}
@@ -1,14 +1,19 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
 )
 
+var snapshotUnlockStatus bool
+
 var snapshotUnlockCmd = &cobra.Command{
 	Use:   "unlock",
 	Short: "Remove snapshot lock",
@@ -16,23 +21,62 @@ var snapshotUnlockCmd = &cobra.Command{
 lock that is used to prevent simultaneous snapshot operations can be
 left behind. It then becomes impossible to run any snapshot operations.
 This command removes the filesystem lock. It should not be needed under
-normal circumstances.`,
+normal circumstances: a lock left behind by a process that is no longer
+running is removed automatically. Use --status to show who holds the lock
+without removing it.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
-		return exitWithJSONOrErrorCondition(unlockSnapshot(cmd.Context()))
+		if snapshotUnlockStatus {
+			return exitWithJSONOrErrorCondition(showLockStatus())
+		}
+		return exitWithJSONOrErrorCondition(unlockSnapshot())
 	},
 }
 
 func init() {
 	snapshotCmd.AddCommand(snapshotUnlockCmd)
-	snapshotUnlockCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, "output json format")
+	snapshotUnlockCmd.Flags().BoolVarP(&outputJSONFormat, "json", "", false, jsonFlagUsage)
+	snapshotUnlockCmd.Flags().BoolVar(&snapshotUnlockStatus, "status", false, "show the state of the lock instead of removing it")
 }
 
-func unlockSnapshot(ctx context.Context) error {
-	manager, err := snapshot.NewManager()
+func unlockSnapshot() error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	return lock.Remove(appPaths)
+}
+
+func showLockStatus() error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	status, err := lock.Status(appPaths)
 	if err != nil {
-		return fmt.Errorf("failed to create snapshot manager: %w", err)
+		return err
 	}
-	return manager.Unlock(ctx, manager.Paths, false)
+	return printOutput(status, func(output io.Writer) error {
+		if !status.Locked {
+			_, err := fmt.Fprintln(output, "The backend is not locked.")
+			return err
+		}
+		writer := tabwriter.NewWriter(output, 0, 4, 4, ' ', 0)
+		if data := status.Data; data != nil {
+			fmt.Fprintf(writer, "Action:\t%s\n", data.Action)
+			if data.PID != 0 {
+				fmt.Fprintf(writer, "PID:\t%d\n", data.PID)
+				fmt.Fprintf(writer, "Host:\t%s\n", data.Hostname)
+				fmt.Fprintf(writer, "Locked since:\t%s\n", data.Created.Local().Format(time.RFC3339))
+				fmt.Fprintf(writer, "rdctl version:\t%s\n", data.Version)
+			}
+		}
+		state := "held"
+		if status.Stale {
+			state = "stale"
+		}
+		fmt.Fprintf(writer, "Status:\t%s (%s)\n", state, status.Reason)
+		return writer.Flush()
+	})
 }
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+const (
+	verifyStatusOK         = "ok"
+	verifyStatusUnverified = "unverified"
+	verifyStatusCorrupt    = "corrupt"
+)
+
+type verifyResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+var snapshotVerifyAll bool
+
+var snapshotVerifyCmd = &cobra.Command{
+	Use:   "verify [<name> | --all]",
+	Short: "Verify the integrity of snapshots",
+	Long: `Check that the files in a snapshot have the sizes and checksums that were
+recorded when the snapshot was created. Snapshots created by older versions
+of Rancher Desktop have no recorded checksums and are reported as unverified.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotVerifyAll == (len(args) == 1) {
+			return fmt.Errorf(`exactly one of a snapshot name or "--all" must be specified`)
+		}
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(verifySnapshots(cmd.Context(), args))
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotVerifyCmd)
+	snapshotVerifyCmd.Flags().BoolVar(&outputJSONFormat, "json", false, `print each result as a line of JSON, and report errors as JSON (deprecated: use "--output json")`)
+	snapshotVerifyCmd.Flags().BoolVar(&snapshotVerifyAll, "all", false, "verify all snapshots")
+}
+
+func verifySnapshots(ctx context.Context, args []string) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	var snapshots []snapshot.Snapshot
+	if snapshotVerifyAll {
+		if snapshots, err = manager.List(false); err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		sort.Sort(SortableSnapshots(snapshots))
+	} else {
+		aSnapshot, err := manager.Snapshot(args[0])
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, aSnapshot)
+	}
+
+	notifyCtx, stop := notifyContext(ctx, "Cancelling snapshot verification...")
+	defer stop()
+
+	// Results are printed as each snapshot is verified, unless they are
+	// printed as a single document with --output.
+	streaming := outputJSONFormat || isTableOutput()
+	results := make([]verifyResult, 0, len(snapshots))
+	failures := 0
+	cancelled := false
+	for _, aSnapshot := range snapshots {
+		result := verifyResult{Name: aSnapshot.Name, Status: verifyStatusOK}
+		err := manager.Verify(notifyCtx, aSnapshot)
+		if errors.Is(err, runner.ErrContextDone) {
+			cancelled = true
+			break
+		} else if errors.Is(err, snapshot.ErrNoChecksums) {
+			result.Status = verifyStatusUnverified
+		} else if err != nil {
+			result.Status = verifyStatusCorrupt
+			result.Error = err.Error()
+			failures++
+		}
+		if streaming {
+			if err := printVerifyResult(os.Stdout, result); err != nil {
+				return err
+			}
+		}
+		results = append(results, result)
+	}
+	if !streaming {
+		if err := printOutput(results, nil); err != nil {
+			return err
+		}
+	}
+	if failures > 0 && !cancelled {
+		return fmt.Errorf("%d snapshot(s) failed verification", failures)
+	}
+	return nil
+}
+
+func printVerifyResult(writer io.Writer, result verifyResult) error {
+	if outputJSONFormat {
+		return printStreamOutput(result)
+	}
+	var err error
+	switch result.Status {
+	case verifyStatusOK:
+		_, err = fmt.Fprintf(writer, "%s: OK\n", result.Name)
+	case verifyStatusUnverified:
+		_, err = fmt.Fprintf(writer, "%s: no checksums recorded\n", result.Name)
+	default:
+		_, err = fmt.Fprintf(writer, "%s: FAILED: %s\n", result.Name, result.Error)
+	}
+	return err
+}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
@@ -25,14 +26,22 @@ import (
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
 )
 
+type versionInfo struct {
+	Version    string `json:"version"`
+	APIVersion string `json:"apiVersion"`
+}
+
 // showVersionCmd represents the showVersion command
 var showVersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Shows the CLI version.",
 	Long:  `Shows the CLI version.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := fmt.Printf("rdctl client version: %s, targeting server version: %s\n", version.Version, client.APIVersion)
-		return err
+		info := versionInfo{Version: version.Version, APIVersion: client.APIVersion}
+		return printOutput(info, func(writer io.Writer) error {
+			_, err := fmt.Fprintf(writer, "rdctl client version: %s, targeting server version: %s\n", info.Version, info.APIVersion)
+			return err
+		})
 	},
 }
 
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/command"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/kubeconfig"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+// The exit codes of `rdctl wait`, which tell scripts why waiting failed.
+const (
+	waitExitTimeout    = 2
+	waitExitErrorState = 3
+	waitExitNotRunning = 4
+)
+
+const k8sReadyCondition = "k8s-ready"
+
+var waitSettings struct {
+	Conditions []string
+	Timeout    time.Duration
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for Rancher Desktop to reach a state",
+	Long: `Wait until Rancher Desktop meets all of the conditions given with --for:
+
+  state=STATE[,STATE...]  the backend is in one of the given states: STOPPED,
+                          STARTING, STARTED, STOPPING, ERROR, or DISABLED
+                          (running with Kubernetes disabled)
+  k8s-ready               the backend is STARTED, and the Kubernetes API server
+                          of the rancher-desktop context reports that it is
+                          ready
+
+Without --for, waits for state=STARTED,DISABLED.
+
+Rancher Desktop doesn't need to be running yet; it is polled until the
+timeout. The exit status tells why waiting failed:
+
+  1  an unexpected error
+  2  timed out
+  3  the backend is in the ERROR state, or Kubernetes is disabled while
+     waiting for k8s-ready
+  4  timed out, and Rancher Desktop is not running
+
+With --output, every change in the observed state is written in that
+format; JSON is written as an object on its own line.`,
+	Example: `  rdctl start && rdctl wait --for k8s-ready --timeout 15m`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conditions, err := parseWaitConditions(waitSettings.Conditions)
+		if err != nil {
+			return err
+		}
+		if err := checkStreamOutput(); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return exitWithJSONOrErrorCondition(doWait(cmd.Context(), conditions, waitSettings.Timeout))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(waitCmd)
+	waitCmd.Flags().StringArrayVar(&waitSettings.Conditions, "for", nil, fmt.Sprintf(`condition to wait for: "state=STATE[,STATE...]" or %q (can be repeated)`, k8sReadyCondition))
+	waitCmd.Flags().DurationVar(&waitSettings.Timeout, "timeout", 10*time.Minute, "how long to wait")
+	waitCmd.Flags().BoolVar(&outputJSONFormat, "json", false, `report each state change as a line of JSON, and errors as JSON (deprecated: use "--output json")`)
+}
+
+// waitConditions are the conditions that `rdctl wait` waits for.
+type waitConditions struct {
+	// The backend must be in one of these states.
+	States          []string
+	KubernetesReady bool
+}
+
+func parseWaitConditions(specs []string) (waitConditions, error) {
+	var conditions waitConditions
+	for _, spec := range specs {
+		if spec == k8sReadyCondition {
+			conditions.KubernetesReady = true
+			continue
+		}
+		value, ok := strings.CutPrefix(spec, "state=")
+		if !ok {
+			return conditions, fmt.Errorf(`invalid condition %q: must be "state=STATE" or %q`, spec, k8sReadyCondition)
+		}
+		if conditions.States != nil {
+			return conditions, errors.New("only one state condition can be given; list the states it allows separated by commas")
+		}
+		conditions.States = []string{}
+		for state := range strings.SplitSeq(value, ",") {
+			state = strings.ToUpper(strings.TrimSpace(state))
+			if !slices.Contains(backendStates, state) {
+				return conditions, fmt.Errorf("invalid state %q: must be one of %s", state, strings.Join(backendStates, ", "))
+			}
+			conditions.States = append(conditions.States, state)
+		}
+	}
+	if conditions.KubernetesReady {
+		if conditions.States != nil && !slices.Contains(conditions.States, client.StateStarted) {
+			return conditions, fmt.Errorf("%s can't be combined with a state condition that doesn't allow %s", k8sReadyCondition, client.StateStarted)
+		}
+		conditions.States = []string{client.StateStarted}
+	} else if conditions.States == nil {
+		conditions.States = []string{client.StateStarted, client.StateDisabled}
+	}
+	return conditions, nil
+}
+
+var backendStates = []string{
+	client.StateStopped,
+	client.StateStarting,
+	client.StateStarted,
+	client.StateStopping,
+	client.StateError,
+	client.StateDisabled,
+}
+
+// waitObservation is what `rdctl wait` observed in one poll; with --output,
+// each one that differs from the last is reported.
+type waitObservation struct {
+	Time time.Time `json:"time"`
+	// False while the Rancher Desktop API can't be reached.
+	Running bool   `json:"running"`
+	VMState string `json:"vmState,omitempty"`
+	Locked  bool   `json:"locked,omitempty"`
+	// Only checked with k8s-ready, once the backend has started.
+	KubernetesReady *bool `json:"kubernetesReady,omitempty"`
+	// Why Kubernetes isn't ready, if it was checked.
+	KubernetesError string `json:"kubernetesError,omitempty"`
+}
+
+// Reports whether the observations are the same, apart from when they
+// were made.
+func (observation waitObservation) sameAs(other waitObservation) bool {
+	return observation.Running == other.Running &&
+		observation.VMState == other.VMState &&
+		observation.Locked == other.Locked &&
+		(observation.KubernetesReady == nil) == (other.KubernetesReady == nil) &&
+		(observation.KubernetesReady == nil || *observation.KubernetesReady == *other.KubernetesReady)
+}
+
+func (observation waitObservation) meets(conditions waitConditions) bool {
+	if !observation.Running || !slices.Contains(conditions.States, observation.VMState) {
+		return false
+	}
+	return !conditions.KubernetesReady || (observation.KubernetesReady != nil && *observation.KubernetesReady)
+}
+
+// Observes the state of Rancher Desktop. The connection settings are read
+// again on every poll, as they change when the application restarts.
+func observeForWait(ctx context.Context, conditions waitConditions) (waitObservation, error) {
+	observation := waitObservation{Time: time.Now()}
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil {
+		return observation, fmt.Errorf("failed to get connection info: %w", err)
+	} else if connectionInfo == nil {
+		return observation, nil
+	}
+	state, err := sdk.NewClient(client.NewRDClient(connectionInfo)).GetBackendState(ctx)
+	if errors.Is(err, client.ErrConnectionRefused) {
+		return observation, nil
+	} else if err != nil {
+		return observation, fmt.Errorf("failed to get backend state: %w", err)
+	}
+	observation.Running = true
+	observation.VMState = state.VMState
+	observation.Locked = state.Locked
+	if conditions.KubernetesReady && state.VMState == client.StateStarted {
+		ready := false
+		if kubeConfig, err := kubeconfig.Load(); err != nil {
+			observation.KubernetesError = err.Error()
+		} else if err := kubeConfig.Ready(ctx, kubeconfig.RancherDesktopContext); err != nil {
+			observation.KubernetesError = err.Error()
+		} else {
+			ready = true
+		}
+		observation.KubernetesReady = &ready
+	}
+	return observation, nil
+}
+
+func doWait(ctx context.Context, conditions waitConditions, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// The latest observation, and the last one that was reported; changes
+	// that sameAs ignores, such as why Kubernetes isn't ready, aren't
+	// reported, but are still given when timing out.
+	var latest, reported *waitObservation
+	err := sdk.Poll(ctx, func() (bool, error) {
+		observation, err := observeForWait(ctx, conditions)
+		if ctx.Err() != nil {
+			// The observation was cut short by the timeout.
+			return false, ctx.Err()
+		} else if err != nil {
+			return false, err
+		}
+		latest = &observation
+		if reported == nil || !observation.sameAs(*reported) {
+			var outputErr error
+			if outputFormat.Name() != "" {
+				outputErr = printStreamOutput(observation)
+			}
+			if outputErr != nil {
+				return false, outputErr
+			}
+			reported = &observation
+		}
+		if observation.meets(conditions) {
+			return true, nil
+		}
+		if observation.VMState == client.StateError && !slices.Contains(conditions.States, client.StateError) {
+			return false, command.NewFatalError("the Rancher Desktop backend is in the ERROR state", waitExitErrorState)
+		}
+		if conditions.KubernetesReady && observation.VMState == client.StateDisabled {
+			return false, command.NewFatalError("Kubernetes is disabled", waitExitErrorState)
+		}
+		return false, nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return waitTimeoutError(timeout, latest)
+	}
+	return err
+}
+
+// Returns the error for timing out, describing latest, the last
+// observation made, if any.
+func waitTimeoutError(timeout time.Duration, latest *waitObservation) error {
+	if latest == nil || !latest.Running {
+		return command.NewFatalError(fmt.Sprintf("timed out after %s: Rancher Desktop is not running", timeout), waitExitNotRunning)
+	}
+	message := fmt.Sprintf("timed out after %s: the backend state is %s", timeout, latest.VMState)
+	if latest.KubernetesError != "" {
+		message += "; " + latest.KubernetesError
+	}
+	return command.NewFatalError(message, waitExitTimeout)
+}
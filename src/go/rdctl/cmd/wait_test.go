@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/command"
+)
+
+func TestParseWaitConditions(t *testing.T) {
+	t.Run("The default should be a running backend", func(t *testing.T) {
+		conditions, err := parseWaitConditions(nil)
+		require.NoError(t, err)
+		assert.Equal(t, waitConditions{States: []string{"STARTED", "DISABLED"}}, conditions)
+	})
+
+	t.Run("States should be parsed", func(t *testing.T) {
+		conditions, err := parseWaitConditions([]string{"state=stopped,error"})
+		require.NoError(t, err)
+		assert.Equal(t, waitConditions{States: []string{"STOPPED", "ERROR"}}, conditions)
+	})
+
+	t.Run("k8s-ready should need a started backend", func(t *testing.T) {
+		conditions, err := parseWaitConditions([]string{"k8s-ready", "state=STARTED"})
+		require.NoError(t, err)
+		assert.Equal(t, waitConditions{States: []string{"STARTED"}, KubernetesReady: true}, conditions)
+		_, err = parseWaitConditions([]string{"k8s-ready", "state=DISABLED"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid conditions should be rejected", func(t *testing.T) {
+		for _, spec := range [][]string{{"state=RUNNING"}, {"ready"}, {"state=STARTED", "state=STOPPED"}} {
+			_, err := parseWaitConditions(spec)
+			assert.Error(t, err, "%v", spec)
+		}
+	})
+}
+
+func TestWaitObservationMeets(t *testing.T) {
+	ready, notReady := true, false
+	conditions := waitConditions{States: []string{"STARTED"}, KubernetesReady: true}
+	assert.False(t, waitObservation{}.meets(conditions))
+	assert.False(t, waitObservation{Running: true, VMState: "STARTED"}.meets(conditions))
+	assert.False(t, waitObservation{Running: true, VMState: "STARTED", KubernetesReady: &notReady}.meets(conditions))
+	assert.True(t, waitObservation{Running: true, VMState: "STARTED", KubernetesReady: &ready}.meets(conditions))
+	assert.False(t, waitObservation{Running: true, VMState: "STARTED", KubernetesReady: &ready}.sameAs(
+		waitObservation{Running: true, VMState: "STARTED", KubernetesReady: &notReady}))
+}
+
+func TestWaitTimeoutError(t *testing.T) {
+	notReady := false
+	err := waitTimeoutError(time.Minute, nil)
+	assert.EqualError(t, err, "timed out after 1m0s: Rancher Desktop is not running")
+	err = waitTimeoutError(time.Minute, &waitObservation{Running: true, VMState: "STARTED", KubernetesReady: &notReady, KubernetesError: "connection refused"})
+	assert.EqualError(t, err, "timed out after 1m0s: the backend state is STARTED; connection refused")
+	var fatalError command.FatalError
+	require.ErrorAs(t, err, &fatalError)
+	assert.Equal(t, waitExitTimeout, fatalError.ExitCode())
+}
@@ -20,6 +20,16 @@ const (
 
 var ErrConnectionRefused = errors.New("connection refused")
 
+// The states of the backend, as reported in BackendState.VMState.
+const (
+	StateStopped  = "STOPPED"
+	StateStarting = "STARTING"
+	StateStarted  = "STARTED"
+	StateStopping = "STOPPING"
+	StateError    = "ERROR"
+	StateDisabled = "DISABLED"
+)
+
 type BackendState struct {
 	VMState string `json:"vmState"`
 	Locked  bool   `json:"locked"`
@@ -29,6 +39,34 @@ type BackendState struct {
 type APIError struct {
 	Message          *string `json:"message,omitempty"`
 	DocumentationURL *string `json:"documentation_url,omitempty"`
+	// The HTTP status code of the response; set by ProcessResponse.
+	StatusCode int `json:"-"`
+	// The body of the response, which usually explains the error; set by
+	// ProcessResponse.
+	Body string `json:"-"`
+}
+
+func (apiError *APIError) Error() string {
+	status := http.StatusText(apiError.StatusCode)
+	if apiError.Message != nil {
+		status = *apiError.Message
+	}
+	// Note that the status includes the status code.
+	switch apiError.StatusCode {
+	case http.StatusBadRequest:
+		// Prefer the error message in the body written by the command-server, not the one from the http server.
+		if apiError.Body != "" {
+			return apiError.Body
+		}
+	case http.StatusUnauthorized:
+		return fmt.Sprintf("%s: user/password not accepted", status)
+	case http.StatusInternalServerError:
+		return fmt.Sprintf("%s: server-side problem: please consult the server logs for more information", status)
+	}
+	if apiError.Body != "" {
+		return fmt.Sprintf("%s: %s", status, apiError.Body)
+	}
+	return status
 }
 
 type RDClient interface {
@@ -39,7 +77,7 @@ type RDClient interface {
 }
 
 func validateBackendState(state BackendState) error {
-	validStates := []string{"STOPPED", "STARTING", "STARTED", "STOPPING", "ERROR", "DISABLED"}
+	validStates := []string{StateStopped, StateStarting, StateStarted, StateStopping, StateError, StateDisabled}
 	if slices.Contains(validStates, state.VMState) {
 		return nil
 	}
@@ -48,11 +86,13 @@ func validateBackendState(state BackendState) error {
 
 type RDClientImpl struct {
 	connectionInfo *config.ConnectionInfo
+	httpClient     *http.Client
 }
 
 func NewRDClient(connectionInfo *config.ConnectionInfo) *RDClientImpl {
 	return &RDClientImpl{
 		connectionInfo: connectionInfo,
+		httpClient:     newHTTPClient(connectionInfo),
 	}
 }
 
@@ -68,7 +108,7 @@ func (client *RDClientImpl) DoRequest(ctx context.Context, method, command strin
 	if err != nil {
 		return nil, err
 	}
-	return http.DefaultClient.Do(req)
+	return client.httpClient.Do(req)
 }
 
 func (client *RDClientImpl) DoRequestWithPayload(ctx context.Context, method, command string, payload io.Reader) (*http.Response, error) {
@@ -77,10 +117,10 @@ func (client *RDClientImpl) DoRequestWithPayload(ctx context.Context, method, co
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(client.connectionInfo.User, client.connectionInfo.Password)
+	client.setAuth(req)
 	req.Header.Add("Content-Type", "application/json")
 	req.Close = true
-	return http.DefaultClient.Do(req)
+	return client.httpClient.Do(req)
 }
 
 func (client *RDClientImpl) getRequestObject(ctx context.Context, method, command string) (*http.Request, error) {
@@ -89,12 +129,20 @@ func (client *RDClientImpl) getRequestObject(ctx context.Context, method, comman
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(client.connectionInfo.User, client.connectionInfo.Password)
+	client.setAuth(req)
 	req.Header.Add("Content-Type", "text/plain")
 	req.Close = true
 	return req, nil
 }
 
+// Adds the user and password to req, unless it is sent over the socket,
+// where access is controlled by its permissions instead.
+func (client *RDClientImpl) setAuth(req *http.Request) {
+	if client.connectionInfo.Socket == "" {
+		req.SetBasicAuth(client.connectionInfo.User, client.connectionInfo.Password)
+	}
+}
+
 func (client *RDClientImpl) GetBackendState(ctx context.Context) (BackendState, error) {
 	command := VersionCommand("", "backend_state")
 	body, err := ProcessRequestForUtility(client.DoRequest(ctx, http.MethodGet, command))
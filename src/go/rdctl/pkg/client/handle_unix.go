@@ -9,7 +9,7 @@ import (
 )
 
 func handleConnectionRefused(err error) error {
-	if errors.Is(err, unix.ECONNREFUSED) {
+	if errors.Is(err, unix.ECONNREFUSED) || errors.Is(err, ErrConnectionRefused) {
 		return ErrConnectionRefused
 	}
 	return err
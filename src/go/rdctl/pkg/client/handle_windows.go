@@ -7,7 +7,7 @@ import (
 )
 
 func handleConnectionRefused(err error) error {
-	if errors.Is(err, windows.WSAECONNREFUSED) {
+	if errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, ErrConnectionRefused) {
 		return ErrConnectionRefused
 	}
 	return err
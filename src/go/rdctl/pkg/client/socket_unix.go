@@ -0,0 +1,22 @@
+//go:build unix
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Connects to the Unix domain socket at path. A socket that doesn't exist
+// means that the server isn't running, just like a refused connection.
+func dialSocket(ctx context.Context, path string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrConnectionRefused, err)
+	}
+	return conn, err
+}
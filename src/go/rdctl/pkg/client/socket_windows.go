@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const pipePrefix = `\\.\pipe\`
+
+// How long to wait before trying again to open a named pipe that has no
+// free instances.
+const pipeBusyRetryInterval = 10 * time.Millisecond
+
+// Connects to the named pipe at path, or to a Unix domain socket if path is
+// not a named pipe. A pipe or socket that doesn't exist means that the
+// server isn't running, just like a refused connection.
+func dialSocket(ctx context.Context, path string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if strings.HasPrefix(path, pipePrefix) {
+		conn, err = dialPipe(ctx, path)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "unix", path)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrConnectionRefused, err)
+	}
+	return conn, err
+}
+
+func dialPipe(ctx context.Context, path string) (net.Conn, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		handle, err := windows.CreateFile(pathPtr, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil,
+			windows.OPEN_EXISTING, windows.FILE_FLAG_OVERLAPPED, 0)
+		if err == nil {
+			return &pipeConn{handle: handle, path: path}, nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) {
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pipeBusyRetryInterval):
+		}
+	}
+}
+
+// pipeConn is a client connection to a named pipe. It uses overlapped I/O,
+// as the HTTP transport reads the response while the request is still
+// being written; with synchronous I/O, the write would wait for the read.
+type pipeConn struct {
+	handle    windows.Handle
+	path      string
+	closeOnce sync.Once
+}
+
+type pipeAddr string
+
+func (addr pipeAddr) Network() string { return "pipe" }
+func (addr pipeAddr) String() string  { return string(addr) }
+
+func (conn *pipeConn) Read(buf []byte) (int, error) {
+	n, err := conn.do(windows.ReadFile, buf)
+	if errors.Is(err, windows.ERROR_BROKEN_PIPE) || errors.Is(err, windows.ERROR_PIPE_NOT_CONNECTED) {
+		return n, io.EOF
+	}
+	return n, err
+}
+
+func (conn *pipeConn) Write(buf []byte) (int, error) {
+	return conn.do(windows.WriteFile, buf)
+}
+
+// Runs a read or write, and waits for it to complete.
+func (conn *pipeConn) do(op func(windows.Handle, []byte, *uint32, *windows.Overlapped) error, buf []byte) (int, error) {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = windows.CloseHandle(event) }()
+	// The operation refers to overlapped until it completes, so it must not
+	// be on the stack, which can move.
+	overlapped := &windows.Overlapped{HEvent: event}
+	var done uint32
+	err = op(conn.handle, buf, &done, overlapped)
+	if err == nil || errors.Is(err, windows.ERROR_IO_PENDING) {
+		err = windows.GetOverlappedResult(conn.handle, overlapped, &done, true)
+	}
+	if errors.Is(err, windows.ERROR_OPERATION_ABORTED) {
+		err = net.ErrClosed
+	}
+	return int(done), err
+}
+
+func (conn *pipeConn) Close() error {
+	err := net.ErrClosed
+	conn.closeOnce.Do(func() {
+		// Reads and writes in progress would otherwise keep waiting.
+		_ = windows.CancelIoEx(conn.handle, nil)
+		err = windows.CloseHandle(conn.handle)
+	})
+	return err
+}
+
+func (conn *pipeConn) LocalAddr() net.Addr  { return pipeAddr(conn.path) }
+func (conn *pipeConn) RemoteAddr() net.Addr { return pipeAddr(conn.path) }
+
+// Deadlines are not supported; the HTTP client cancels a request by closing
+// its connection instead.
+func (conn *pipeConn) SetDeadline(time.Time) error      { return nil }
+func (conn *pipeConn) SetReadDeadline(time.Time) error  { return nil }
+func (conn *pipeConn) SetWriteDeadline(time.Time) error { return nil }
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+)
+
+// Returns the HTTP client used to talk to the server described by
+// connectionInfo. If it has a socket, requests are sent over it instead of
+// TCP; they still go to a URL made from the host and port, which then only
+// matters for the Host header.
+func newHTTPClient(connectionInfo *config.ConnectionInfo) *http.Client {
+	if connectionInfo.Socket == "" {
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed in place of the server, and then replaced by
+	// the socket.
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialSocket(ctx, connectionInfo.Socket)
+	}
+	return &http.Client{Transport: transport}
+}
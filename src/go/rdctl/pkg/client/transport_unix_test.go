@@ -0,0 +1,82 @@
+//go:build unix
+
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+)
+
+// Starts a server that reports the given backend state, listening on
+// listener, or on TCP if listener is nil.
+func newBackendStateServer(t *testing.T, listener net.Listener, vmState string) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"vmState": "` + vmState + `"}`))
+	}))
+	if listener != nil {
+		server.Listener = listener
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serverPort(t *testing.T, server *httptest.Server) int {
+	_, portString, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portString)
+	require.NoError(t, err)
+	return port
+}
+
+func TestSocketTransport(t *testing.T) {
+	t.Run("Requests should be sent over the socket", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "rd-engine.sock")
+		listener, err := net.Listen("unix", socketPath)
+		require.NoError(t, err)
+		newBackendStateServer(t, listener, "STARTED")
+		// Anything listening on TCP would give a different answer.
+		tcpServer := newBackendStateServer(t, nil, "STOPPED")
+
+		rdClient := NewRDClient(&config.ConnectionInfo{Host: "127.0.0.1", Port: serverPort(t, tcpServer), Socket: socketPath})
+		state, err := rdClient.GetBackendState(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "STARTED", state.VMState)
+	})
+
+	t.Run("Requests over the socket should not have basic auth", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "rd-engine.sock")
+		listener, err := net.Listen("unix", socketPath)
+		require.NoError(t, err)
+		var hasAuth bool
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _, hasAuth = r.BasicAuth()
+			_, _ = w.Write([]byte(`{"vmState": "STARTED"}`))
+		}))
+		server.Listener = listener
+		server.Start()
+		t.Cleanup(server.Close)
+
+		rdClient := NewRDClient(&config.ConnectionInfo{Host: "127.0.0.1", User: "user", Password: "password", Socket: socketPath})
+		_, err = rdClient.GetBackendState(context.Background())
+		require.NoError(t, err)
+		assert.False(t, hasAuth)
+	})
+
+	t.Run("A missing socket should be a refused connection", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "rd-engine.sock")
+		rdClient := NewRDClient(&config.ConnectionInfo{Host: "127.0.0.1", Socket: socketPath})
+		_, err := rdClient.GetBackendState(context.Background())
+		assert.ErrorIs(t, err, ErrConnectionRefused)
+	})
+}
@@ -81,3 +81,25 @@ func ProcessRequestForUtility(response *http.Response, err error) ([]byte, error
 	}
 	return body, nil
 }
+
+// ProcessResponse returns the body of a successful response. If the server
+// returned an error, the error is an *APIError describing it; if the server
+// is not running, it is ErrConnectionRefused.
+func ProcessResponse(response *http.Response, err error) ([]byte, error) {
+	if err := handleConnectionRefused(err); err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, &APIError{
+			Message:    &response.Status,
+			StatusCode: response.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
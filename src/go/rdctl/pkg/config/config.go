@@ -42,8 +42,21 @@ type ConnectionInfo struct {
 	Password string
 	Host     string
 	Port     int
+	// The path of the well-known Unix domain socket (or on Windows, named
+	// pipe) that the server listens on, if it exists. Requests are then sent
+	// over it instead of to Host and Port, and access is controlled by its
+	// permissions, so no user or password is required. It is never taken
+	// from the config file, so that it can't point anywhere else.
+	Socket string `json:"-"`
 }
 
+// The name of the Unix domain socket in the application directory that the
+// server listens on, and the named pipe it listens on instead on Windows.
+const (
+	apiSocketName = "rd-engine.sock"
+	apiPipePath   = `\\.\pipe\rancher-desktop-rd-engine`
+)
+
 var (
 	connectionSettings ConnectionInfo
 	verbose            bool
@@ -55,6 +68,8 @@ var (
 	wslDistroEnvs = []string{"WSL_DISTRO_NAME", "WSL_INTEROP", "WSLENV"}
 	// lstatFunc allows tests to inject a stub for /bin/wslpath checks.
 	lstatFunc = os.Lstat
+	// apiSocketFunc allows tests to inject a stub for the socket path.
+	apiSocketFunc = apiSocket
 )
 
 // DefineGlobalFlags sets up the global flags, available for all sub-commands
@@ -125,7 +140,12 @@ func GetConnectionInfo(mayBeMissing bool) (*ConnectionInfo, error) {
 	if connectionSettings.Port != 0 {
 		settings.Port = connectionSettings.Port
 	}
-	if settings.Port == 0 || settings.User == "" || settings.Password == "" {
+	if socket, err := apiSocketFunc(); err == nil {
+		if _, err := os.Stat(socket); err == nil {
+			settings.Socket = socket
+		}
+	}
+	if settings.Socket == "" && (settings.Port == 0 || settings.User == "" || settings.Password == "") {
 		// Missing the default config file may or may not be considered an error
 		if readFileError != nil {
 			if mayBeMissing {
@@ -139,6 +159,19 @@ func GetConnectionInfo(mayBeMissing bool) (*ConnectionInfo, error) {
 	return &settings, nil
 }
 
+// Returns the path of the socket, or on Windows the named pipe, that the
+// server listens on.
+func apiSocket() (string, error) {
+	if runtime.GOOS == "windows" {
+		return apiPipePath, nil
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appPaths.AppHome, apiSocketName), nil
+}
+
 // determines if we are running in a wsl linux distro
 // by checking for availability of wslpath and see if it's a symlink
 func isWSLDistro() bool {
@@ -309,3 +309,35 @@ func TestIsWSLDistroLstatError(t *testing.T) {
 	os.Setenv(wslDistroEnvs[0], "Ubuntu")
 	assert.False(t, isWSLDistro(), "expected isWSLDistro to be false when lstat fails")
 }
+
+func TestGetConnectionInfo_Socket(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "rd-engine.json")
+	socketPath := filepath.Join(tmpDir, "rd-engine.sock")
+	// The socket path in the config file must be ignored.
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"socket": "/elsewhere.sock"}`), 0600))
+
+	originalConfigPath := configPath
+	originalDefaultConfigPath := DefaultConfigPath
+	originalConnectionSettings := connectionSettings
+	originalAPISocket := apiSocketFunc
+	t.Cleanup(func() {
+		configPath = originalConfigPath
+		DefaultConfigPath = originalDefaultConfigPath
+		connectionSettings = originalConnectionSettings
+		apiSocketFunc = originalAPISocket
+	})
+
+	configPath = configFile
+	DefaultConfigPath = configFile
+	connectionSettings = ConnectionInfo{}
+	apiSocketFunc = func() (string, error) { return socketPath, nil }
+
+	_, err := GetConnectionInfo(false)
+	assert.ErrorContains(t, err, "insufficient connection settings", "a missing socket should need a port, user and password")
+
+	require.NoError(t, os.WriteFile(socketPath, nil, 0600))
+	result, err := GetConnectionInfo(false)
+	require.NoError(t, err, "a socket should not need a port, user or password")
+	assert.Equal(t, socketPath, result.Socket)
+}
@@ -0,0 +1,183 @@
+// Package diagnostics collects the state of a Rancher Desktop installation
+// into a bundle that can be attached to a support ticket.
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
+)
+
+// ManifestName is the name of the file describing the contents of a bundle.
+const ManifestName = "manifest.json"
+
+// Component collects one kind of data into a bundle.
+type Component struct {
+	Name        string
+	Description string
+	// Whether the component is collected unless it is asked for.
+	Default bool
+	Collect func(ctx context.Context, bundle *Bundle) error
+}
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Created      time.Time           `json:"created"`
+	RdctlVersion string              `json:"rdctlVersion"`
+	OS           string              `json:"os"`
+	Arch         string              `json:"arch"`
+	Components   []ManifestComponent `json:"components"`
+}
+
+// ManifestComponent describes what was collected for a component.
+type ManifestComponent struct {
+	Name string `json:"name"`
+	// The files added for the component, relative to the bundle's
+	// directory.
+	Files []string `json:"files"`
+	// Why the component couldn't be collected, or only partly; the files
+	// collected before the error are still included.
+	Error string `json:"error,omitempty"`
+}
+
+// Bundle is the archive that components add their files to.
+type Bundle struct {
+	writer  *tar.Writer
+	root    string
+	created time.Time
+	// The files added by the component being collected.
+	files []string
+	// The first error writing the archive; once set, nothing more is
+	// written.
+	err error
+}
+
+// AddFile adds a file with the given contents. name is relative to the
+// bundle's directory, and uses forward slashes.
+func (bundle *Bundle) AddFile(name string, contents []byte) error {
+	return bundle.AddReader(name, int64(len(contents)), bytes.NewReader(contents))
+}
+
+// AddReader adds a file holding the first size bytes read from reader, such
+// as a file that may grow while it is being added. If reader ends early, the
+// rest of the file is filled with zeros to keep the archive readable, and an
+// error is returned.
+func (bundle *Bundle) AddReader(name string, size int64, reader io.Reader) error {
+	if bundle.err != nil {
+		return bundle.err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Join(bundle.root, name),
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  bundle.created,
+	}
+	if err := bundle.writer.WriteHeader(header); err != nil {
+		bundle.err = fmt.Errorf("failed to write %s: %w", name, err)
+		return bundle.err
+	}
+	source := &sourceReader{reader: reader}
+	copied, err := io.CopyN(bundle.writer, source, size)
+	if err != nil && source.err == nil {
+		bundle.err = fmt.Errorf("failed to write %s: %w", name, err)
+		return bundle.err
+	}
+	bundle.files = append(bundle.files, name)
+	if copied == size {
+		return nil
+	}
+	if _, err := io.CopyN(bundle.writer, zeros{}, size-copied); err != nil {
+		bundle.err = fmt.Errorf("failed to write %s: %w", name, err)
+		return bundle.err
+	}
+	if errors.Is(source.err, io.EOF) {
+		source.err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("failed to read %s: %w", name, source.err)
+}
+
+// sourceReader remembers the error returned by reader, to tell it apart
+// from errors writing what was read.
+type sourceReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (source *sourceReader) Read(buffer []byte) (int, error) {
+	n, err := source.reader.Read(buffer)
+	if err != nil {
+		source.err = err
+	}
+	return n, err
+}
+
+// zeros is an io.Reader of endless zeros.
+type zeros struct{}
+
+func (zeros) Read(buffer []byte) (int, error) {
+	clear(buffer)
+	return len(buffer), nil
+}
+
+// AddJSON adds a file holding value as indented JSON.
+func (bundle *Bundle) AddJSON(name string, value any) error {
+	contents, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	return bundle.AddFile(name, append(contents, '\n'))
+}
+
+// Write writes a gzipped tar archive of the given components to writer,
+// with every file under a directory named root. Components that fail are
+// recorded in the manifest, which is written last; only errors writing the
+// archive are returned.
+func Write(ctx context.Context, writer io.Writer, root string, components []Component) (*Manifest, error) {
+	gzipWriter := gzip.NewWriter(writer)
+	bundle := &Bundle{
+		writer:  tar.NewWriter(gzipWriter),
+		root:    root,
+		created: time.Now(),
+	}
+	manifest := &Manifest{
+		Created:      bundle.created,
+		RdctlVersion: version.Version,
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Components:   make([]ManifestComponent, 0, len(components)),
+	}
+	for _, component := range components {
+		bundle.files = []string{}
+		err := component.Collect(ctx, bundle)
+		if bundle.err != nil {
+			return nil, bundle.err
+		}
+		entry := ManifestComponent{Name: component.Name, Files: bundle.files}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		manifest.Components = append(manifest.Components, entry)
+	}
+	bundle.files = nil
+	if err := bundle.AddJSON(ManifestName, manifest); err != nil {
+		return nil, err
+	}
+	if err := bundle.writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return manifest, nil
+}
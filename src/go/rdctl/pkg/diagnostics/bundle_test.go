@@ -0,0 +1,93 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the contents of each file in a bundle.
+func readBundle(t *testing.T, contents []byte) map[string]string {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(contents))
+	require.NoError(t, err)
+	reader := tar.NewReader(gzipReader)
+	files := map[string]string{}
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	components := []Component{
+		{Name: "good", Collect: func(_ context.Context, bundle *Bundle) error {
+			return bundle.AddFile("good/a.txt", []byte("a"))
+		}},
+		{Name: "partial", Collect: func(_ context.Context, bundle *Bundle) error {
+			if err := bundle.AddJSON("partial.json", map[string]int{"b": 1}); err != nil {
+				return err
+			}
+			return errors.New("something failed")
+		}},
+		{Name: "failed", Collect: func(context.Context, *Bundle) error {
+			return errors.New("nothing collected")
+		}},
+	}
+	var buffer bytes.Buffer
+	manifest, err := Write(t.Context(), &buffer, "bundle", components)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestComponent{
+		{Name: "good", Files: []string{"good/a.txt"}},
+		{Name: "partial", Files: []string{"partial.json"}, Error: "something failed"},
+		{Name: "failed", Files: []string{}, Error: "nothing collected"},
+	}, manifest.Components)
+
+	files := readBundle(t, buffer.Bytes())
+	assert.Equal(t, "a", files["bundle/good/a.txt"])
+	assert.JSONEq(t, `{"b": 1}`, files["bundle/partial.json"])
+	var writtenManifest Manifest
+	require.NoError(t, json.Unmarshal([]byte(files["bundle/"+ManifestName]), &writtenManifest))
+	assert.Equal(t, manifest.Components, writtenManifest.Components)
+	assert.Len(t, files, 3)
+}
+
+func TestAddReader(t *testing.T) {
+	components := []Component{
+		{Name: "growing", Collect: func(_ context.Context, bundle *Bundle) error {
+			return bundle.AddReader("growing.log", 3, bytes.NewReader([]byte("abc written later")))
+		}},
+		{Name: "shrinking", Collect: func(_ context.Context, bundle *Bundle) error {
+			return bundle.AddReader("shrinking.log", 5, bytes.NewReader([]byte("abc")))
+		}},
+		{Name: "after", Collect: func(_ context.Context, bundle *Bundle) error {
+			return bundle.AddFile("after.txt", []byte("after"))
+		}},
+	}
+	var buffer bytes.Buffer
+	manifest, err := Write(t.Context(), &buffer, "bundle", components)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestComponent{
+		{Name: "growing", Files: []string{"growing.log"}},
+		{Name: "shrinking", Files: []string{"shrinking.log"}, Error: "failed to read shrinking.log: unexpected EOF"},
+		{Name: "after", Files: []string{"after.txt"}},
+	}, manifest.Components)
+
+	files := readBundle(t, buffer.Bytes())
+	assert.Equal(t, "abc", files["bundle/growing.log"])
+	assert.Equal(t, "abc\x00\x00", files["bundle/shrinking.log"])
+	assert.Equal(t, "after", files["bundle/after.txt"])
+}
@@ -0,0 +1,241 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/info"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/kubeconfig"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shell"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+)
+
+// Components lists the components that can be collected, in the order they
+// are collected.
+var Components = []Component{
+	{Name: "logs", Description: "the Rancher Desktop log files", Default: true, Collect: collectLogs},
+	{Name: "settings", Description: "the current settings, with secrets redacted", Default: true, Collect: collectSettings},
+	{Name: "info", Description: `the output of "rdctl info"`, Default: true, Collect: collectInfo},
+	{Name: "paths", Description: `the output of "rdctl paths"`, Default: true, Collect: collectPaths},
+	{Name: "snapshots", Description: "the list of snapshots", Default: true, Collect: collectSnapshots},
+	{Name: "lock", Description: "the state of the backend lock", Default: true, Collect: collectLock},
+	{Name: "lima", Description: `the output of "limactl ls"`, Default: true, Collect: collectLima},
+	{Name: "docker-config", Description: "the docker CLI config, with credentials stripped", Default: true, Collect: collectDockerConfig},
+	{Name: "kubeconfig", Description: "the names of the clusters and contexts in the kubeconfig", Default: true, Collect: collectKubeconfig},
+	{Name: "vm-logs", Description: "the contents of /var/log in the VM, which must be running", Collect: collectVMLogs},
+}
+
+func collectLogs(_ context.Context, bundle *Bundle) error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(appPaths.Logs, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(appPaths.Logs, path)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return bundle.AddReader("logs/"+filepath.ToSlash(relativePath), info.Size(), file)
+	})
+}
+
+// Adds the settings reported by the API, or the settings file if the API
+// can't be reached.
+func collectSettings(ctx context.Context, bundle *Bundle) error {
+	var contents []byte
+	rdClient, err := sdk.Connect()
+	if err == nil {
+		contents, err = rdClient.GetSettingsJSON(ctx)
+	}
+	if err != nil {
+		appPaths, pathsErr := paths.GetPaths()
+		if pathsErr != nil {
+			return pathsErr
+		}
+		var readErr error
+		if contents, readErr = os.ReadFile(filepath.Join(appPaths.Config, "settings.json")); readErr != nil {
+			return errors.Join(err, readErr)
+		}
+	}
+	var settings any
+	if err := json.Unmarshal(contents, &settings); err != nil {
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+	return bundle.AddJSON("settings.json", RedactSettings(settings))
+}
+
+func collectInfo(ctx context.Context, bundle *Bundle) error {
+	var rdClient client.RDClient
+	if connectionInfo, err := config.GetConnectionInfo(false); err == nil {
+		rdClient = client.NewRDClient(connectionInfo)
+	}
+	var result info.Info
+	var errs []error
+	for name, handler := range info.Handlers {
+		if err := handler(ctx, &result, rdClient); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if err := bundle.AddJSON("info.json", result); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func collectPaths(_ context.Context, bundle *Bundle) error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return err
+	}
+	return bundle.AddJSON("paths.json", appPaths)
+}
+
+func collectSnapshots(_ context.Context, bundle *Bundle) error {
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return err
+	}
+	snapshots, err := manager.List(true)
+	if err != nil {
+		return err
+	}
+	return bundle.AddJSON("snapshots.json", snapshots)
+}
+
+func collectLock(_ context.Context, bundle *Bundle) error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return err
+	}
+	status, err := lock.Status(appPaths)
+	if err != nil {
+		return err
+	}
+	return bundle.AddJSON("lock.json", status)
+}
+
+func collectLima(ctx context.Context, bundle *Bundle) error {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return err
+	}
+	if err := directories.SetupLimaHome(appPaths.AppHome); err != nil {
+		return err
+	}
+	limactl, err := directories.GetLimactlPath()
+	if err != nil {
+		return err
+	}
+	// The output is collected even if limactl fails, as it explains why.
+	output, err := exec.CommandContext(ctx, limactl, "ls").CombinedOutput()
+	if addErr := bundle.AddFile("limactl-ls.txt", output); addErr != nil {
+		return addErr
+	}
+	if err != nil {
+		return fmt.Errorf("limactl ls failed: %w", err)
+	}
+	return nil
+}
+
+func collectDockerConfig(_ context.Context, bundle *Bundle) error {
+	contents, err := os.ReadFile(filepath.Join(dockerconfig.Dir(), "config.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var dockerConfig map[string]any
+	if err := json.Unmarshal(contents, &dockerConfig); err != nil {
+		return fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return bundle.AddJSON("docker-config.json", StripDockerCredentials(dockerConfig))
+}
+
+// The parts of the kubeconfig that are collected; the clusters' addresses
+// and the users' credentials are left out.
+type kubeconfigSummary struct {
+	Files          []string `json:"files"`
+	Clusters       []string `json:"clusters"`
+	Contexts       []string `json:"contexts"`
+	CurrentContext string   `json:"currentContext"`
+}
+
+func collectKubeconfig(_ context.Context, bundle *Bundle) error {
+	files, err := kubeconfig.Paths()
+	if err != nil {
+		return err
+	}
+	kubeConfig, err := kubeconfig.Load()
+	if err != nil {
+		return err
+	}
+	summary := kubeconfigSummary{
+		Files:          files,
+		Clusters:       []string{},
+		Contexts:       []string{},
+		CurrentContext: kubeConfig.CurrentContext,
+	}
+	for _, cluster := range kubeConfig.Clusters {
+		summary.Clusters = append(summary.Clusters, cluster.Name)
+	}
+	for _, kubeContext := range kubeConfig.Contexts {
+		summary.Contexts = append(summary.Contexts, kubeContext.Name)
+	}
+	return bundle.AddJSON("kubeconfig.json", summary)
+}
+
+// The script run in the VM to archive /var/log; the VM's shell may or may
+// not run as root.
+const vmLogsScript = `if [ "$(id -u)" -ne 0 ]; then exec sudo tar -C /var/log -czf - .; fi; exec tar -C /var/log -czf - .`
+
+func collectVMLogs(ctx context.Context, bundle *Bundle) error {
+	cmd, err := shell.SpawnCommand(ctx, "/bin/sh", "-c", vmLogsScript)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// tar fails if a log changes while it is read, but the archive is still
+	// worth having.
+	err = cmd.Run()
+	if stdout.Len() > 0 {
+		if addErr := bundle.AddFile("vm-logs.tar.gz", stdout.Bytes()); addErr != nil {
+			return addErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive /var/log in the VM: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
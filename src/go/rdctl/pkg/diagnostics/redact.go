@@ -0,0 +1,101 @@
+package diagnostics
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the values of secrets in a bundle.
+const Redacted = "REDACTED"
+
+// Parts of the names of settings that hold secrets.
+var secretKeyParts = []string{"password", "passwd", "secret", "token", "credential", "username"}
+
+// The docker config keys whose values are all secrets.
+var dockerSecretKeys = []string{"auths", "HttpHeaders"}
+
+// RedactSettings returns a copy of settings, decoded from JSON, with the
+// values of settings whose names suggest they are secrets replaced, along
+// with the credentials in URLs.
+func RedactSettings(settings any) any {
+	switch value := settings.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, child := range value {
+			if isSecretKey(key) && !isEmpty(child) {
+				result[key] = Redacted
+			} else {
+				result[key] = RedactSettings(child)
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, child := range value {
+			result[i] = RedactSettings(child)
+		}
+		return result
+	case string:
+		return redactURL(value)
+	}
+	return settings
+}
+
+// StripDockerCredentials returns a copy of a docker CLI config, decoded from
+// JSON, without the credentials it holds. The registries that have
+// credentials are kept, so that it can be seen which ones are logged into.
+func StripDockerCredentials(dockerConfig map[string]any) map[string]any {
+	result := RedactSettings(dockerConfig).(map[string]any)
+	for _, key := range dockerSecretKeys {
+		entries, ok := result[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		stripped := maps.Clone(entries)
+		for name, entry := range entries {
+			if fields, ok := entry.(map[string]any); ok {
+				redactedFields := make(map[string]any, len(fields))
+				for field := range fields {
+					redactedFields[field] = Redacted
+				}
+				stripped[name] = redactedFields
+			} else {
+				stripped[name] = Redacted
+			}
+		}
+		result[key] = stripped
+	}
+	return result
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmpty(value any) bool {
+	return value == nil || value == ""
+}
+
+// Returns value with the password of any URL it holds redacted.
+func redactURL(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), Redacted)
+	} else {
+		parsed.User = url.User(Redacted)
+	}
+	return parsed.String()
+}
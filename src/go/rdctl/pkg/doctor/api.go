@@ -0,0 +1,73 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+// How long to wait for the API to respond.
+const apiTimeout = 5 * time.Second
+
+func init() {
+	register(Check{
+		Name:        "api",
+		Description: "The Rancher Desktop API can be reached",
+		Run:         checkAPI,
+	})
+}
+
+func checkAPI(ctx context.Context, env *Environment) Result {
+	if env.ConnectionError != nil {
+		return Result{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("The API connection settings can't be read: %s", env.ConnectionError),
+			Fix:     "Restart Rancher Desktop to rewrite its connection settings.",
+		}
+	}
+	if env.ConnectionInfo == nil {
+		return Result{
+			Status:  StatusWarn,
+			Message: "Rancher Desktop has not written its API connection settings; it may never have been started.",
+			Fix:     "Start Rancher Desktop.",
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+	rdClient := sdk.NewClient(client.NewRDClient(env.ConnectionInfo))
+	_, err := rdClient.GetBackendState(ctx)
+	if err == nil {
+		return Result{Status: StatusPass, Message: fmt.Sprintf("The API at %s responded.", describeConnection(env))}
+	}
+	if errors.Is(err, client.ErrConnectionRefused) {
+		return Result{
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("The API at %s can't be reached; Rancher Desktop may not be running.", describeConnection(env)),
+			Fix:     "Start Rancher Desktop; if it is running, restart it so that it listens on the port in its connection settings again.",
+		}
+	}
+	var apiError *client.APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == 401 {
+		return Result{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("The API at %s rejected the credentials in the connection settings.", describeConnection(env)),
+			Fix:     "Restart Rancher Desktop to rewrite its connection settings.",
+		}
+	}
+	return Result{
+		Status:  StatusFail,
+		Message: fmt.Sprintf("The API at %s failed: %s", describeConnection(env), err),
+		Fix:     "Restart Rancher Desktop.",
+	}
+}
+
+func describeConnection(env *Environment) string {
+	if env.ConnectionInfo.Socket != "" {
+		return env.ConnectionInfo.Socket
+	}
+	return fmt.Sprintf("%s:%d", env.ConnectionInfo.Host, env.ConnectionInfo.Port)
+}
@@ -0,0 +1,80 @@
+//go:build unix
+
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	register(Check{
+		Name:        "docker-cli-plugins",
+		Description: "The docker CLI plugins installed by Rancher Desktop still exist",
+		Run:         checkDockerCLIPlugins,
+		Fix:         fixDockerCLIPlugins,
+	})
+}
+
+func checkDockerCLIPlugins(_ context.Context, env *Environment) Result {
+	links, err := danglingDockerCLIPlugins(env)
+	if err != nil {
+		return Result{Status: StatusFail, Message: err.Error()}
+	}
+	if len(links) == 0 {
+		return Result{Status: StatusPass, Message: "No docker CLI plugins point at missing Rancher Desktop executables."}
+	}
+	return Result{
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("These docker CLI plugins point at missing Rancher Desktop executables: %s", strings.Join(links, ", ")),
+		Fix:     "Remove the broken links; Rancher Desktop recreates those it still provides when it starts.",
+		Fixable: true,
+	}
+}
+
+func fixDockerCLIPlugins(_ context.Context, env *Environment) error {
+	links, err := danglingDockerCLIPlugins(env)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, link := range links {
+		if err := os.Remove(link); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the symlinks in the docker CLI plugins directory that point into
+// the directory of Rancher Desktop's executables, but whose targets are
+// gone.
+func danglingDockerCLIPlugins(env *Environment) ([]string, error) {
+	cliPluginsDir := filepath.Join(env.DockerConfigDir, "cli-plugins")
+	entries, err := os.ReadDir(cliPluginsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", cliPluginsDir, err)
+	}
+	var links []string
+	for _, entry := range entries {
+		if entry.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+		fullPath := filepath.Join(cliPluginsDir, entry.Name())
+		target, err := os.Readlink(fullPath)
+		if err != nil || !strings.HasPrefix(target, env.Paths.Integration+string(filepath.Separator)) {
+			continue
+		}
+		if _, err := os.Stat(fullPath); errors.Is(err, fs.ErrNotExist) {
+			links = append(links, fullPath)
+		}
+	}
+	return links, nil
+}
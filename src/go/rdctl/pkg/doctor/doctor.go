@@ -0,0 +1,149 @@
+// Package doctor checks for common problems with a Rancher Desktop
+// installation, and fixes those that can be fixed safely.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is what a check found.
+type Result struct {
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	// How to fix the problem found, if any.
+	Fix string `json:"fix,omitempty"`
+	// Whether `rdctl doctor --fix` can fix the problem.
+	Fixable bool `json:"fixable,omitempty"`
+}
+
+// Check looks for one kind of problem.
+type Check struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, env *Environment) Result
+	// Fixes the problem reported by Run, if its result is Fixable. Only
+	// fixes that can't lose data or disturb a running application belong
+	// here.
+	Fix func(ctx context.Context, env *Environment) error
+}
+
+// Checks that have been registered, in the order they are run.
+var Checks []Check
+
+func register(check Check) {
+	Checks = append(Checks, check)
+}
+
+// Environment is what the checks examine.
+type Environment struct {
+	Paths   *paths.Paths
+	HomeDir string
+	// The directory holding the docker CLI config.
+	DockerConfigDir string
+	// How to connect to the API; nil if there's no way to, with the reason
+	// in ConnectionError if there should have been one.
+	ConnectionInfo  *config.ConnectionInfo
+	ConnectionError error
+	// The saved settings, decoded from JSON; nil if they can't be read.
+	Settings map[string]any
+}
+
+// NewEnvironment returns the environment of the current user.
+func NewEnvironment() (*Environment, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	env := &Environment{Paths: appPaths, HomeDir: homeDir, DockerConfigDir: dockerconfig.Dir()}
+	env.ConnectionInfo, env.ConnectionError = config.GetConnectionInfo(true)
+	contents, err := os.ReadFile(filepath.Join(appPaths.Config, "settings.json"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	} else if err == nil {
+		if err := json.Unmarshal(contents, &env.Settings); err != nil {
+			return nil, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+	return env, nil
+}
+
+// Returns the setting with the given keys, or nil if it isn't set.
+func (env *Environment) setting(keys ...string) any {
+	var value any = env.Settings
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// CheckReport is the result of running a check.
+type CheckReport struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Result
+	// Whether --fix fixed the problem; the result is then that of checking
+	// again after fixing it.
+	Fixed bool `json:"fixed,omitempty"`
+	// Why --fix failed to fix the problem.
+	FixError string `json:"fixError,omitempty"`
+}
+
+// Report is the result of running the checks.
+type Report struct {
+	Checks []CheckReport `json:"checks"`
+	// The worst status of any check.
+	Status Status `json:"status"`
+}
+
+// Run runs the checks, fixing the problems that can be fixed if fix is
+// true.
+func Run(ctx context.Context, env *Environment, checks []Check, fix bool) Report {
+	report := Report{Checks: make([]CheckReport, 0, len(checks)), Status: StatusPass}
+	for _, check := range checks {
+		checkReport := CheckReport{Name: check.Name, Description: check.Description, Result: check.Run(ctx, env)}
+		if fix && checkReport.Status != StatusPass && checkReport.Fixable && check.Fix != nil {
+			if err := check.Fix(ctx, env); err != nil {
+				checkReport.FixError = err.Error()
+			} else {
+				checkReport.Fixed = true
+				checkReport.Result = check.Run(ctx, env)
+			}
+		}
+		switch {
+		case checkReport.Status == StatusFail:
+			report.Status = StatusFail
+		case checkReport.Status == StatusWarn && report.Status == StatusPass:
+			report.Status = StatusWarn
+		}
+		report.Checks = append(report.Checks, checkReport)
+	}
+	return report
+}
@@ -0,0 +1,203 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+func TestRun(t *testing.T) {
+	fixed := false
+	checks := []Check{
+		{
+			Name: "passes",
+			Run: func(context.Context, *Environment) Result {
+				return Result{Status: StatusPass}
+			},
+		},
+		{
+			Name: "fixable",
+			Run: func(context.Context, *Environment) Result {
+				if fixed {
+					return Result{Status: StatusPass}
+				}
+				return Result{Status: StatusFail, Fixable: true}
+			},
+			Fix: func(context.Context, *Environment) error {
+				fixed = true
+				return nil
+			},
+		},
+		{
+			Name: "fix fails",
+			Run: func(context.Context, *Environment) Result {
+				return Result{Status: StatusWarn, Fixable: true}
+			},
+			Fix: func(context.Context, *Environment) error {
+				return errors.New("can't fix")
+			},
+		},
+	}
+
+	t.Run("without fixing", func(t *testing.T) {
+		report := Run(t.Context(), &Environment{}, checks, false)
+		require.Len(t, report.Checks, 3)
+		assert.Equal(t, StatusFail, report.Status)
+		assert.False(t, fixed)
+		assert.False(t, report.Checks[1].Fixed)
+	})
+
+	t.Run("with fixing", func(t *testing.T) {
+		report := Run(t.Context(), &Environment{}, checks, true)
+		require.Len(t, report.Checks, 3)
+		assert.Equal(t, StatusWarn, report.Status)
+		assert.True(t, report.Checks[1].Fixed)
+		assert.Equal(t, StatusPass, report.Checks[1].Status)
+		assert.False(t, report.Checks[2].Fixed)
+		assert.Equal(t, "can't fix", report.Checks[2].FixError)
+	})
+}
+
+func TestCheckBackendLock(t *testing.T) {
+	env := &Environment{Paths: &paths.Paths{AppHome: t.TempDir()}}
+	lockPath := filepath.Join(env.Paths.AppHome, "backend.lock")
+
+	result := checkBackendLock(t.Context(), env)
+	assert.Equal(t, StatusPass, result.Status)
+
+	// A lock taken by a process that has exited is stale.
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command(exe, "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	contents, err := json.Marshal(lock.LockData{Action: "testing", PID: cmd.Process.Pid, Hostname: hostname})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(lockPath, contents, 0o644))
+
+	result = checkBackendLock(t.Context(), env)
+	assert.Equal(t, StatusFail, result.Status, result.Message)
+	assert.True(t, result.Fixable)
+	require.NoError(t, fixBackendLock(t.Context(), env))
+	assert.NoFileExists(t, lockPath)
+
+	// A lock held by a running process is only a warning, and can't be
+	// removed.
+	contents, err = json.Marshal(lock.LockData{Action: "testing", PID: os.Getpid(), Hostname: hostname})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(lockPath, contents, 0o644))
+	result = checkBackendLock(t.Context(), env)
+	assert.Equal(t, StatusWarn, result.Status, result.Message)
+	assert.False(t, result.Fixable)
+}
+
+func TestCheckAPI(t *testing.T) {
+	t.Run("not started", func(t *testing.T) {
+		result := checkAPI(t.Context(), &Environment{})
+		assert.Equal(t, StatusWarn, result.Status)
+	})
+
+	testCases := []struct {
+		name       string
+		statusCode int
+		expected   Status
+	}{
+		{name: "reachable", statusCode: http.StatusOK, expected: StatusPass},
+		{name: "bad credentials", statusCode: http.StatusUnauthorized, expected: StatusFail},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.statusCode)
+				_, _ = w.Write([]byte(`{"vmState": "STARTED", "locked": false}`))
+			}))
+			defer server.Close()
+			serverURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			port, err := strconv.Atoi(serverURL.Port())
+			require.NoError(t, err)
+			env := &Environment{ConnectionInfo: &config.ConnectionInfo{
+				Host:     serverURL.Hostname(),
+				Port:     port,
+				User:     "user",
+				Password: "password",
+			}}
+			result := checkAPI(t.Context(), env)
+			assert.Equal(t, testCase.expected, result.Status, result.Message)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		serverURL, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		server.Close()
+		port, err := strconv.Atoi(serverURL.Port())
+		require.NoError(t, err)
+		env := &Environment{ConnectionInfo: &config.ConnectionInfo{Host: serverURL.Hostname(), Port: port}}
+		result := checkAPI(t.Context(), env)
+		assert.Equal(t, StatusWarn, result.Status, result.Message)
+	})
+}
+
+func TestCheckKubeconfig(t *testing.T) {
+	writeKubeconfig := func(t *testing.T, contents string) {
+		path := filepath.Join(t.TempDir(), "config")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		t.Setenv("KUBECONFIG", path)
+	}
+
+	t.Run("has the context", func(t *testing.T) {
+		writeKubeconfig(t, `
+clusters:
+- name: rancher-desktop
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: rancher-desktop
+  context:
+    cluster: rancher-desktop
+    user: rancher-desktop
+`)
+		result := checkKubeconfig(t.Context(), &Environment{})
+		assert.Equal(t, StatusPass, result.Status, result.Message)
+	})
+
+	t.Run("missing the cluster", func(t *testing.T) {
+		writeKubeconfig(t, `
+contexts:
+- name: rancher-desktop
+  context:
+    cluster: rancher-desktop
+    user: rancher-desktop
+`)
+		result := checkKubeconfig(t.Context(), &Environment{})
+		assert.Equal(t, StatusWarn, result.Status, result.Message)
+	})
+
+	t.Run("missing the context", func(t *testing.T) {
+		writeKubeconfig(t, "contexts: []\n")
+		result := checkKubeconfig(t.Context(), &Environment{})
+		assert.Equal(t, StatusWarn, result.Status, result.Message)
+
+		env := &Environment{Settings: map[string]any{"kubernetes": map[string]any{"enabled": false}}}
+		result = checkKubeconfig(t.Context(), env)
+		assert.Equal(t, StatusPass, result.Status, "Kubernetes is disabled")
+	})
+}
@@ -0,0 +1,84 @@
+//go:build unix
+
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+func TestCheckDockerCLIPlugins(t *testing.T) {
+	integration := t.TempDir()
+	env := &Environment{
+		Paths:           &paths.Paths{Integration: integration},
+		DockerConfigDir: t.TempDir(),
+	}
+	result := checkDockerCLIPlugins(t.Context(), env)
+	assert.Equal(t, StatusPass, result.Status, "there is no cli-plugins directory")
+
+	cliPluginsDir := filepath.Join(env.DockerConfigDir, "cli-plugins")
+	require.NoError(t, os.MkdirAll(cliPluginsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(integration, "docker-buildx"), nil, 0o755))
+	require.NoError(t, os.Symlink(filepath.Join(integration, "docker-buildx"), filepath.Join(cliPluginsDir, "docker-buildx")))
+	require.NoError(t, os.Symlink(filepath.Join(integration, "docker-compose"), filepath.Join(cliPluginsDir, "docker-compose")))
+	// Broken links that aren't Rancher Desktop's are left alone.
+	require.NoError(t, os.Symlink(filepath.Join(t.TempDir(), "docker-other"), filepath.Join(cliPluginsDir, "docker-other")))
+
+	result = checkDockerCLIPlugins(t.Context(), env)
+	assert.Equal(t, StatusWarn, result.Status)
+	assert.True(t, result.Fixable)
+	assert.Contains(t, result.Message, "docker-compose")
+	assert.NotContains(t, result.Message, "docker-buildx")
+	assert.NotContains(t, result.Message, "docker-other")
+
+	require.NoError(t, fixDockerCLIPlugins(t.Context(), env))
+	assert.Equal(t, StatusPass, checkDockerCLIPlugins(t.Context(), env).Status)
+	for _, name := range []string{"docker-buildx", "docker-other"} {
+		_, err := os.Lstat(filepath.Join(cliPluginsDir, name))
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestCheckPathManagement(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	env := &Environment{
+		Paths:    &paths.Paths{Integration: "/home/user/.rd/bin"},
+		HomeDir:  homeDir,
+		Settings: map[string]any{"application": map[string]any{"pathManagementStrategy": pathManagementRCFiles}},
+	}
+	for rcFile, line := range expectedPathLines(env) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(rcFile), 0o755))
+		require.NoError(t, os.WriteFile(rcFile, []byte("# before\n"+line+"\n"), 0o644))
+	}
+	result := checkPathManagement(t.Context(), env)
+	assert.Equal(t, StatusPass, result.Status, result.Message)
+
+	// Once .profile exists, it is used for login shells instead of
+	// .bash_profile; it isn't in either.
+	require.NoError(t, os.Remove(filepath.Join(homeDir, ".bash_profile")))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".profile"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tcshrc"), nil, 0o644))
+	result = checkPathManagement(t.Context(), env)
+	assert.Equal(t, StatusWarn, result.Status)
+	assert.Contains(t, result.Message, filepath.Join(homeDir, ".profile"))
+	assert.Contains(t, result.Message, filepath.Join(homeDir, ".tcshrc"))
+	assert.NotContains(t, result.Message, ".bashrc")
+
+	t.Run("manual", func(t *testing.T) {
+		env := &Environment{
+			Paths:    &paths.Paths{Integration: "/home/user/.rd/bin"},
+			Settings: map[string]any{"application": map[string]any{"pathManagementStrategy": pathManagementManual}},
+		}
+		t.Setenv("PATH", "/usr/bin:/bin")
+		assert.Equal(t, StatusWarn, checkPathManagement(t.Context(), env).Status)
+		t.Setenv("PATH", "/usr/bin:/home/user/.rd/bin:/bin")
+		assert.Equal(t, StatusPass, checkPathManagement(t.Context(), env).Status)
+	})
+}
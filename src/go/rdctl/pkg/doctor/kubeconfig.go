@@ -0,0 +1,47 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/kubeconfig"
+)
+
+func init() {
+	register(Check{
+		Name:        "kubeconfig",
+		Description: "The kubeconfig has the rancher-desktop context",
+		Run:         checkKubeconfig,
+	})
+}
+
+func checkKubeconfig(_ context.Context, env *Environment) Result {
+	if enabled, ok := env.setting("kubernetes", "enabled").(bool); ok && !enabled {
+		return Result{Status: StatusPass, Message: "Kubernetes is disabled."}
+	}
+	kubeConfig, err := kubeconfig.Load()
+	if err != nil {
+		return Result{
+			Status:  StatusFail,
+			Message: err.Error(),
+			Fix:     "Fix or remove the broken kubeconfig file.",
+		}
+	}
+	fix := "Restart Rancher Desktop with Kubernetes enabled to add it again."
+	kubeContext, ok := kubeConfig.Context(kubeconfig.RancherDesktopContext)
+	if !ok {
+		return Result{
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("The kubeconfig has no %q context.", kubeconfig.RancherDesktopContext),
+			Fix:     fix,
+		}
+	}
+	if _, ok := kubeConfig.Cluster(kubeContext.Context.Cluster); !ok {
+		return Result{
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("The %q context refers to the missing cluster %q.", kubeconfig.RancherDesktopContext, kubeContext.Context.Cluster),
+			Fix:     fix,
+		}
+	}
+	return Result{Status: StatusPass, Message: fmt.Sprintf("The kubeconfig has the %q context.", kubeconfig.RancherDesktopContext)}
+}
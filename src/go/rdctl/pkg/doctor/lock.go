@@ -0,0 +1,54 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+)
+
+func init() {
+	register(Check{
+		Name:        "backend-lock",
+		Description: "The backend is not locked by a process that is gone",
+		Run:         checkBackendLock,
+		Fix:         fixBackendLock,
+	})
+}
+
+func checkBackendLock(_ context.Context, env *Environment) Result {
+	status, err := lock.Status(env.Paths)
+	switch {
+	case err != nil:
+		return Result{Status: StatusFail, Message: err.Error()}
+	case !status.Locked:
+		return Result{Status: StatusPass, Message: "The backend is not locked."}
+	case status.Stale:
+		return Result{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("The backend lock was left behind (%s; %s).", status.Data, status.Reason),
+			Fix:     `Remove the lock with "rdctl snapshot unlock".`,
+			Fixable: true,
+		}
+	}
+	message := fmt.Sprintf("The backend is locked (%s).", status.Reason)
+	if status.Data != nil {
+		message = fmt.Sprintf("The backend is locked (%s; %s).", status.Data, status.Reason)
+	}
+	return Result{
+		Status:  StatusWarn,
+		Message: message,
+		Fix:     `Wait for the operation holding the lock to finish; if it was interrupted, remove the lock with "rdctl snapshot unlock".`,
+	}
+}
+
+func fixBackendLock(_ context.Context, env *Environment) error {
+	status, err := lock.RemoveStale(env.Paths)
+	if err != nil {
+		return err
+	}
+	if status.Locked && !status.Stale {
+		return fmt.Errorf("the lock was not removed: %s", status.Reason)
+	}
+	return nil
+}
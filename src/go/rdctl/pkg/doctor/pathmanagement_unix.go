@@ -0,0 +1,102 @@
+//go:build unix
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The strategies for application.pathManagementStrategy.
+const (
+	pathManagementManual  = "manual"
+	pathManagementRCFiles = "rcfiles"
+)
+
+// The files bash reads for login shells; Rancher Desktop adds its PATH line
+// to the first one that exists.
+var bashLoginFiles = []string{".bash_profile", ".bash_login", ".profile"}
+
+func init() {
+	register(Check{
+		Name:        "path-management",
+		Description: "The directory of Rancher Desktop's executables is added to PATH",
+		Run:         checkPathManagement,
+	})
+}
+
+func checkPathManagement(_ context.Context, env *Environment) Result {
+	strategy, _ := env.setting("application", "pathManagementStrategy").(string)
+	switch strategy {
+	case pathManagementRCFiles:
+		return checkRCFiles(env)
+	case pathManagementManual:
+		if slices.Contains(filepath.SplitList(os.Getenv("PATH")), env.Paths.Integration) {
+			return Result{Status: StatusPass, Message: fmt.Sprintf("%s is in PATH.", env.Paths.Integration)}
+		}
+		return Result{
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("PATH is managed manually, and %s is not in it.", env.Paths.Integration),
+			Fix:     fmt.Sprintf("Add %s to PATH in your shell's startup files, or let Rancher Desktop manage PATH automatically.", env.Paths.Integration),
+		}
+	}
+	return Result{Status: StatusPass, Message: "PATH management has not been configured."}
+}
+
+func checkRCFiles(env *Environment) Result {
+	var missing []string
+	for rcFile, line := range expectedPathLines(env) {
+		contents, err := os.ReadFile(rcFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Result{Status: StatusFail, Message: fmt.Sprintf("Failed to read %s: %s", rcFile, err)}
+		}
+		if !bytes.Contains(contents, []byte(line)) {
+			missing = append(missing, rcFile)
+		}
+	}
+	if len(missing) == 0 {
+		return Result{Status: StatusPass, Message: "The shell startup files add Rancher Desktop's executables to PATH."}
+	}
+	slices.Sort(missing)
+	return Result{
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("These shell startup files don't add %s to PATH: %s", env.Paths.Integration, strings.Join(missing, ", ")),
+		Fix:     "Restart Rancher Desktop to rewrite them, and make sure they are writable.",
+	}
+}
+
+// Returns the line that Rancher Desktop adds to each of the shell startup
+// files it manages.
+func expectedPathLines(env *Environment) map[string]string {
+	integration := env.Paths.Integration
+	posixLine := fmt.Sprintf(`export PATH="%s:$PATH"`, integration)
+	cshLine := fmt.Sprintf(`setenv PATH "%s"\:"$PATH"`, integration)
+	fishLine := fmt.Sprintf(`set --export --prepend PATH "%s"`, integration)
+
+	loginFile := bashLoginFiles[0]
+	for _, name := range bashLoginFiles {
+		if _, err := os.Stat(filepath.Join(env.HomeDir, name)); err == nil {
+			loginFile = name
+			break
+		}
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(env.HomeDir, ".config")
+	}
+	return map[string]string{
+		filepath.Join(env.HomeDir, loginFile):            posixLine,
+		filepath.Join(env.HomeDir, ".bashrc"):            posixLine,
+		filepath.Join(env.HomeDir, ".zshrc"):             posixLine,
+		filepath.Join(env.HomeDir, ".cshrc"):             cshLine,
+		filepath.Join(env.HomeDir, ".tcshrc"):            cshLine,
+		filepath.Join(configHome, "fish", "config.fish"): fishLine,
+	}
+}
@@ -0,0 +1,202 @@
+// Package kubeconfig reads the kubeconfig files that Rancher Desktop adds its
+// Kubernetes context to. Only the fields that rdctl needs are parsed.
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RancherDesktopContext is the name of the context, cluster and user that
+// Rancher Desktop adds to the kubeconfig.
+const RancherDesktopContext = "rancher-desktop"
+
+// Config is the part of a kubeconfig that rdctl reads.
+type Config struct {
+	Clusters       []NamedCluster `yaml:"clusters"`
+	Contexts       []NamedContext `yaml:"contexts"`
+	Users          []NamedUser    `yaml:"users"`
+	CurrentContext string         `yaml:"current-context"`
+}
+
+type NamedCluster struct {
+	Name    string  `yaml:"name"`
+	Cluster Cluster `yaml:"cluster"`
+	// The directory of the file the cluster was read from, which relative
+	// paths are relative to.
+	dir string
+}
+
+type Cluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+}
+
+type NamedContext struct {
+	Name    string  `yaml:"name"`
+	Context Context `yaml:"context"`
+}
+
+type Context struct {
+	Cluster string `yaml:"cluster"`
+	User    string `yaml:"user"`
+}
+
+type NamedUser struct {
+	Name string `yaml:"name"`
+	User User   `yaml:"user"`
+	// The directory of the file the user was read from, which relative
+	// paths are relative to.
+	dir string
+}
+
+type User struct {
+	ClientCertificate     string `yaml:"client-certificate"`
+	ClientCertificateData string `yaml:"client-certificate-data"`
+	ClientKey             string `yaml:"client-key"`
+	ClientKeyData         string `yaml:"client-key-data"`
+	Token                 string `yaml:"token"`
+}
+
+// Paths returns the kubeconfig files that kubectl would read: those listed
+// in $KUBECONFIG, or ~/.kube/config.
+func Paths() ([]string, error) {
+	if value := os.Getenv("KUBECONFIG"); value != "" {
+		var result []string
+		for _, path := range filepath.SplitList(value) {
+			if path != "" {
+				result = append(result, path)
+			}
+		}
+		return result, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return []string{filepath.Join(homeDir, ".kube", "config")}, nil
+}
+
+// Load reads and merges the kubeconfig files returned by Paths, skipping
+// any that don't exist. As with kubectl, the first file to define a name
+// wins.
+func Load() (*Config, error) {
+	paths, err := Paths()
+	if err != nil {
+		return nil, err
+	}
+	result := &Config{}
+	for _, path := range paths {
+		config, err := LoadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		result.merge(config)
+	}
+	return result, nil
+}
+
+// LoadFile reads a single kubeconfig file.
+func LoadFile(path string) (*Config, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %q: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for i := range config.Clusters {
+		config.Clusters[i].dir = dir
+	}
+	for i := range config.Users {
+		config.Users[i].dir = dir
+	}
+	return config, nil
+}
+
+func (config *Config) merge(other *Config) {
+	for _, cluster := range other.Clusters {
+		if _, ok := config.Cluster(cluster.Name); !ok {
+			config.Clusters = append(config.Clusters, cluster)
+		}
+	}
+	for _, context := range other.Contexts {
+		if _, ok := config.Context(context.Name); !ok {
+			config.Contexts = append(config.Contexts, context)
+		}
+	}
+	for _, user := range other.Users {
+		if _, ok := config.User(user.Name); !ok {
+			config.Users = append(config.Users, user)
+		}
+	}
+	if config.CurrentContext == "" {
+		config.CurrentContext = other.CurrentContext
+	}
+}
+
+// Cluster returns the cluster with the given name.
+func (config *Config) Cluster(name string) (NamedCluster, bool) {
+	for _, cluster := range config.Clusters {
+		if cluster.Name == name {
+			return cluster, true
+		}
+	}
+	return NamedCluster{}, false
+}
+
+// Context returns the context with the given name.
+func (config *Config) Context(name string) (NamedContext, bool) {
+	for _, context := range config.Contexts {
+		if context.Name == name {
+			return context, true
+		}
+	}
+	return NamedContext{}, false
+}
+
+// User returns the user with the given name.
+func (config *Config) User(name string) (NamedUser, bool) {
+	for _, user := range config.Users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return NamedUser{}, false
+}
+
+// Returns the contents of a file referred to by a kubeconfig, either
+// inline as base64 in data, or by path, relative to dir.
+func readData(data, path, dir string) ([]byte, error) {
+	if data != "" {
+		return decodeBase64(data)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return os.ReadFile(path)
+}
+
+func decodeBase64(data string) ([]byte, error) {
+	result, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package kubeconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKubeconfig(t *testing.T, path, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Load should merge the files in KUBECONFIG", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "first")
+		second := filepath.Join(dir, "second")
+		writeKubeconfig(t, first, `
+clusters:
+- name: rancher-desktop
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: rancher-desktop
+  context:
+    cluster: rancher-desktop
+    user: rancher-desktop
+`)
+		writeKubeconfig(t, second, `
+current-context: other
+clusters:
+- name: rancher-desktop
+  cluster:
+    server: https://ignored:6443
+- name: other
+  cluster:
+    server: https://other:6443
+`)
+		t.Setenv("KUBECONFIG", first+string(filepath.ListSeparator)+filepath.Join(dir, "missing")+string(filepath.ListSeparator)+second)
+
+		config, err := Load()
+		require.NoError(t, err)
+		assert.Equal(t, "other", config.CurrentContext)
+		require.Len(t, config.Clusters, 2)
+		cluster, ok := config.Cluster(RancherDesktopContext)
+		require.True(t, ok)
+		assert.Equal(t, "https://127.0.0.1:6443", cluster.Cluster.Server)
+		_, ok = config.Context(RancherDesktopContext)
+		assert.True(t, ok)
+	})
+
+	t.Run("Load should return an empty config if there are no files", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+		config, err := Load()
+		require.NoError(t, err)
+		assert.Empty(t, config.Contexts)
+	})
+}
+
+func TestReady(t *testing.T) {
+	var notReady atomic.Bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/readyz", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if notReady.Load() {
+			http.Error(w, "[-]etcd failed", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	// The certificate authority is given by a path relative to the
+	// kubeconfig.
+	dir := t.TempDir()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), caPEM, 0o600))
+	path := filepath.Join(dir, "config")
+	writeKubeconfig(t, path, `
+clusters:
+- name: rancher-desktop
+  cluster:
+    server: `+server.URL+`
+    certificate-authority: ca.crt
+contexts:
+- name: rancher-desktop
+  context:
+    cluster: rancher-desktop
+    user: rancher-desktop
+users:
+- name: rancher-desktop
+  user:
+    token: secret
+`)
+	config, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.NoError(t, config.Ready(context.Background(), RancherDesktopContext))
+	notReady.Store(true)
+	assert.ErrorIs(t, config.Ready(context.Background(), RancherDesktopContext), ErrNotReady)
+	assert.ErrorContains(t, config.Ready(context.Background(), "missing"), `no context "missing"`)
+
+	t.Run("Inline certificate authorities should be decoded", func(t *testing.T) {
+		config := &Config{
+			Clusters: []NamedCluster{{Name: "inline", Cluster: Cluster{
+				Server:                   server.URL,
+				CertificateAuthorityData: base64.StdEncoding.EncodeToString(caPEM),
+			}}},
+			Contexts: []NamedContext{{Name: "inline", Context: Context{Cluster: "inline"}}},
+		}
+		httpClient, serverURL, err := config.HTTPClient("inline")
+		require.NoError(t, err)
+		assert.Equal(t, server.URL, serverURL)
+		assert.NotNil(t, httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs)
+	})
+}
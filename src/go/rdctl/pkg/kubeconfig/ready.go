@@ -0,0 +1,109 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Returned by Ready when the API server answers, but is not ready.
+var ErrNotReady = errors.New("the Kubernetes API server is not ready")
+
+// How long to wait for the API server to answer a readiness check.
+const readyTimeout = 5 * time.Second
+
+// HTTPClient returns a client that authenticates to the API server of the
+// cluster of the given context, and the URL of that server.
+func (config *Config) HTTPClient(contextName string) (*http.Client, string, error) {
+	namedContext, ok := config.Context(contextName)
+	if !ok {
+		return nil, "", fmt.Errorf("kubeconfig has no context %q", contextName)
+	}
+	cluster, ok := config.Cluster(namedContext.Context.Cluster)
+	if !ok {
+		return nil, "", fmt.Errorf("kubeconfig has no cluster %q", namedContext.Context.Cluster)
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cluster.Cluster.InsecureSkipTLSVerify, //nolint:gosec // as configured by the user
+	}
+	caData, err := readData(cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority, cluster.dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read certificate authority of cluster %q: %w", cluster.Name, err)
+	}
+	if caData != nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caData) {
+			return nil, "", fmt.Errorf("failed to parse certificate authority of cluster %q", cluster.Name)
+		}
+	}
+	token := ""
+	if user, ok := config.User(namedContext.Context.User); ok {
+		certData, err := readData(user.User.ClientCertificateData, user.User.ClientCertificate, user.dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read client certificate of user %q: %w", user.Name, err)
+		}
+		keyData, err := readData(user.User.ClientKeyData, user.User.ClientKey, user.dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read client key of user %q: %w", user.Name, err)
+		}
+		if certData != nil && keyData != nil {
+			certificate, err := tls.X509KeyPair(certData, keyData)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to parse client certificate of user %q: %w", user.Name, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+		token = user.User.Token
+	}
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+		// The client is used once per check.
+		DisableKeepAlives: true,
+	}
+	if token != "" {
+		transport = &bearerTransport{token: token, next: transport}
+	}
+	return &http.Client{Transport: transport, Timeout: readyTimeout}, cluster.Cluster.Server, nil
+}
+
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (transport *bearerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.Header.Set("Authorization", "Bearer "+transport.token)
+	return transport.next.RoundTrip(request)
+}
+
+// Ready checks whether the API server of the cluster of the given context
+// reports that it is ready. It returns an error wrapping ErrNotReady if the
+// server answers that it isn't, and other errors if it can't be reached.
+func (config *Config) Ready(ctx context.Context, contextName string) error {
+	httpClient, server, err := config.HTTPClient(contextName)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(server, "/")+"/readyz", nil)
+	if err != nil {
+		return err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach the Kubernetes API server: %w", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s: %s", ErrNotReady, response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
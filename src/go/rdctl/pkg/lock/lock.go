@@ -7,46 +7,286 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"slices"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/process"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
 )
 
 const backendLockName = "backend.lock"
 
+// Created while a stale backend lock is being removed, so that a process
+// that finds the same stale lock can't remove a fresh lock taken after it.
+// If the process removing the lock dies, it is left behind, and stale locks
+// are no longer removed automatically until `rdctl snapshot unlock` is run.
+const clearingLockName = "backend.lock.clearing"
+
+const (
+	// How long to wait for the backend to stop before giving up.
+	stopTimeout = 2 * time.Minute
+	// How long to wait for the backend to start; starting includes booting
+	// the VM and Kubernetes, which can take much longer than stopping.
+	startTimeout = 10 * time.Minute
+)
+
 type BackendLocker interface {
 	Lock(ctx context.Context, appPaths *paths.Paths, action string) error
 	Unlock(ctx context.Context, appPaths *paths.Paths, restart bool) error
+	// LockMetadata takes the lock for an operation that only changes
+	// snapshot metadata, so the backend can keep running. It is released
+	// with Unlock, without restarting.
+	LockMetadata(appPaths *paths.Paths, action string) error
+	// LockFailed takes the lock like Lock, but also when the backend is in
+	// the ERROR state, so that changes that made it fail to start can be
+	// rolled back.
+	LockFailed(ctx context.Context, appPaths *paths.Paths, action string) error
+	// WaitForStarted waits for a backend restarted by Unlock to finish
+	// starting, returning an error if it fails to start.
+	WaitForStarted(ctx context.Context) error
 }
 
 type BackendLock struct {
 }
 
+// LockData is written to the lock file, and describes the operation and
+// process that hold the lock. Locks taken by older versions of rdctl only
+// record the action.
 type LockData struct {
 	Action string `json:"action"`
+	// The process that holds the lock.
+	PID      int    `json:"pid,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	// When the process that holds the lock started. On Linux, this is
+	// derived from the boot time, which moves as the clock is adjusted, so
+	// a mismatch only suggests that the PID was reused.
+	ProcessStartTime time.Time `json:"processStartTime,omitzero"`
+	// On Linux, when the process that holds the lock started, in clock
+	// ticks since boot, and the ID of that boot; these never change, and
+	// tell it apart from a later process that reuses its PID.
+	ProcessStartTicks uint64 `json:"processStartTicks,omitempty"`
+	BootID            string `json:"bootId,omitempty"`
+	// When the lock was taken.
+	Created time.Time `json:"created,omitzero"`
+	// The version of rdctl that took the lock.
+	Version string `json:"version,omitempty"`
+}
+
+// LockStatus describes the state of the backend lock.
+type LockStatus struct {
+	Locked bool `json:"locked"`
+	// The contents of the lock file; nil if the backend is not locked or
+	// the lock file can't be parsed.
+	Data *LockData `json:"data,omitempty"`
+	// Whether the process that holds the lock is known to be gone, so that
+	// the lock can safely be removed.
+	Stale bool `json:"stale"`
+	// Why the lock is or isn't stale.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Returns the data for a lock taken by the current process.
+func newLockData(action string) LockData {
+	lockData := LockData{
+		Action:  action,
+		PID:     os.Getpid(),
+		Created: time.Now(),
+		Version: version.Version,
+	}
+	lockData.Hostname, _ = os.Hostname()
+	if startTime, err := process.GetProcessStartTime(lockData.PID); err == nil {
+		lockData.ProcessStartTime = startTime
+	}
+	if ticks, err := process.GetProcessStartTicks(lockData.PID); err == nil {
+		if bootID, err := process.GetBootID(); err == nil {
+			lockData.ProcessStartTicks, lockData.BootID = ticks, bootID
+		}
+	}
+	return lockData
+}
+
+// Describes the lock, for error messages.
+func (lockData LockData) String() string {
+	if lockData.PID == 0 {
+		return fmt.Sprintf("action %q, by an unknown process", lockData.Action)
+	}
+	return fmt.Sprintf("action %q, by process %d on %s, since %s, rdctl version %s",
+		lockData.Action, lockData.PID, lockData.Hostname, lockData.Created.Local().Format(time.RFC3339), lockData.Version)
+}
+
+// Status reports whether the backend is locked, and if so, by whom and
+// whether the lock is stale.
+func Status(appPaths *paths.Paths) (LockStatus, error) {
+	contents, err := os.ReadFile(filepath.Join(appPaths.AppHome, backendLockName))
+	if errors.Is(err, os.ErrNotExist) {
+		return LockStatus{}, nil
+	} else if err != nil {
+		return LockStatus{}, fmt.Errorf("failed to read backend lock: %w", err)
+	}
+	return parseLockStatus(contents), nil
+}
+
+func parseLockStatus(contents []byte) LockStatus {
+	status := LockStatus{Locked: true}
+	lockData := LockData{}
+	if err := json.Unmarshal(contents, &lockData); err != nil {
+		// The lock file is created empty and then written, so this may
+		// be a lock that is being taken right now.
+		status.Reason = fmt.Sprintf("the lock file can't be parsed: %s", err)
+		return status
+	}
+	status.Data = &lockData
+	status.Stale, status.Reason = isStale(lockData)
+	return status
+}
+
+// Reports whether the process that took a lock is provably gone, and why.
+// Only locks that record their owner can be stale; a lock is never stale
+// merely because it is old, as restoring a snapshot can take a long time.
+func isStale(lockData LockData) (bool, string) {
+	if lockData.PID == 0 {
+		return false, "the lock was taken by a version of rdctl that does not record its owner"
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false, fmt.Sprintf("the current hostname can't be determined: %s", err)
+	}
+	if lockData.Hostname != hostname {
+		return false, fmt.Sprintf("the lock is held by a process on another host, %q", lockData.Hostname)
+	}
+	startTime, err := process.GetProcessStartTime(lockData.PID)
+	if errors.Is(err, process.ErrNoSuchProcess) {
+		return true, fmt.Sprintf("process %d is no longer running", lockData.PID)
+	} else if err != nil {
+		return false, fmt.Sprintf("process %d can't be checked: %s", lockData.PID, err)
+	}
+	if lockData.BootID != "" {
+		return isReused(lockData)
+	}
+	if !lockData.ProcessStartTime.IsZero() && !startTime.Equal(lockData.ProcessStartTime) {
+		return false, fmt.Sprintf("process %d is running, but started at %s rather than %s; its PID may have been reused",
+			lockData.PID, startTime.Local().Format(time.RFC3339), lockData.ProcessStartTime.Local().Format(time.RFC3339))
+	}
+	return false, fmt.Sprintf("process %d is running", lockData.PID)
+}
+
+// Reports whether the process that took a lock that records its start in
+// clock ticks since boot has exited and its PID was reused.
+func isReused(lockData LockData) (bool, string) {
+	bootID, err := process.GetBootID()
+	if err != nil {
+		return false, fmt.Sprintf("process %d can't be checked: %s", lockData.PID, err)
+	}
+	if bootID != lockData.BootID {
+		return true, fmt.Sprintf("the system has restarted since process %d took the lock", lockData.PID)
+	}
+	ticks, err := process.GetProcessStartTicks(lockData.PID)
+	if errors.Is(err, process.ErrNoSuchProcess) {
+		return true, fmt.Sprintf("process %d is no longer running", lockData.PID)
+	} else if err != nil {
+		return false, fmt.Sprintf("process %d can't be checked: %s", lockData.PID, err)
+	}
+	if ticks != lockData.ProcessStartTicks {
+		return true, fmt.Sprintf("process %d has exited, and its PID was reused by another process", lockData.PID)
+	}
+	return false, fmt.Sprintf("process %d is running", lockData.PID)
+}
+
+// Removes the backend lock if it is stale, returning the status of the lock
+// that was found. The lock is checked again while holding the clearing lock,
+// so that a lock taken after the stale one was removed is never removed.
+func removeStaleLock(appPaths *paths.Paths) (LockStatus, error) {
+	status, err := Status(appPaths)
+	if err != nil || !status.Stale {
+		return status, err
+	}
+	clearingPath := filepath.Join(appPaths.AppHome, clearingLockName)
+	clearingFile, err := os.OpenFile(clearingPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		status.Stale = false
+		status.Reason += ", but another process is removing the lock, or an earlier attempt to remove it was interrupted"
+		return status, nil
+	}
+	_ = clearingFile.Close()
+	defer func() {
+		_ = os.Remove(clearingPath)
+	}()
+	if status, err = Status(appPaths); err != nil || !status.Stale {
+		return status, err
+	}
+	if err := os.Remove(filepath.Join(appPaths.AppHome, backendLockName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return status, fmt.Errorf("failed to remove stale backend lock: %w", err)
+	}
+	return status, nil
 }
 
 // Lock the backend by creating the lock file and shutting down the VM.
 // The lock file will be deleted if Lock returns an error (e.g. the backend couldn't be stopped).
+// A stale lock left behind by a process that no longer exists is removed first.
 func (lock *BackendLock) Lock(ctx context.Context, appPaths *paths.Paths, action string) error {
+	return lockAndStop(ctx, appPaths, action, false)
+}
+
+// LockFailed locks the backend like Lock, but also accepts a backend that
+// failed to start.
+func (lock *BackendLock) LockFailed(ctx context.Context, appPaths *paths.Paths, action string) error {
+	return lockAndStop(ctx, appPaths, action, true)
+}
+
+func lockAndStop(ctx context.Context, appPaths *paths.Paths, action string, allowError bool) error {
+	if err := createLockFile(appPaths, action); err != nil {
+		return err
+	}
+	err := ensureBackendStopped(ctx, action, allowError)
+	if err != nil {
+		_ = os.Remove(filepath.Join(appPaths.AppHome, backendLockName))
+	}
+	return err
+}
+
+// LockMetadata creates the lock file without stopping the backend.
+func (lock *BackendLock) LockMetadata(appPaths *paths.Paths, action string) error {
+	return createLockFile(appPaths, action)
+}
+
+// Creates the lock file, removing a stale one first.
+func createLockFile(appPaths *paths.Paths, action string) error {
 	if err := os.MkdirAll(appPaths.AppHome, 0o755); err != nil {
 		return fmt.Errorf("failed to create backend lock parent directory %q: %w", appPaths.AppHome, err)
 	}
-	// Create an empty file whose presence signifies that the backend is locked.
+	// Create a file whose presence signifies that the backend is locked.
 	lockPath := filepath.Join(appPaths.AppHome, backendLockName)
 	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0o644)
 	if errors.Is(err, os.ErrExist) {
-		return errors.New("backend lock file already exists; if there is no snapshot operation in progress, you can remove this error with `rdctl snapshot unlock`")
+		status, statusErr := removeStaleLock(appPaths)
+		if statusErr != nil {
+			return statusErr
+		}
+		if status.Locked && !status.Stale {
+			return lockedError(status)
+		}
+		if status.Stale {
+			logrus.Warnf("Removed stale backend lock (%s): %s", status.Data, status.Reason)
+		}
+		// The lock was either removed as stale, or released since we
+		// tried to take it.
+		file, err = os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			status, _ = Status(appPaths)
+			return lockedError(status)
+		} else if err != nil {
+			return fmt.Errorf("unexpected error acquiring backend lock: %w", err)
+		}
 	} else if err != nil {
 		return fmt.Errorf("unexpected error acquiring backend lock: %w", err)
 	}
 
-	lockData := LockData{
-		Action: action,
-	}
+	lockData := newLockData(action)
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(lockData); err != nil {
@@ -58,11 +298,41 @@ func (lock *BackendLock) Lock(ctx context.Context, appPaths *paths.Paths, action
 	if err := file.Close(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "failed to close backend lock file descriptor: %s", err)
 	}
-	err = ensureBackendStopped(ctx, action)
-	if err != nil {
-		_ = os.Remove(lockPath)
+	return nil
+}
+
+// Returns the error for a lock that is held, describing who holds it.
+func lockedError(status LockStatus) error {
+	const hint = "if there is no snapshot operation in progress, you can remove this error with `rdctl snapshot unlock`"
+	if status.Data == nil {
+		return fmt.Errorf("backend lock file already exists; %s", hint)
 	}
-	return err
+	return fmt.Errorf("backend is locked (%s; %s); %s", status.Data, status.Reason, hint)
+}
+
+// Remove removes the backend lock, including anything left behind by an
+// interrupted removal of a stale lock, without restarting the backend.
+func Remove(appPaths *paths.Paths) error {
+	return errors.Join(
+		os.RemoveAll(filepath.Join(appPaths.AppHome, backendLockName)),
+		os.RemoveAll(filepath.Join(appPaths.AppHome, clearingLockName)))
+}
+
+// RemoveStale removes the backend lock if the process that took it is gone,
+// returning the status of the lock that was found.
+func RemoveStale(appPaths *paths.Paths) (LockStatus, error) {
+	return removeStaleLock(appPaths)
+}
+
+// IsLocked reports whether the backend lock file exists.
+func IsLocked(appPaths *paths.Paths) (bool, error) {
+	_, err := os.Stat(filepath.Join(appPaths.AppHome, backendLockName))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check backend lock: %w", err)
+	}
+	return true, nil
 }
 
 // Unlock the backend by removing the lock file. Restart the VM if the file was deleted and `restart` is true.
@@ -80,9 +350,9 @@ func ensureBackendStarted(ctx context.Context) error {
 	if err != nil || connectionInfo == nil {
 		return err
 	}
-	rdClient := client.NewRDClient(connectionInfo)
+	rdClient := sdk.NewClient(client.NewRDClient(connectionInfo))
 	desiredState := client.BackendState{
-		VMState: "STARTED",
+		VMState: client.StateStarted,
 		Locked:  false,
 	}
 	err = rdClient.UpdateBackendState(ctx, desiredState)
@@ -92,14 +362,35 @@ func ensureBackendStarted(ctx context.Context) error {
 	return nil
 }
 
-func ensureBackendStopped(ctx context.Context, action string) error {
+// WaitForStarted waits for the backend to leave the STOPPED and STARTING
+// states. It returns an error if the backend ends up in the ERROR state, and
+// nil if the main process is not running.
+func (lock *BackendLock) WaitForStarted(ctx context.Context) error {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return err
+	}
+	rdClient := sdk.NewClient(client.NewRDClient(connectionInfo))
+	state, err := rdClient.WaitForState(ctx, startTimeout, client.StateStarted, client.StateDisabled, client.StateError)
+	if errors.Is(err, client.ErrConnectionRefused) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error waiting for backend to start: %w", err)
+	}
+	if state.VMState == client.StateError {
+		return errors.New("backend failed to start")
+	}
+	return nil
+}
+
+func ensureBackendStopped(ctx context.Context, action string, allowError bool) error {
 	connectionInfo, err := config.GetConnectionInfo(true)
 	if err != nil || connectionInfo == nil {
 		return err
 	}
 
 	// Ensure backend is running if the main process is running at all
-	rdClient := client.NewRDClient(connectionInfo)
+	rdClient := sdk.NewClient(client.NewRDClient(connectionInfo))
 	state, err := rdClient.GetBackendState(ctx)
 	if errors.Is(err, client.ErrConnectionRefused) {
 		// If we cannot connect to the server, assume that the main
@@ -108,38 +399,35 @@ func ensureBackendStopped(ctx context.Context, action string) error {
 	} else if err != nil {
 		return fmt.Errorf("failed to get backend state: %w", err)
 	}
-	if state.VMState != "STARTED" && state.VMState != "DISABLED" {
-		return fmt.Errorf("Rancher Desktop state is %v. It must be fully running or fully shut down to perform the action: %s", state.VMState, action)
+	if err := checkStateForLock(state.VMState, action, allowError); err != nil {
+		return err
 	}
 
 	// Stop and lock the backend
 	desiredState := client.BackendState{
-		VMState: "STOPPED",
+		VMState: client.StateStopped,
 		Locked:  true,
 	}
 	if err := rdClient.UpdateBackendState(ctx, desiredState); err != nil {
 		return fmt.Errorf("failed to stop backend: %w", err)
 	}
-	if err := waitForVMState(ctx, rdClient, []string{"STOPPED"}); err != nil {
+	if _, err := rdClient.WaitForState(ctx, stopTimeout, client.StateStopped); err != nil {
 		return fmt.Errorf("error waiting for backend to stop: %w", err)
 	}
 
 	return nil
 }
 
-// Normally snapshots can be created at state STARTED or DISABLED
-func waitForVMState(ctx context.Context, rdClient client.RDClient, desiredStates []string) error {
-	interval := 1 * time.Second
-	numIntervals := 120
-	for range numIntervals {
-		state, err := rdClient.GetBackendState(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to poll backend state: %w", err)
-		}
-		if slices.Contains(desiredStates, state.VMState) {
+// Returns an error unless the backend can be stopped and locked in state;
+// the ERROR state is only accepted if allowError is true.
+func checkStateForLock(state, action string, allowError bool) error {
+	switch state {
+	case client.StateStarted, client.StateDisabled:
+		return nil
+	case client.StateError:
+		if allowError {
 			return nil
 		}
-		time.Sleep(interval)
 	}
-	return fmt.Errorf("timed out waiting for backend state in %s", desiredStates)
+	return fmt.Errorf("Rancher Desktop state is %v. It must be fully running or fully shut down to perform the action: %s", state, action)
 }
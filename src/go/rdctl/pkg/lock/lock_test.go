@@ -0,0 +1,167 @@
+package lock
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+func writeLockFile(t *testing.T, appPaths *paths.Paths, lockData any) {
+	contents, err := json.Marshal(lockData)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(appPaths.AppHome, backendLockName), contents, 0o644))
+}
+
+// Returns the PID of a process that has exited.
+func exitedPid(t *testing.T) int {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command(exe, "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func TestStatus(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	t.Run("unlocked", func(t *testing.T) {
+		appPaths := &paths.Paths{AppHome: t.TempDir()}
+		status, err := Status(appPaths)
+		require.NoError(t, err)
+		assert.False(t, status.Locked)
+	})
+
+	testCases := []struct {
+		name     string
+		lockData func(t *testing.T) any
+		stale    bool
+	}{
+		{
+			name: "held by the current process",
+			lockData: func(t *testing.T) any {
+				return newLockData("testing")
+			},
+		},
+		{
+			name: "held by a process that has exited",
+			lockData: func(t *testing.T) any {
+				lockData := newLockData("testing")
+				lockData.PID = exitedPid(t)
+				return lockData
+			},
+			stale: true,
+		},
+		{
+			name: "held by a process whose PID was reused",
+			lockData: func(t *testing.T) any {
+				lockData := newLockData("testing")
+				if lockData.BootID == "" {
+					t.Skip("process start ticks are not supported on this platform")
+				}
+				lockData.ProcessStartTicks--
+				return lockData
+			},
+			stale: true,
+		},
+		{
+			name: "held by a process from an earlier boot",
+			lockData: func(t *testing.T) any {
+				lockData := newLockData("testing")
+				if lockData.BootID == "" {
+					t.Skip("process start ticks are not supported on this platform")
+				}
+				lockData.BootID = "earlier-boot"
+				return lockData
+			},
+			stale: true,
+		},
+		{
+			// The start time may move as the clock is adjusted, so it
+			// can't prove that the PID was reused.
+			name: "held by a process with a different start time",
+			lockData: func(t *testing.T) any {
+				lockData := newLockData("testing")
+				lockData.ProcessStartTicks, lockData.BootID = 0, ""
+				lockData.ProcessStartTime = lockData.ProcessStartTime.Add(-time.Hour)
+				return lockData
+			},
+		},
+		{
+			name: "held by a process on another host",
+			lockData: func(t *testing.T) any {
+				lockData := newLockData("testing")
+				lockData.PID = exitedPid(t)
+				lockData.Hostname = hostname + "-other"
+				return lockData
+			},
+		},
+		{
+			name: "taken by an older version",
+			lockData: func(t *testing.T) any {
+				return map[string]string{"action": "testing"}
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			appPaths := &paths.Paths{AppHome: t.TempDir()}
+			writeLockFile(t, appPaths, testCase.lockData(t))
+			status, err := Status(appPaths)
+			require.NoError(t, err)
+			assert.True(t, status.Locked)
+			require.NotNil(t, status.Data)
+			assert.Equal(t, "testing", status.Data.Action)
+			assert.Equal(t, testCase.stale, status.Stale, status.Reason)
+
+			_, err = removeStaleLock(appPaths)
+			require.NoError(t, err)
+			_, err = os.Stat(filepath.Join(appPaths.AppHome, backendLockName))
+			if testCase.stale {
+				assert.ErrorIs(t, err, os.ErrNotExist, "stale lock should be removed")
+			} else {
+				assert.NoError(t, err, "lock should be kept")
+			}
+		})
+	}
+
+	t.Run("stale lock is kept while another process is removing it", func(t *testing.T) {
+		appPaths := &paths.Paths{AppHome: t.TempDir()}
+		lockData := newLockData("testing")
+		lockData.PID = exitedPid(t)
+		writeLockFile(t, appPaths, lockData)
+		require.NoError(t, os.WriteFile(filepath.Join(appPaths.AppHome, clearingLockName), []byte{}, 0o644))
+		status, err := removeStaleLock(appPaths)
+		require.NoError(t, err)
+		assert.False(t, status.Stale)
+		assert.FileExists(t, filepath.Join(appPaths.AppHome, backendLockName))
+
+		require.NoError(t, Remove(appPaths))
+		status, err = Status(appPaths)
+		require.NoError(t, err)
+		assert.False(t, status.Locked)
+		assert.NoFileExists(t, filepath.Join(appPaths.AppHome, clearingLockName))
+	})
+}
+
+func TestCheckStateForLock(t *testing.T) {
+	for _, state := range []string{client.StateStarted, client.StateDisabled} {
+		assert.NoError(t, checkStateForLock(state, "testing", false), state)
+		assert.NoError(t, checkStateForLock(state, "testing", true), state)
+	}
+	for _, state := range []string{client.StateStarting, client.StateStopping, client.StateStopped} {
+		assert.Error(t, checkStateForLock(state, "testing", false), state)
+		assert.Error(t, checkStateForLock(state, "testing", true), state)
+	}
+	assert.ErrorContains(t, checkStateForLock(client.StateError, "testing", false), "state is ERROR")
+	assert.NoError(t, checkStateForLock(client.StateError, "testing", true), "a failed backend can be locked to roll back")
+}
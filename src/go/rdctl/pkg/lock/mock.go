@@ -7,12 +7,26 @@ import (
 )
 
 type MockBackendLock struct {
+	// Returned by WaitForStarted, to simulate a backend that fails to start.
+	StartError error
 }
 
 func (lock *MockBackendLock) Lock(ctx context.Context, appPaths *paths.Paths, action string) error {
 	return nil
 }
 
+func (lock *MockBackendLock) LockMetadata(appPaths *paths.Paths, action string) error {
+	return nil
+}
+
+func (lock *MockBackendLock) LockFailed(ctx context.Context, appPaths *paths.Paths, action string) error {
+	return nil
+}
+
 func (lock *MockBackendLock) Unlock(ctx context.Context, appPaths *paths.Paths, restart bool) error {
 	return nil
 }
+
+func (lock *MockBackendLock) WaitForStarted(ctx context.Context) error {
+	return lock.StartError
+}
@@ -0,0 +1,171 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lima"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// Component is a part of Rancher Desktop that writes logs.
+type Component struct {
+	Name        string
+	Description string
+	// The log files in the logs directory.
+	Files []string
+	// The log files in the directory of the Lima instance.
+	LimaFiles []string
+	// The log files in the VM; on Windows, most services in the VM write
+	// to the logs directory instead.
+	VMFiles []string
+}
+
+// Components lists the components whose logs can be shown. Any other log
+// file in the logs directory can be named by its name without ".log".
+var Components = []Component{
+	{
+		Name:        "background",
+		Description: "the main process",
+		Files:       []string{"background.log"},
+	},
+	{
+		Name:        "lima",
+		Description: "the Lima VM and its host agent",
+		Files:       []string{"lima.log"},
+		LimaFiles:   []string{"ha.stderr.log", "ha.stdout.log"},
+		VMFiles:     []string{"/var/log/lima-guestagent.log"},
+	},
+	{
+		Name:        "wsl",
+		Description: "the WSL distribution",
+		Files:       []string{"wsl.log", "wsl-exec.log", "wsl-helper.log", "wsl-init.log"},
+	},
+	{
+		Name:        "networking",
+		Description: "the host and VM networking services",
+		Files:       []string{"networking.log", "networking-ca.log", "host-switch.log", "vm-switch.log", "network-setup.log", "wsl-proxy.log"},
+	},
+	{
+		Name:        "guest-agent",
+		Description: "the Rancher Desktop guest agent, which forwards ports",
+		Files:       []string{"rancher-desktop-guestagent.log"},
+		VMFiles:     []string{"/var/log/rancher-desktop-guestagent.log"},
+	},
+	{
+		Name:        "kubernetes",
+		Description: "k3s, and the management of Kubernetes",
+		Files:       []string{"kube.log", "k3s.log"},
+		VMFiles:     []string{"/var/log/k3s.log"},
+	},
+	{
+		Name:        "container-engine",
+		Description: "dockerd, containerd and buildkitd, and image management",
+		Files:       []string{"docker.log", "containerd.log", "buildkitd.log", "moby.log", "nerdctl.log", "images.log"},
+		VMFiles:     []string{"/var/log/docker.log", "/var/log/containerd.log", "/var/log/buildkitd.log"},
+	},
+	{
+		Name:        "api",
+		Description: "the API server used by rdctl",
+		Files:       []string{"server.log"},
+	},
+	{
+		Name:        "settings",
+		Description: "loading and saving settings, and deployment profiles",
+		Files:       []string{"settings.log", "deploymentProfile.log"},
+	},
+	{
+		Name:        "extensions",
+		Description: "Docker extensions",
+		Files:       []string{"extensions.log"},
+	},
+	{
+		Name:        "update",
+		Description: "checking for and installing updates",
+		Files:       []string{"update.log"},
+	},
+}
+
+// Sources returns the sources of the logs of the named components, or of
+// every log file if names is empty. In-VM logs are only included if vm is
+// true.
+func Sources(appPaths *paths.Paths, names []string, vm bool) ([]Source, error) {
+	var sources []Source
+	seen := map[string]bool{}
+	add := func(source Source) {
+		if !seen[source.Name()] {
+			seen[source.Name()] = true
+			sources = append(sources, source)
+		}
+	}
+	addComponent := func(component Component) {
+		for _, name := range component.Files {
+			add(newFileSource(strings.TrimSuffix(name, ".log"), filepath.Join(appPaths.Logs, name)))
+		}
+		if appPaths.Lima != "" {
+			for _, name := range component.LimaFiles {
+				add(newFileSource("lima:"+strings.TrimSuffix(name, ".log"), filepath.Join(appPaths.Lima, lima.InstanceName, name)))
+			}
+		}
+		if vm {
+			for _, path := range component.VMFiles {
+				add(newVMSource("vm:"+strings.TrimSuffix(filepath.Base(path), ".log"), path))
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		for _, component := range Components {
+			addComponent(component)
+		}
+		otherFiles, err := logFiles(appPaths.Logs)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range otherFiles {
+			add(newFileSource(strings.TrimSuffix(name, ".log"), filepath.Join(appPaths.Logs, name)))
+		}
+		return sources, nil
+	}
+
+	for _, name := range names {
+		index := slices.IndexFunc(Components, func(component Component) bool {
+			return component.Name == name
+		})
+		if index >= 0 {
+			addComponent(Components[index])
+			continue
+		}
+		path := filepath.Join(appPaths.Logs, name+".log")
+		if filepath.Base(path) != name+".log" {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("unknown component %q, and there is no %s", name, path)
+		}
+		add(newFileSource(name, path))
+	}
+	return sources, nil
+}
+
+// Returns the names of the log files in dir.
+func logFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".log") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
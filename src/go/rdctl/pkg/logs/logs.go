@@ -0,0 +1,221 @@
+// Package logs reads the logs of Rancher Desktop's components, on the host
+// and in the VM.
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// How often followed log files are checked for new lines.
+const pollInterval = 500 * time.Millisecond
+
+// Entry is a line of a log.
+type Entry struct {
+	// When the line was logged; lines without a timestamp, such as the
+	// continuation lines of a stack trace, have the time of the line
+	// before them. Zero if no line before them had a timestamp.
+	Time time.Time
+	// The name of the log.
+	Source string
+	Line   string
+}
+
+// Source is a log that can be read.
+type Source interface {
+	Name() string
+	// Read returns the lines logged so far.
+	Read(ctx context.Context) ([]Entry, error)
+	// Follow sends the lines logged after those returned by Read to
+	// entries, until ctx is done.
+	Follow(ctx context.Context, entries chan<- Entry) error
+}
+
+var (
+	// A timestamp at the start of a line, as written by Rancher Desktop
+	// ("2024-01-02T03:04:05.678Z: ...") and many other programs.
+	leadingTimePattern = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)`)
+	// A timestamp in a logrus text or JSON line (`time="..."` or
+	// `"time":"..."`).
+	timeFieldPattern = regexp.MustCompile(`\btime"?[=:]\s*"([^"]+)"`)
+)
+
+// The layouts of timestamps, after a space between the date and time has
+// been replaced by "T" and a comma before fractional seconds by ".".
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ParseTime returns the timestamp of a log line, if it has one. Timestamps
+// without a time zone are taken to be local.
+func ParseTime(line string) (time.Time, bool) {
+	match := leadingTimePattern.FindStringSubmatch(line)
+	if match == nil {
+		match = timeFieldPattern.FindStringSubmatch(line)
+	}
+	if match == nil {
+		return time.Time{}, false
+	}
+	value := strings.Replace(strings.Replace(match[1], " ", "T", 1), ",", ".", 1)
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseSince parses the value of --since, which is either a duration before
+// now, or an RFC 3339 timestamp or date.
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: must be a duration such as 10m, a timestamp such as 2006-01-02T15:04:05Z, or a date", value)
+}
+
+// Merge merges the entries of several logs, each in the order they were
+// logged, into one list ordered by time. Entries logged at the same time
+// keep the order of their logs.
+func Merge(logs [][]Entry) []Entry {
+	total := 0
+	for _, entries := range logs {
+		total += len(entries)
+	}
+	result := make([]Entry, 0, total)
+	next := make([]int, len(logs))
+	for len(result) < total {
+		best := -1
+		for i, entries := range logs {
+			if next[i] >= len(entries) {
+				continue
+			}
+			if best < 0 || entries[next[i]].Time.Before(logs[best][next[best]].Time) {
+				best = i
+			}
+		}
+		result = append(result, logs[best][next[best]])
+		next[best]++
+	}
+	return result
+}
+
+// Splits text into entries; the last line is only included if it is
+// complete, and the number of bytes used is returned. lastTime is the time
+// of the line before the text, and is updated.
+func parseEntries(source string, text []byte, lastTime *time.Time) ([]Entry, int) {
+	var entries []Entry
+	used := 0
+	for {
+		end := bytes.IndexByte(text[used:], '\n')
+		if end < 0 {
+			return entries, used
+		}
+		entries = append(entries, newEntry(source, string(text[used:used+end]), lastTime))
+		used += end + 1
+	}
+}
+
+// Returns the entry for a line, given the time of the line before it, which
+// is updated.
+func newEntry(source, line string, lastTime *time.Time) Entry {
+	line = strings.TrimSuffix(line, "\r")
+	if parsed, ok := ParseTime(line); ok {
+		*lastTime = parsed
+	}
+	return Entry{Time: *lastTime, Source: source, Line: line}
+}
+
+// A log file on the host.
+type fileSource struct {
+	name string
+	path string
+	// What was last read, so that following can start from there and
+	// notice when the file is replaced.
+	info     os.FileInfo
+	offset   int64
+	lastTime time.Time
+}
+
+func newFileSource(name, path string) *fileSource {
+	return &fileSource{name: name, path: path}
+}
+
+func (source *fileSource) Name() string {
+	return source.name
+}
+
+// Read returns nothing if the file doesn't exist, as not every component
+// runs on every platform.
+func (source *fileSource) Read(_ context.Context) ([]Entry, error) {
+	return source.readNew()
+}
+
+func (source *fileSource) Follow(ctx context.Context, entries chan<- Entry) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		newEntries, err := source.readNew()
+		if err != nil {
+			return err
+		}
+		for _, entry := range newEntries {
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Returns the complete lines added since the file was last read, starting
+// again from the beginning if it has been replaced or truncated.
+func (source *fileSource) readNew() ([]Entry, error) {
+	file, err := os.Open(source.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if source.info != nil && (!os.SameFile(source.info, info) || info.Size() < source.offset) {
+		source.offset = 0
+	}
+	source.info = info
+	if info.Size() == source.offset {
+		return nil, nil
+	}
+	text, err := io.ReadAll(io.NewSectionReader(file, source.offset, info.Size()-source.offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source.path, err)
+	}
+	entries, used := parseEntries(source.name, text, &source.lastTime)
+	source.offset += int64(used)
+	return entries, nil
+}
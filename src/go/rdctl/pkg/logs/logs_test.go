@@ -0,0 +1,169 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	testCases := map[string]string{
+		"Rancher Desktop":  "2024-01-02T03:04:05.678Z: Starting",
+		"logrus text":      `time="2024-01-02T03:04:05.678Z" level=info msg="Starting"`,
+		"logrus JSON":      `{"level":"info","msg":"Starting","time":"2024-01-02T03:04:05.678Z"}`,
+		"numeric offset":   "2024-01-02T04:04:05.678+0100 Starting",
+		"space and comma":  "[2024-01-02 03:04:05,678Z] Starting",
+		"offset with time": `time="2024-01-02T05:04:05.678+02:00" msg="Starting"`,
+	}
+	for name, line := range testCases {
+		t.Run(name, func(t *testing.T) {
+			parsed, ok := ParseTime(line)
+			require.True(t, ok)
+			assert.True(t, expected.Equal(parsed), "expected %s, got %s", expected, parsed)
+		})
+	}
+
+	t.Run("local time", func(t *testing.T) {
+		parsed, ok := ParseTime("2024-01-02 03:04:05 Starting")
+		require.True(t, ok)
+		assert.True(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local).Equal(parsed))
+	})
+
+	for _, line := range []string{"", "    at Object.<anonymous>", "I0102 03:04:05.678 Starting"} {
+		_, ok := ParseTime(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	since, err := ParseSince("10m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-10*time.Minute), since)
+
+	since, err = ParseSince("2024-01-01T00:00:00Z", now)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Equal(since))
+
+	since, err = ParseSince("2024-01-01", now)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local).Equal(since))
+
+	_, err = ParseSince("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	at := func(seconds int) time.Time {
+		return time.Date(2024, 1, 2, 3, 4, seconds, 0, time.UTC)
+	}
+	first := []Entry{
+		{Time: at(1), Line: "first 1"},
+		{Time: at(3), Line: "first 3"},
+		{Time: at(3), Line: "first 3, continued"},
+	}
+	second := []Entry{
+		{Line: "second, before any timestamp"},
+		{Time: at(2), Line: "second 2"},
+		{Time: at(3), Line: "second 3"},
+		{Time: at(4), Line: "second 4"},
+	}
+	var lines []string
+	for _, entry := range Merge([][]Entry{first, nil, second}) {
+		lines = append(lines, entry.Line)
+	}
+	assert.Equal(t, []string{
+		"second, before any timestamp",
+		"first 1",
+		"second 2",
+		"first 3",
+		"first 3, continued",
+		"second 3",
+		"second 4",
+	}, lines)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	source := newFileSource("test", path)
+
+	entries, err := source.Read(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the file doesn't exist")
+
+	require.NoError(t, os.WriteFile(path, []byte("before\n2024-01-02T03:04:05Z: one\r\ncontinued\npartial"), 0o644))
+	entries, err = source.Read(t.Context())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.True(t, entries[0].Time.IsZero())
+	assert.Equal(t, "2024-01-02T03:04:05Z: one", entries[1].Line)
+	assert.Equal(t, "continued", entries[2].Line)
+	assert.Equal(t, entries[1].Time, entries[2].Time)
+	assert.Equal(t, "test", entries[2].Source)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(" line\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	entries, err = source.readNew()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "partial line", entries[0].Line)
+
+	// A file that is truncated is read again from the start.
+	require.NoError(t, os.WriteFile(path, []byte("new\n"), 0o644))
+	entries, err = source.readNew()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "new", entries[0].Line)
+}
+
+func TestSources(t *testing.T) {
+	appPaths := &paths.Paths{Logs: t.TempDir()}
+	for _, name := range []string{"background.log", "custom.log", "not-a-log.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(appPaths.Logs, name), nil, 0o644))
+	}
+	names := func(sources []Source) []string {
+		var result []string
+		for _, source := range sources {
+			result = append(result, source.Name())
+		}
+		return result
+	}
+
+	sources, err := Sources(appPaths, []string{"kubernetes", "custom"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube", "k3s", "custom"}, names(sources))
+
+	sources, err = Sources(appPaths, []string{"kubernetes"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube", "k3s", "vm:k3s"}, names(sources))
+
+	sources, err = Sources(appPaths, nil, false)
+	require.NoError(t, err)
+	assert.Contains(t, names(sources), "custom")
+	assert.Contains(t, names(sources), "lima")
+	assert.NotContains(t, names(sources), "not-a-log")
+	assert.NotContains(t, names(sources), "vm:k3s")
+	backgroundCount := 0
+	for _, name := range names(sources) {
+		if name == "background" {
+			backgroundCount++
+		}
+	}
+	assert.Equal(t, 1, backgroundCount, "background is only included once")
+
+	_, err = Sources(appPaths, []string{"missing"}, false)
+	assert.ErrorContains(t, err, `unknown component "missing"`)
+	_, err = Sources(appPaths, []string{"../custom"}, false)
+	assert.ErrorContains(t, err, `unknown component "../custom"`)
+}
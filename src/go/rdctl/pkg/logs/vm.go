@@ -0,0 +1,89 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shell"
+)
+
+// The scripts run in the VM to read a log file, given as $1; the logs in
+// /var/log may only be readable by root, and the VM's shell may or may not
+// run as root.
+const (
+	vmReadScript   = `[ -e "$1" ] || exit 0; if [ "$(id -u)" -ne 0 ]; then exec sudo cat "$1"; fi; exec cat "$1"`
+	vmFollowScript = `if [ "$(id -u)" -ne 0 ]; then exec sudo tail -n 0 -F "$1"; fi; exec tail -n 0 -F "$1"`
+)
+
+// A log file in the VM, which must be running.
+type vmSource struct {
+	name     string
+	path     string
+	lastTime time.Time
+}
+
+func newVMSource(name, path string) *vmSource {
+	return &vmSource{name: name, path: path}
+}
+
+func (source *vmSource) Name() string {
+	return source.name
+}
+
+func (source *vmSource) Read(ctx context.Context) ([]Entry, error) {
+	cmd, err := shell.SpawnCommand(ctx, "/bin/sh", "-c", vmReadScript, "sh", source.path)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read %s in the VM: %w: %s", source.path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	// A missing final newline means the last line is still being written;
+	// it is complete now.
+	text := stdout.Bytes()
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		text = append(text, '\n')
+	}
+	entries, _ := parseEntries(source.name, text, &source.lastTime)
+	return entries, nil
+}
+
+// Follow only sends lines logged after it starts, so lines logged between
+// Read and Follow are missed.
+func (source *vmSource) Follow(ctx context.Context, entries chan<- Entry) error {
+	cmd, err := shell.SpawnCommand(ctx, "/bin/sh", "-c", vmFollowScript, "sh", source.path)
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to follow %s in the VM: %w", source.path, err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		select {
+		case entries <- newEntry(source.name, scanner.Text(), &source.lastTime):
+		case <-ctx.Done():
+		}
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to follow %s in the VM: %w: %s", source.path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package output prints the data returned by rdctl commands in the format
+// chosen with the global --output flag.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The names of the formats.
+const (
+	JSON     = "json"
+	YAML     = "yaml"
+	Table    = "table"
+	Template = "template"
+)
+
+// Formats describes the values accepted by Format, for help and completion.
+var Formats = []string{JSON, YAML, Table, Template + "="}
+
+// ErrNoTable is returned by Format.Print when a table is requested from a
+// command that only prints structured data.
+var ErrNoTable = errors.New("table output is not supported by this command")
+
+// Format is an output format, usable as a pflag.Value. The zero Format lets
+// each command use its usual format.
+type Format struct {
+	name     string
+	value    string
+	template *template.Template
+}
+
+func (format *Format) String() string {
+	return format.value
+}
+
+func (format *Format) Set(value string) error {
+	name, text, isTemplate := strings.Cut(value, "=")
+	switch {
+	case isTemplate && name == Template:
+		parsed, err := template.New("output").Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		*format = Format{name: Template, value: value, template: parsed}
+	case value == JSON || value == YAML || value == Table:
+		*format = Format{name: value, value: value}
+	case value == "text":
+		// Accepted for compatibility with `rdctl info -o text`.
+		*format = Format{name: Table, value: value}
+	default:
+		return fmt.Errorf("must be one of %s, %s, %s or %s=TEMPLATE", JSON, YAML, Table, Template)
+	}
+	return nil
+}
+
+func (format *Format) Type() string {
+	return "format"
+}
+
+// Name returns the name of the format, or "" if none was chosen.
+func (format *Format) Name() string {
+	return format.name
+}
+
+// Print writes value to writer in the format. value is always printed as
+// the JSON it marshals to; YAML and templates see the same document, with
+// the same field names. table writes the table; if it is nil, the command
+// has no table, and prints JSON unless another format is chosen.
+func (format *Format) Print(writer io.Writer, value any, table func(writer io.Writer) error) error {
+	name := format.name
+	if name == "" {
+		name = Table
+		if table == nil {
+			name = JSON
+		}
+	}
+	switch name {
+	case Table:
+		if table == nil {
+			return ErrNoTable
+		}
+		return table(writer)
+	case JSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	document, err := toDocument(value)
+	if err != nil {
+		return err
+	}
+	if name == YAML {
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	var buffer bytes.Buffer
+	if err := format.template.Execute(&buffer, document); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	if !bytes.HasSuffix(buffer.Bytes(), []byte("\n")) {
+		buffer.WriteByte('\n')
+	}
+	_, err = writer.Write(buffer.Bytes())
+	return err
+}
+
+// Returns value as decoded JSON.
+func toDocument(value any) (any, error) {
+	contents, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeJSON(contents)
+}
+
+// The "json" template function.
+func toJSON(value any) (string, error) {
+	contents, err := json.Marshal(value)
+	return string(contents), err
+}
+
+// DecodeJSON decodes a JSON document, keeping integers as integers rather
+// than floating point numbers, so that they are printed as they were given.
+func DecodeJSON(contents []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return convertNumbers(document), nil
+}
+
+func convertNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	case map[string]any:
+		for key, child := range value {
+			value[key] = convertNumbers(child)
+		}
+	case []any:
+		for i, child := range value {
+			value[i] = convertNumbers(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testValue struct {
+	Name  string            `json:"name"`
+	Size  int64             `json:"size"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+func printValue(t *testing.T, spec string, table func(io.Writer) error) (string, error) {
+	var format Format
+	if spec != "" {
+		require.NoError(t, format.Set(spec))
+	}
+	var buffer bytes.Buffer
+	err := format.Print(&buffer, []testValue{{Name: "a", Size: 10_000_000}}, table)
+	return buffer.String(), err
+}
+
+func TestFormat(t *testing.T) {
+	table := func(writer io.Writer) error {
+		_, err := io.WriteString(writer, "NAME SIZE\na    10000000\n")
+		return err
+	}
+
+	t.Run("The table should be printed by default", func(t *testing.T) {
+		text, err := printValue(t, "", table)
+		require.NoError(t, err)
+		assert.Equal(t, "NAME SIZE\na    10000000\n", text)
+	})
+
+	t.Run("JSON should be printed by default without a table", func(t *testing.T) {
+		text, err := printValue(t, "", nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"name": "a", "size": 10000000}]`, text)
+	})
+
+	t.Run("YAML should use the JSON field names", func(t *testing.T) {
+		text, err := printValue(t, "yaml", table)
+		require.NoError(t, err)
+		assert.Equal(t, "- name: a\n  size: 10000000\n", text)
+	})
+
+	t.Run("Templates should see the JSON document", func(t *testing.T) {
+		text, err := printValue(t, `template={{range .}}{{.name}}={{.size}} {{json .}}{{end}}`, table)
+		require.NoError(t, err)
+		assert.Equal(t, "a=10000000 {\"name\":\"a\",\"size\":10000000}\n", text)
+	})
+
+	t.Run("A table should be an error if there is none", func(t *testing.T) {
+		_, err := printValue(t, "table", nil)
+		assert.ErrorIs(t, err, ErrNoTable)
+	})
+
+	t.Run("text should be taken as table", func(t *testing.T) {
+		var format Format
+		require.NoError(t, format.Set("text"))
+		assert.Equal(t, Table, format.Name())
+		assert.Equal(t, "text", format.String())
+	})
+
+	t.Run("Invalid formats should be rejected", func(t *testing.T) {
+		for _, spec := range []string{"xml", "json=", "template", "template={{.name"} {
+			var format Format
+			assert.Error(t, format.Set(spec), spec)
+		}
+	})
+}
+
+func TestDecodeJSON(t *testing.T) {
+	document, err := DecodeJSON([]byte(`{"a": [1, 1.5, "x"], "b": {"c": 10000000}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a": []any{int64(1), 1.5, "x"},
+		"b": map[string]any{"c": int64(10000000)},
+	}, document)
+}
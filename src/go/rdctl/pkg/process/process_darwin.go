@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
@@ -88,3 +89,38 @@ func WaitForProcess(pid int) error {
 	logrus.Tracef("got %d kqueue events: %+v", n, events[:n])
 	return nil
 }
+
+// The p_stat of a process that has exited but has not been reaped yet.
+const processStateZombie = 5
+
+// GetProcessStartTime returns the time the process with the given pid
+// started, or ErrNoSuchProcess if it is not running.
+func GetProcessStartTime(pid int) (time.Time, error) {
+	// sysctl does not distinguish missing processes from other errors, so
+	// check whether the process exists first.
+	if err := unix.Kill(pid, 0); errors.Is(err, unix.ESRCH) {
+		return time.Time{}, ErrNoSuchProcess
+	} else if err != nil && !errors.Is(err, unix.EPERM) {
+		return time.Time{}, fmt.Errorf("failed to check process %d: %w", pid, err)
+	}
+	info, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get information on process %d: %w", pid, err)
+	}
+	if info.Proc.P_stat == processStateZombie {
+		return time.Time{}, ErrNoSuchProcess
+	}
+	return time.Unix(info.Proc.P_starttime.Unix()), nil
+}
+
+// GetProcessStartTicks is only supported on Linux; elsewhere, it returns
+// errors.ErrUnsupported.
+func GetProcessStartTicks(_ int) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// GetBootID is only supported on Linux; elsewhere, it returns
+// errors.ErrUnsupported.
+func GetBootID() (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package process
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
@@ -73,3 +77,81 @@ func WaitForProcess(pid int) error {
 	}
 	return nil
 }
+
+// The unit of the times in /proc/<pid>/stat; the kernel always reports
+// them in USER_HZ, which is 100 on all architectures.
+const userHz = 100
+
+// GetProcessStartTime returns the time the process with the given pid
+// started, or ErrNoSuchProcess if it is not running. It is derived from the
+// boot time, which moves as the clock is adjusted; use GetProcessStartTicks
+// to tell processes apart.
+func GetProcessStartTime(pid int) (time.Time, error) {
+	ticks, err := GetProcessStartTicks(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	bootTime, err := getBootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return bootTime.Add(time.Duration(ticks) * time.Second / userHz), nil //nolint:gosec // Uptimes aren't that long.
+}
+
+// GetProcessStartTicks returns the time the process with the given pid
+// started, in clock ticks since boot, or ErrNoSuchProcess if it is not
+// running. Together with GetBootID, it identifies the process exactly.
+func GetProcessStartTicks(pid int) (uint64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNoSuchProcess
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read status of process %d: %w", pid, err)
+	}
+	// The command name (the second field) is in parentheses, and may
+	// itself contain spaces and parentheses; the remaining fields start
+	// with the state (the third field) after the last parenthesis.
+	index := bytes.LastIndexByte(stat, ')')
+	if index < 0 {
+		return 0, fmt.Errorf("failed to parse status of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[index+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("failed to parse status of process %d", pid)
+	}
+	if fields[0] == "Z" || fields[0] == "X" {
+		// The process has exited but has not been reaped yet.
+		return 0, ErrNoSuchProcess
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse start time of process %d: %w", pid, err)
+	}
+	return ticks, nil
+}
+
+// GetBootID returns a random ID that the kernel generates at every boot.
+func GetBootID() (string, error) {
+	bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", fmt.Errorf("failed to read boot ID: %w", err)
+	}
+	return strings.TrimSpace(string(bootID)), nil
+}
+
+func getBootTime() (time.Time, error) {
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read system status: %w", err)
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		if value, ok := strings.CutPrefix(line, "btime "); ok {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("failed to parse boot time: %w", err)
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("failed to find boot time")
+}
@@ -1,8 +1,11 @@
 package process_test
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,3 +20,40 @@ func TestFindPidOfProcess(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, os.Getpid(), pid)
 }
+
+func TestGetProcessStartTime(t *testing.T) {
+	startTime, err := process.GetProcessStartTime(os.Getpid())
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), startTime, 10*time.Minute)
+	again, err := process.GetProcessStartTime(os.Getpid())
+	require.NoError(t, err)
+	assert.WithinDuration(t, startTime, again, time.Second)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command(exe, "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	_, err = process.GetProcessStartTime(cmd.Process.Pid)
+	assert.ErrorIs(t, err, process.ErrNoSuchProcess)
+}
+
+func TestGetProcessStartTicks(t *testing.T) {
+	ticks, err := process.GetProcessStartTicks(os.Getpid())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("process start ticks are not supported on this platform")
+	}
+	require.NoError(t, err)
+	again, err := process.GetProcessStartTicks(os.Getpid())
+	require.NoError(t, err)
+	assert.Equal(t, ticks, again)
+	bootID, err := process.GetBootID()
+	require.NoError(t, err)
+	assert.NotEmpty(t, bootID)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command(exe, "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	_, err = process.GetProcessStartTicks(cmd.Process.Pid)
+	assert.ErrorIs(t, err, process.ErrNoSuchProcess)
+}
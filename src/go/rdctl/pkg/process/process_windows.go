@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/sirupsen/logrus"
@@ -454,3 +455,46 @@ func TerminateProcessInDirectory(directory string, force bool) error {
 		return nil
 	})
 }
+
+// The exit code GetExitCodeProcess reports for a process that is running.
+const stillActive = 259
+
+// GetProcessStartTime returns the time the process with the given pid
+// started, or ErrNoSuchProcess if it is not running.
+func GetProcessStartTime(pid int) (time.Time, error) {
+	//nolint:gosec // pids cannot be negative
+	proc, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+		return time.Time{}, ErrNoSuchProcess
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer func() {
+		_ = windows.CloseHandle(proc)
+	}()
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(proc, &exitCode); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get exit code of process %d: %w", pid, err)
+	}
+	if exitCode != stillActive {
+		// Something still has a handle to the process, but it has exited.
+		return time.Time{}, ErrNoSuchProcess
+	}
+	var creationTime, exitTime, kernelTime, userTime windows.Filetime
+	if err := windows.GetProcessTimes(proc, &creationTime, &exitTime, &kernelTime, &userTime); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get start time of process %d: %w", pid, err)
+	}
+	return time.Unix(0, creationTime.Nanoseconds()), nil
+}
+
+// GetProcessStartTicks is only supported on Linux; elsewhere, it returns
+// errors.ErrUnsupported.
+func GetProcessStartTicks(_ int) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// GetBootID is only supported on Linux; elsewhere, it returns
+// errors.ErrUnsupported.
+func GetBootID() (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -0,0 +1,208 @@
+// Package profiles stores named sets of settings, so that settings that
+// are changed together can be applied at once.
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+// The directory in paths.Config that holds the profiles.
+const dirName = "profiles"
+
+const fileExtension = ".json"
+
+// Profile names are used as file names, so they are restricted to
+// characters that are safe on every platform.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Profile is a saved set of settings.
+type Profile struct {
+	Name     string    `json:"name"`
+	Modified time.Time `json:"modified"`
+	// The settings, as decoded JSON, including the settings version.
+	Settings map[string]any `json:"settings"`
+}
+
+// Manager reads and writes the profiles in a directory.
+type Manager struct {
+	dir string
+}
+
+// NewManager returns a manager for the profiles of the current user.
+func NewManager(appPaths *paths.Paths) *Manager {
+	return &Manager{dir: filepath.Join(appPaths.Config, dirName)}
+}
+
+// ValidateName checks that name can be used as the name of a profile.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: must be at most 64 letters, digits, '.', '_' or '-', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+func (manager *Manager) path(name string) string {
+	return filepath.Join(manager.dir, name+fileExtension)
+}
+
+// Save saves settings as the profile name. An existing profile is only
+// replaced if overwrite is true.
+func (manager *Manager) Save(name string, settings *options.ServerSettingsForJSON, overwrite bool) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	document, err := sdk.SettingsDocument(settings)
+	if err != nil {
+		return err
+	}
+	if len(document) == 0 || (len(document) == 1 && document["version"] != nil) {
+		return errors.New("a profile must set at least one setting")
+	}
+	contents, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	if err := os.MkdirAll(manager.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	if !overwrite {
+		if _, err := os.Stat(manager.path(name)); err == nil {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+	}
+	// Write a temporary file and move it into place, so that a profile is
+	// never left half written.
+	tempFile, err := os.CreateTemp(manager.dir, ".profile-*")
+	if err != nil {
+		return fmt.Errorf("failed to save profile %q: %w", name, err)
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.Write(append(contents, '\n'))
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), manager.path(name))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// Get returns the profile name.
+func (manager *Manager) Get(name string) (Profile, error) {
+	if err := ValidateName(name); err != nil {
+		return Profile{}, err
+	}
+	return manager.read(name)
+}
+
+func (manager *Manager) read(name string) (Profile, error) {
+	path := manager.path(name)
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Profile{}, fmt.Errorf("profile %q does not exist", name)
+	} else if err != nil {
+		return Profile{}, fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	profile := Profile{Name: name, Modified: info.ModTime()}
+	if err := json.Unmarshal(contents, &profile.Settings); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse profile %q: %w", name, err)
+	}
+	return profile, nil
+}
+
+// Settings returns the settings in the profile name, checking that they are
+// valid settings.
+func (manager *Manager) Settings(name string) (*options.ServerSettingsForJSON, error) {
+	profile, err := manager.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := json.Marshal(profile.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal profile %q: %w", name, err)
+	}
+	settings, err := sdk.ParseSettings(contents)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return settings, nil
+}
+
+// List returns the profiles, sorted by name.
+func (manager *Manager) List() ([]Profile, error) {
+	entries, err := os.ReadDir(manager.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Profile{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	profiles := []Profile{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileExtension)
+		if !ok || !entry.Type().IsRegular() || ValidateName(name) != nil {
+			continue
+		}
+		profile, err := manager.read(name)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return profiles, nil
+}
+
+// Delete deletes the profile name.
+func (manager *Manager) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	err := os.Remove(manager.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("profile %q does not exist", name)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// SettingNames returns the dotted names of the settings that a profile
+// sets, other than the settings version, sorted.
+func (profile Profile) SettingNames() []string {
+	var names []string
+	var walk func(document map[string]any, prefix string)
+	walk = func(document map[string]any, prefix string) {
+		for key, value := range document {
+			if child, ok := value.(map[string]any); ok && len(child) > 0 {
+				walk(child, prefix+key+".")
+			} else if prefix != "" || key != "version" {
+				names = append(names, prefix+key)
+			}
+		}
+	}
+	walk(profile.Settings, "")
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,88 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sdk"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"platform", "k8s-1.30", "App_2"} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "-flag", "a/b", `a\b`, "with space", strings.Repeat("a", 65)} {
+		assert.Error(t, ValidateName(name), name)
+	}
+}
+
+func TestManager(t *testing.T) {
+	manager := NewManager(&paths.Paths{Config: t.TempDir()})
+
+	list, err := manager.List()
+	require.NoError(t, err)
+	assert.Empty(t, list, "the profiles directory doesn't exist")
+
+	settings, err := sdk.ParseSettings([]byte("kubernetes:\n  enabled: true\ncontainerEngine:\n  name: containerd\n"))
+	require.NoError(t, err)
+	require.NoError(t, manager.Save("platform", settings, false))
+	assert.ErrorContains(t, manager.Save("platform", settings, false), `profile "platform" already exists`)
+
+	settings, err = sdk.ParseSettings([]byte("kubernetes:\n  enabled: false\n"))
+	require.NoError(t, err)
+	require.NoError(t, manager.Save("platform", settings, true))
+	require.NoError(t, manager.Save("app", settings, false))
+
+	versionOnly, err := sdk.ParseSettings([]byte("version: 10\n"))
+	require.NoError(t, err)
+	assert.ErrorContains(t, manager.Save("empty", versionOnly, false), "at least one setting")
+
+	profile, err := manager.Get("platform")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes.enabled"}, profile.SettingNames(), "the profile should have been replaced")
+
+	loaded, err := manager.Settings("platform")
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Kubernetes.Enabled)
+	assert.False(t, *loaded.Kubernetes.Enabled)
+
+	// Files that aren't profiles are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(manager.dir, "notes.txt"), nil, 0o644))
+	list, err = manager.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "app", list[0].Name)
+	assert.Equal(t, "platform", list[1].Name)
+
+	require.NoError(t, manager.Delete("app"))
+	assert.ErrorContains(t, manager.Delete("app"), `profile "app" does not exist`)
+	_, err = manager.Get("app")
+	assert.ErrorContains(t, err, `profile "app" does not exist`)
+	_, err = manager.Get("../app")
+	assert.ErrorContains(t, err, "invalid profile name")
+
+	t.Run("Invalid settings should be rejected when applied", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(manager.path("broken"), []byte(`{"kubernetes": {"enabled": "yes"}}`), 0o644))
+		_, err := manager.Settings("broken")
+		assert.ErrorContains(t, err, `profile "broken"`)
+	})
+}
+
+func TestSettingNames(t *testing.T) {
+	profile := Profile{Settings: map[string]any{
+		"version":    float64(18),
+		"kubernetes": map[string]any{"enabled": true, "options": map[string]any{"traefik": false}},
+		"WSL":        map[string]any{"integrations": map[string]any{"Ubuntu": true}},
+	}}
+	assert.Equal(t, []string{
+		"WSL.integrations.Ubuntu",
+		"kubernetes.enabled",
+		"kubernetes.options.traefik",
+	}, profile.SettingNames())
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// ParallelTaskRunner is like TaskRunner, but calls up to a fixed number of
+// functions at the same time instead of one after another. Once a function
+// returns an error, or the context is marked done, no more functions are
+// started.
+type ParallelTaskRunner struct {
+	context   context.Context
+	cancel    context.CancelFunc
+	semaphore chan struct{}
+	waitGroup sync.WaitGroup
+	mutex     sync.Mutex
+	err       error
+}
+
+func NewParallelTaskRunner(ctx context.Context, concurrency int) *ParallelTaskRunner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	runnerCtx, cancel := context.WithCancel(ctx)
+	return &ParallelTaskRunner{
+		context:   runnerCtx,
+		cancel:    cancel,
+		semaphore: make(chan struct{}, concurrency),
+	}
+}
+
+// Schedules a function to be called once fewer than the maximum number of
+// functions are running.
+func (tr *ParallelTaskRunner) Add(function func() error) {
+	tr.waitGroup.Add(1)
+	go func() {
+		defer tr.waitGroup.Done()
+		select {
+		case <-tr.context.Done():
+			tr.setError(ErrContextDone)
+			return
+		case tr.semaphore <- struct{}{}:
+		}
+		defer func() { <-tr.semaphore }()
+		// Both cases of the select above may be ready at once; make sure
+		// nothing new is started after the context is marked done.
+		if tr.context.Err() != nil {
+			tr.setError(ErrContextDone)
+			return
+		}
+		if err := function(); err != nil {
+			tr.setError(err)
+		}
+	}()
+}
+
+// Waits until every function that was started has completed, returning the
+// first (if any) error returned by a passed function. ErrContextDone is
+// returned if functions were skipped because the context was marked done.
+func (tr *ParallelTaskRunner) Wait() error {
+	tr.waitGroup.Wait()
+	tr.cancel()
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	return tr.err
+}
+
+// Records the first error, and stops any further functions from starting.
+// The error is recorded before the context is cancelled, so functions that
+// are skipped because of it don't replace it with ErrContextDone.
+func (tr *ParallelTaskRunner) setError(err error) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	if tr.err == nil {
+		tr.err = err
+	}
+	tr.cancel()
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelTaskRunner(t *testing.T) {
+	t.Run("should run all functions if context not cancelled and no errors", func(t *testing.T) {
+		taskRunner := NewParallelTaskRunner(context.Background(), 2)
+		var count atomic.Int32
+		for range 5 {
+			taskRunner.Add(func() error {
+				count.Add(1)
+				return nil
+			})
+		}
+		assert.NoError(t, taskRunner.Wait())
+		assert.Equal(t, int32(5), count.Load())
+	})
+
+	t.Run("should not run more functions at once than its concurrency", func(t *testing.T) {
+		taskRunner := NewParallelTaskRunner(context.Background(), 2)
+		var running, maxRunning atomic.Int32
+		release := make(chan struct{})
+		for range 4 {
+			taskRunner.Add(func() error {
+				current := running.Add(1)
+				for {
+					previous := maxRunning.Load()
+					if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+						break
+					}
+				}
+				<-release
+				running.Add(-1)
+				return nil
+			})
+		}
+		close(release)
+		assert.NoError(t, taskRunner.Wait())
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+
+	t.Run("should return the first error and not start further functions", func(t *testing.T) {
+		taskRunner := NewParallelTaskRunner(context.Background(), 1)
+		expectedError := errors.New("func1 error")
+		func2Ran := false
+		taskRunner.Add(func() error {
+			return expectedError
+		})
+		// Wait for the first function to finish, so that the second one is
+		// only considered after the error has been recorded.
+		<-taskRunner.context.Done()
+		taskRunner.Add(func() error {
+			func2Ran = true
+			return nil
+		})
+		assert.ErrorIs(t, taskRunner.Wait(), expectedError)
+		assert.False(t, func2Ran)
+	})
+
+	t.Run("should return ErrContextDone when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		taskRunner := NewParallelTaskRunner(ctx, 2)
+		ran := false
+		taskRunner.Add(func() error {
+			ran = true
+			return nil
+		})
+		assert.ErrorIs(t, taskRunner.Wait(), ErrContextDone)
+		assert.False(t, ran)
+	})
+}
@@ -0,0 +1,291 @@
+// Package s3 is a minimal client for the parts of the Amazon S3 API that are
+// needed to store snapshots: getting, putting (in parts, for large objects)
+// and listing objects. It works with other S3-compatible stores, such as
+// MinIO, that accept Signature Version 4.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// The default size of each part of a multipart upload. Objects smaller
+	// than this are uploaded in a single request.
+	DefaultPartSize = 64 << 20
+	// S3 allows at most this many parts in a multipart upload.
+	maxParts = 10000
+)
+
+// ErrNotFound is returned for objects that do not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Error is an error response from the server.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("S3 request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("S3 request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && (e.Code == "NoSuchKey" || (e.Code == "" && e.StatusCode == http.StatusNotFound))
+}
+
+// Client accesses the objects in a single bucket.
+type Client struct {
+	// The URL of the service, such as https://s3.us-east-1.amazonaws.com
+	// or http://localhost:9000.
+	Endpoint *url.URL
+	Region   string
+	Bucket   string
+	// Whether the bucket is given in the path (http://endpoint/bucket/key)
+	// rather than in the host name (http://bucket.endpoint/key). Most
+	// stand-ins for S3 require this.
+	PathStyle   bool
+	Credentials Credentials
+	// The size of each part of a multipart upload; DefaultPartSize if zero.
+	PartSize   int64
+	HTTPClient *http.Client
+}
+
+// Object describes an object returned by ListObjects.
+type Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// Returns the URL of the object with the given key, with the given query.
+func (client *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *client.Endpoint
+	u.RawQuery = query.Encode()
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if client.PathStyle {
+		u.Path = basePath + "/" + client.Bucket + "/" + key
+	} else {
+		u.Host = client.Bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = ""
+	return &u
+}
+
+// Sends a signed request. The body, if any, must be fully in memory so that
+// its hash can be signed. Responses with an error status are returned as
+// *Error.
+func (client *Client) do(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, client.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	signRequest(req, client.Credentials, client.Region, payloadHash, time.Now())
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, parseError(resp.StatusCode, resp.Body)
+	}
+	return resp, nil
+}
+
+func parseError(statusCode int, body io.Reader) error {
+	s3Error := &Error{}
+	contents, _ := io.ReadAll(io.LimitReader(body, 1<<20))
+	_ = xml.Unmarshal(contents, s3Error)
+	s3Error.StatusCode = statusCode
+	return s3Error
+}
+
+// GetObject returns the contents of the object with the given key. The
+// caller must close it.
+func (client *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := client.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %q: %w", key, err)
+	}
+	return resp.Body, nil
+}
+
+// PutObject stores the contents of body under key. Bodies larger than the
+// part size are uploaded in parts, so that they need not fit in memory or
+// have a known length.
+func (client *Client) PutObject(ctx context.Context, key string, body io.Reader) error {
+	partSize := client.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	first, err := readPart(body, partSize)
+	if err != nil {
+		return fmt.Errorf("failed to read contents of %q: %w", key, err)
+	}
+	if int64(len(first)) < partSize {
+		resp, err := client.do(ctx, http.MethodPut, key, nil, first, nil)
+		if err != nil {
+			return fmt.Errorf("failed to put %q: %w", key, err)
+		}
+		return resp.Body.Close()
+	}
+	return client.putMultipart(ctx, key, first, body, partSize)
+}
+
+// Reads up to size bytes; a short read means the end of reader was reached.
+func readPart(reader io.Reader, size int64) ([]byte, error) {
+	buffer := make([]byte, size)
+	n, err := io.ReadFull(reader, buffer)
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return buffer[:n], err
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (client *Client) putMultipart(ctx context.Context, key string, first []byte, rest io.Reader, partSize int64) (err error) {
+	resp, err := client.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start upload of %q: %w", key, err)
+	}
+	initiated := initiateMultipartUploadResult{}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start upload of %q: %w", key, err)
+	}
+	uploadQuery := url.Values{"uploadId": {initiated.UploadID}}
+	defer func() {
+		if err != nil {
+			// ctx may have been cancelled, which is why the upload failed.
+			if resp, abortErr := client.do(context.WithoutCancel(ctx), http.MethodDelete, key, uploadQuery, nil, nil); abortErr == nil {
+				_ = resp.Body.Close()
+			}
+		}
+	}()
+
+	completed := completeMultipartUpload{}
+	part := first
+	for partNumber := 1; len(part) > 0; partNumber++ {
+		if partNumber > maxParts {
+			return fmt.Errorf("failed to upload %q: too large for %d parts of %d bytes", key, maxParts, partSize)
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {initiated.UploadID}}
+		resp, err := client.do(ctx, http.MethodPut, key, query, part, nil)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d of %q: %w", partNumber, key, err)
+		}
+		_ = resp.Body.Close()
+		completed.Parts = append(completed.Parts, completedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+		if int64(len(part)) < partSize {
+			break
+		}
+		if part, err = readPart(rest, partSize); err != nil {
+			return fmt.Errorf("failed to read contents of %q: %w", key, err)
+		}
+	}
+
+	completeBody, err := xml.Marshal(completed)
+	if err != nil {
+		return err
+	}
+	resp, err = client.do(ctx, http.MethodPost, key, uploadQuery, completeBody, http.Header{"Content-Type": {"application/xml"}})
+	if err != nil {
+		return fmt.Errorf("failed to complete upload of %q: %w", key, err)
+	}
+	defer resp.Body.Close()
+	// Completing an upload can fail after the response status was sent,
+	// in which case the error is in the body.
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload of %q: %w", key, err)
+	}
+	if bytes.Contains(contents, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete upload of %q: %w", key, parseError(resp.StatusCode, bytes.NewReader(contents)))
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	Contents              []Object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// ListObjects returns every object whose key starts with prefix.
+func (client *Client) ListObjects(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+	continuationToken := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		resp, err := client.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse object list: %w", err)
+		}
+		objects = append(objects, result.Contents...)
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// DeleteObject removes the object with the given key.
+func (client *Client) DeleteObject(ctx context.Context, key string) error {
+	resp, err := client.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %q: %w", key, err)
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,85 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3/s3test"
+)
+
+func TestClient(t *testing.T) {
+	server := s3test.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.PartSize = 16
+	ctx := context.Background()
+
+	for _, size := range []int{0, 10, 16, 40, 48} {
+		t.Run(fmt.Sprintf("PutObject and GetObject with %d bytes", size), func(t *testing.T) {
+			key := fmt.Sprintf("objects/object-%d", size)
+			contents := bytes.Repeat([]byte("x"), size)
+			require.NoError(t, client.PutObject(ctx, key, bytes.NewReader(contents)))
+			reader, err := client.GetObject(ctx, key)
+			require.NoError(t, err)
+			defer reader.Close()
+			actual, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, contents, actual)
+		})
+	}
+	assert.Zero(t, server.PendingUploads())
+
+	t.Run("GetObject on a missing object", func(t *testing.T) {
+		_, err := client.GetObject(ctx, "no-such-object")
+		assert.ErrorIs(t, err, s3.ErrNotFound)
+	})
+
+	t.Run("ListObjects pages through results", func(t *testing.T) {
+		server.MaxKeys = 2
+		defer func() { server.MaxKeys = 1000 }()
+		require.NoError(t, client.PutObject(ctx, "other/object", bytes.NewReader([]byte("x"))))
+		objects, err := client.ListObjects(ctx, "objects/")
+		require.NoError(t, err)
+		keys := []string{}
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+		assert.Equal(t, []string{"objects/object-0", "objects/object-10", "objects/object-16", "objects/object-40", "objects/object-48"}, keys)
+	})
+
+	t.Run("DeleteObject", func(t *testing.T) {
+		require.NoError(t, client.DeleteObject(ctx, "other/object"))
+		_, ok := server.Object("other/object")
+		assert.False(t, ok)
+	})
+
+	t.Run("PutObject aborts failed uploads", func(t *testing.T) {
+		reader := io.MultiReader(bytes.NewReader(bytes.Repeat([]byte("x"), 20)), &failingReader{})
+		assert.Error(t, client.PutObject(ctx, "failed", reader))
+		assert.Zero(t, server.PendingUploads())
+		_, ok := server.Object("failed")
+		assert.False(t, ok)
+	})
+
+	t.Run("requests with the wrong credentials fail", func(t *testing.T) {
+		badClient := server.Client()
+		badClient.Credentials.AccessKeyID = "wrong"
+		_, err := badClient.ListObjects(ctx, "")
+		var s3Error *s3.Error
+		require.ErrorAs(t, err, &s3Error)
+		assert.Equal(t, "InvalidAccessKeyId", s3Error.Code)
+	})
+}
+
+type failingReader struct{}
+
+func (*failingReader) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("read failed")
+}
@@ -0,0 +1,238 @@
+// Package s3test provides an in-memory stand-in for an S3-compatible object
+// store, for testing code that uses package s3 without a real service.
+package s3test
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3"
+)
+
+const (
+	Bucket          = "test-bucket"
+	Region          = "us-east-1"
+	AccessKeyID     = "test-access-key"
+	SecretAccessKey = "test-secret-key"
+)
+
+// Server serves the single bucket named Bucket, using path-style addressing.
+// It checks that requests are signed with AccessKeyID, but does not verify
+// the signatures.
+type Server struct {
+	*httptest.Server
+	// The largest number of keys returned in one page of a listing.
+	MaxKeys int
+
+	mutex   sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+// NewServer starts a server; the caller must close it.
+func NewServer() *Server {
+	server := &Server{
+		MaxKeys: 1000,
+		objects: map[string][]byte{},
+		uploads: map[string]map[int][]byte{},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// Client returns a client for the server's bucket.
+func (server *Server) Client() *s3.Client {
+	endpoint, _ := url.Parse(server.URL)
+	return &s3.Client{
+		Endpoint:  endpoint,
+		Region:    Region,
+		Bucket:    Bucket,
+		PathStyle: true,
+		Credentials: s3.Credentials{
+			AccessKeyID:     AccessKeyID,
+			SecretAccessKey: SecretAccessKey,
+		},
+		HTTPClient: server.Server.Client(),
+	}
+}
+
+// Object returns the contents of the object with the given key.
+func (server *Server) Object(key string) ([]byte, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	contents, ok := server.objects[key]
+	return contents, ok
+}
+
+// PendingUploads returns the number of multipart uploads that have been
+// started but neither completed nor aborted.
+func (server *Server) PendingUploads() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.uploads)
+}
+
+type errorResponse struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(errorResponse{Code: code, Message: message})
+}
+
+func writeXML(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(value)
+}
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId", "The access key is not valid")
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The bucket does not exist")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	query := r.URL.Query()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		server.list(w, query)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		server.nextID++
+		uploadID := strconv.Itoa(server.nextID)
+		server.uploads[uploadID] = map[int][]byte{}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string   `xml:"Bucket"`
+			Key      string   `xml:"Key"`
+			UploadID string   `xml:"UploadId"`
+		}{Bucket: bucket, Key: key, UploadID: uploadID})
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := server.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "The upload does not exist")
+			return
+		}
+		partNumber, err := strconv.Atoi(query.Get("partNumber"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", "Invalid part number")
+			return
+		}
+		parts[partNumber] = body
+		sum := md5.Sum(body)
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(sum[:])))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		server.completeUpload(w, key, query.Get("uploadId"), body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(server.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		server.objects[key] = body
+	case r.Method == http.MethodGet:
+		contents, ok := server.objects[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The key does not exist")
+			return
+		}
+		_, _ = w.Write(contents)
+	case r.Method == http.MethodDelete:
+		delete(server.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Unsupported request")
+	}
+}
+
+func (server *Server) list(w http.ResponseWriter, query url.Values) {
+	prefix := query.Get("prefix")
+	keys := []string{}
+	for key := range server.objects {
+		if strings.HasPrefix(key, prefix) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	}
+	result := struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		Contents              []content `xml:"Contents"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+	}{}
+	if len(keys) > server.MaxKeys {
+		keys = keys[:server.MaxKeys]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			Size:         len(server.objects[key]),
+			LastModified: time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+	writeXML(w, result)
+}
+
+func (server *Server) completeUpload(w http.ResponseWriter, key, uploadID string, body []byte) {
+	parts, ok := server.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The upload does not exist")
+		return
+	}
+	request := struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}{}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	contents := []byte{}
+	for i, part := range request.Parts {
+		data, ok := parts[part.PartNumber]
+		if !ok || part.PartNumber != i+1 {
+			writeError(w, http.StatusBadRequest, "InvalidPart", "A part is missing")
+			return
+		}
+		contents = append(contents, data...)
+	}
+	server.objects[key] = contents
+	delete(server.uploads, uploadID)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string   `xml:"Key"`
+	}{Key: key})
+}
@@ -0,0 +1,243 @@
+// Package sdk is a typed client for the Rancher Desktop HTTP API, built on
+// client.RDClient. Errors reported by the server are returned as
+// *client.APIError, and client.ErrConnectionRefused is returned if Rancher
+// Desktop is not running.
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+)
+
+// Returned by Client.WaitForState when the backend does not reach one of
+// the desired states in time.
+var ErrWaitTimeout = errors.New("timed out waiting for backend state")
+
+// How often Poll polls the backend state.
+var statePollInterval = 1 * time.Second
+
+// ResetMode selects what Client.Reset discards.
+type ResetMode string
+
+const (
+	// Delete the deployed Kubernetes workloads, keeping the VM.
+	ResetKubernetes ResetMode = "fast"
+	// Delete the VM, and create a new one with the current settings.
+	ResetVM ResetMode = "wipe"
+)
+
+// RestartReason describes a setting that Client.ProposeSettings reports
+// would restart the backend if changed.
+type RestartReason struct {
+	Current any `json:"current"`
+	Desired any `json:"desired"`
+	// Either "restart", or "reset" if the change discards the VM.
+	Severity string `json:"severity"`
+}
+
+// RestartReasons maps the dotted names of settings to the reasons changing
+// them would restart the backend.
+type RestartReasons map[string]RestartReason
+
+// NeedsReset reports whether any of the changes would discard the VM.
+func (reasons RestartReasons) NeedsReset() bool {
+	for _, reason := range reasons {
+		if reason.Severity == "reset" {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension describes an installed extension.
+type Extension struct {
+	// The tag of the installed image.
+	Version  string            `json:"version"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// Client makes typed requests to the Rancher Desktop HTTP API.
+type Client struct {
+	rdClient client.RDClient
+}
+
+func NewClient(rdClient client.RDClient) *Client {
+	return &Client{rdClient: rdClient}
+}
+
+// Connect returns a Client for the running Rancher Desktop, using the
+// connection settings from the config file and the command line.
+func Connect() (*Client, error) {
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	return NewClient(client.NewRDClient(connectionInfo)), nil
+}
+
+// RDClient returns the underlying client, for requests that have no typed
+// method.
+func (c *Client) RDClient() client.RDClient {
+	return c.rdClient
+}
+
+// Sends a request to the endpoint for command in the current API version,
+// with payload encoded as JSON unless it is nil, and returns the body of
+// the response.
+func (c *Client) do(ctx context.Context, method, command string, payload any) ([]byte, error) {
+	endpoint := client.VersionCommand("", command)
+	if payload == nil {
+		return client.ProcessResponse(c.rdClient.DoRequest(ctx, method, endpoint))
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return client.ProcessResponse(c.rdClient.DoRequestWithPayload(ctx, method, endpoint, bytes.NewReader(body)))
+}
+
+// Like do, but decodes the JSON response into result.
+func (c *Client) doJSON(ctx context.Context, method, command string, payload, result any) error {
+	body, err := c.do(ctx, method, command, payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", command, err)
+	}
+	return nil
+}
+
+// GetSettingsJSON returns the current settings as the server reports them,
+// including any that ServerSettingsForJSON does not describe.
+func (c *Client) GetSettingsJSON(ctx context.Context) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "settings", nil)
+}
+
+// GetSettings returns the current settings.
+func (c *Client) GetSettings(ctx context.Context) (*options.ServerSettingsForJSON, error) {
+	settings := &options.ServerSettingsForJSON{}
+	if err := c.doJSON(ctx, http.MethodGet, "settings", nil, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateSettings changes the settings that are set in partial, leaving the
+// others alone, and returns the server's description of the result. The
+// backend is restarted if the changes require it.
+func (c *Client) UpdateSettings(ctx context.Context, partial *options.ServerSettingsForJSON) (string, error) {
+	body, err := c.do(ctx, http.MethodPut, "settings", partial)
+	return string(body), err
+}
+
+// ProposeSettings reports which of the settings that are set in partial
+// would restart the backend if they were changed, without changing them.
+func (c *Client) ProposeSettings(ctx context.Context, partial *options.ServerSettingsForJSON) (RestartReasons, error) {
+	reasons := RestartReasons{}
+	if err := c.doJSON(ctx, http.MethodPut, "propose_settings", partial, &reasons); err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// ListExtensions returns the installed extensions, keyed by their image
+// name without the tag.
+func (c *Client) ListExtensions(ctx context.Context) (map[string]Extension, error) {
+	extensions := map[string]Extension{}
+	if err := c.doJSON(ctx, http.MethodGet, "extensions", nil, &extensions); err != nil {
+		return nil, err
+	}
+	return extensions, nil
+}
+
+// InstallExtension installs the extension with the given image reference,
+// and returns the server's description of the result.
+func (c *Client) InstallExtension(ctx context.Context, id string) (string, error) {
+	body, err := c.do(ctx, http.MethodPost, "extensions/install?"+url.Values{"id": {id}}.Encode(), nil)
+	return string(body), err
+}
+
+// UninstallExtension uninstalls the extension with the given image
+// reference, and returns the server's description of the result.
+func (c *Client) UninstallExtension(ctx context.Context, id string) (string, error) {
+	body, err := c.do(ctx, http.MethodPost, "extensions/uninstall?"+url.Values{"id": {id}}.Encode(), nil)
+	return string(body), err
+}
+
+// Reset resets Kubernetes or the VM, and returns the server's description
+// of the result.
+func (c *Client) Reset(ctx context.Context, mode ResetMode) (string, error) {
+	payload := struct {
+		Mode ResetMode `json:"mode"`
+	}{Mode: mode}
+	body, err := c.do(ctx, http.MethodPut, "k8s_reset", payload)
+	return string(body), err
+}
+
+// Shutdown asks Rancher Desktop to quit, and returns the server's
+// description of the result.
+func (c *Client) Shutdown(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, http.MethodPut, "shutdown", nil)
+	return string(body), err
+}
+
+// GetBackendState returns the state of the backend.
+func (c *Client) GetBackendState(ctx context.Context) (client.BackendState, error) {
+	return c.rdClient.GetBackendState(ctx)
+}
+
+// UpdateBackendState starts or stops the backend, and locks or unlocks it.
+func (c *Client) UpdateBackendState(ctx context.Context, state client.BackendState) error {
+	return c.rdClient.UpdateBackendState(ctx, state)
+}
+
+// Poll calls check every time the backend state should be polled, until
+// it reports that it is done or returns an error, or ctx is done.
+func Poll(ctx context.Context, check func() (done bool, err error)) error {
+	for {
+		if done, err := check(); done || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(statePollInterval):
+		}
+	}
+}
+
+// WaitForState polls the backend state until it is one of desiredStates,
+// and returns it. It returns an error wrapping ErrWaitTimeout if that
+// doesn't happen within timeout.
+func (c *Client) WaitForState(ctx context.Context, timeout time.Duration, desiredStates ...string) (client.BackendState, error) {
+	deadline := time.Now().Add(timeout)
+	var state client.BackendState
+	err := Poll(ctx, func() (bool, error) {
+		var err error
+		state, err = c.GetBackendState(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to poll backend state: %w", err)
+		}
+		if slices.Contains(desiredStates, state.VMState) {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return false, fmt.Errorf("%w in %s", ErrWaitTimeout, desiredStates)
+		}
+		return false, nil
+	})
+	return state, err
+}
@@ -0,0 +1,178 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+)
+
+// Starts a server that handles requests with handler, and returns a Client
+// connected to it.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host, portString, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portString)
+	require.NoError(t, err)
+	return NewClient(client.NewRDClient(&config.ConnectionInfo{
+		Host:     host,
+		Port:     port,
+		User:     "user",
+		Password: "password",
+	}))
+}
+
+func TestSettings(t *testing.T) {
+	t.Run("GetSettings should decode the settings", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			assert.Equal(t, "/v1/settings", r.URL.Path)
+			_, _ = w.Write([]byte(`{"version": 10, "kubernetes": {"version": "1.29.4", "enabled": true}}`))
+		})
+		settings, err := rdClient.GetSettings(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, settings.Version)
+		assert.Equal(t, 10, *settings.Version)
+		require.NotNil(t, settings.Kubernetes.Version)
+		assert.Equal(t, "1.29.4", *settings.Kubernetes.Version)
+	})
+
+	t.Run("UpdateSettings should only send the given settings", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/v1/settings", r.URL.Path)
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			var sent options.ServerSettingsForJSON
+			assert.NoError(t, json.Unmarshal(body, &sent))
+			assert.Equal(t, 10, *sent.Version)
+			assert.Equal(t, "1.30.1", *sent.Kubernetes.Version)
+			assert.Nil(t, sent.Kubernetes.Enabled)
+			_, _ = w.Write([]byte("reconfiguring Rancher Desktop to apply changes"))
+		})
+		partial := &options.ServerSettingsForJSON{}
+		version := 10
+		k8sVersion := "1.30.1"
+		partial.Version = &version
+		partial.Kubernetes.Version = &k8sVersion
+		result, err := rdClient.UpdateSettings(context.Background(), partial)
+		require.NoError(t, err)
+		assert.Equal(t, "reconfiguring Rancher Desktop to apply changes", result)
+	})
+
+	t.Run("ProposeSettings should report whether a reset is needed", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/propose_settings", r.URL.Path)
+			_, _ = w.Write([]byte(`{"kubernetes.version": {"current": "1.30.1", "desired": "1.29.4", "severity": "reset"}}`))
+		})
+		reasons, err := rdClient.ProposeSettings(context.Background(), &options.ServerSettingsForJSON{})
+		require.NoError(t, err)
+		assert.Equal(t, RestartReasons{
+			"kubernetes.version": {Current: "1.30.1", Desired: "1.29.4", Severity: "reset"},
+		}, reasons)
+		assert.True(t, reasons.NeedsReset())
+	})
+}
+
+func TestAPIError(t *testing.T) {
+	t.Run("Errors should report the status and body", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Extension manager is not ready yet.", http.StatusServiceUnavailable)
+		})
+		_, err := rdClient.ListExtensions(context.Background())
+		var apiError *client.APIError
+		require.ErrorAs(t, err, &apiError)
+		assert.Equal(t, http.StatusServiceUnavailable, apiError.StatusCode)
+		assert.Equal(t, "Extension manager is not ready yet.", apiError.Body)
+		assert.EqualError(t, err, "503 Service Unavailable: Extension manager is not ready yet.")
+	})
+
+	t.Run("Bad requests should report the body", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		})
+		_, err := rdClient.InstallExtension(context.Background(), "bad image")
+		assert.EqualError(t, err, "Invalid image ID")
+	})
+}
+
+func TestExtensions(t *testing.T) {
+	t.Run("ListExtensions should decode the extensions", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/extensions", r.URL.Path)
+			_, _ = w.Write([]byte(`{"example/extension": {"version": "1.0.0", "labels": {"a": "b"}}}`))
+		})
+		extensions, err := rdClient.ListExtensions(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Extension{
+			"example/extension": {Version: "1.0.0", Labels: map[string]string{"a": "b"}},
+		}, extensions)
+	})
+
+	t.Run("InstallExtension should send the image ID", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/v1/extensions/install", r.URL.Path)
+			assert.Equal(t, "example/extension:1.0.0", r.URL.Query().Get("id"))
+			w.WriteHeader(http.StatusCreated)
+		})
+		_, err := rdClient.InstallExtension(context.Background(), "example/extension:1.0.0")
+		assert.NoError(t, err)
+	})
+}
+
+func TestReset(t *testing.T) {
+	rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/v1/k8s_reset", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"mode": "wipe"}`, string(body))
+		w.WriteHeader(http.StatusAccepted)
+	})
+	_, err := rdClient.Reset(context.Background(), ResetVM)
+	assert.NoError(t, err)
+}
+
+func TestWaitForState(t *testing.T) {
+	savedInterval := statePollInterval
+	statePollInterval = time.Millisecond
+	t.Cleanup(func() { statePollInterval = savedInterval })
+
+	t.Run("WaitForState should return the state once it is reached", func(t *testing.T) {
+		states := []string{client.StateStopped, client.StateStarting, client.StateStarted}
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			state := states[0]
+			if len(states) > 1 {
+				states = states[1:]
+			}
+			_, _ = w.Write([]byte(`{"vmState": "` + state + `"}`))
+		})
+		state, err := rdClient.WaitForState(context.Background(), time.Minute, client.StateStarted, client.StateError)
+		require.NoError(t, err)
+		assert.Equal(t, client.StateStarted, state.VMState)
+	})
+
+	t.Run("WaitForState should time out", func(t *testing.T) {
+		rdClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"vmState": "STARTING"}`))
+		})
+		state, err := rdClient.WaitForState(context.Background(), 10*time.Millisecond, client.StateStarted)
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+		assert.Equal(t, client.StateStarting, state.VMState)
+	})
+}
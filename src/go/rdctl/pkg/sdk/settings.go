@@ -0,0 +1,212 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+)
+
+// ParseSettings parses partial settings written as JSON or YAML. Fields that
+// ServerSettingsForJSON doesn't have, and values of the wrong type, are
+// rejected. Settings without a version are taken to be in the current
+// format.
+func ParseSettings(contents []byte) (*options.ServerSettingsForJSON, error) {
+	// YAML is a superset of JSON, so this parses both.
+	var document any
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if document == nil {
+		return nil, errors.New("no settings were given")
+	}
+	if _, ok := document.(map[string]any); !ok {
+		return nil, errors.New("settings must be an object")
+	}
+	jsonContents, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert settings to JSON: %w", err)
+	}
+	settings, err := decodeSettings(jsonContents)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Version == nil {
+		version := options.CURRENT_SETTINGS_VERSION
+		settings.Version = &version
+	}
+	return settings, nil
+}
+
+// Decodes settings from JSON, rejecting unknown fields.
+func decodeSettings(contents []byte) (*options.ServerSettingsForJSON, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	settings := &options.ServerSettingsForJSON{}
+	if err := decoder.Decode(settings); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SettingsDocument returns settings as decoded JSON, holding only the
+// fields that are set.
+func SettingsDocument(settings *options.ServerSettingsForJSON) (map[string]any, error) {
+	contents, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	document := map[string]any{}
+	if err := json.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	removeEmptyObjects(document)
+	return document, nil
+}
+
+// Removes the objects in document that hold nothing once their own empty
+// objects are removed; the groups of settings in ServerSettingsForJSON are
+// marshaled as empty objects when none of their settings are set.
+func removeEmptyObjects(document map[string]any) {
+	for key, value := range document {
+		if child, ok := value.(map[string]any); ok {
+			removeEmptyObjects(child)
+			if len(child) == 0 {
+				delete(document, key)
+			}
+		}
+	}
+}
+
+// MergeSettings returns the settings set in base, overridden by the ones
+// set in overlay.
+func MergeSettings(base, overlay *options.ServerSettingsForJSON) (*options.ServerSettingsForJSON, error) {
+	baseDocument, err := SettingsDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	overlayDocument, err := SettingsDocument(overlay)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := json.Marshal(MergeDocuments(baseDocument, overlayDocument))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	return decodeSettings(contents)
+}
+
+// MergeDocuments returns base with the values in overlay applied, as a PUT
+// to the settings endpoint would: objects are merged key by key, and other
+// values, including arrays, are replaced. Neither argument is modified.
+func MergeDocuments(base, overlay any) any {
+	baseMap, baseIsMap := base.(map[string]any)
+	overlayMap, overlayIsMap := overlay.(map[string]any)
+	if !baseIsMap || !overlayIsMap {
+		return overlay
+	}
+	result := maps.Clone(baseMap)
+	for key, value := range overlayMap {
+		if baseValue, ok := baseMap[key]; ok {
+			result[key] = MergeDocuments(baseValue, value)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// SettingNames returns the dotted names of the settings in
+// ServerSettingsForJSON, in the order they are declared.
+func SettingNames() []string {
+	var names []string
+	var walk func(typ reflect.Type, prefix string)
+	walk = func(typ reflect.Type, prefix string) {
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			name := prefix + jsonFieldName(field)
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, name+".")
+			} else {
+				names = append(names, name)
+			}
+		}
+	}
+	walk(reflect.TypeFor[options.ServerSettingsForJSON](), "")
+	return names
+}
+
+// ResolveSettingPath returns the keys of the setting with the given dotted
+// name in the settings JSON. Each part of the name is either the JSON name of
+// a field in ServerSettingsForJSON, or the name used for it by the flags of
+// `rdctl set`, such as "virtual-machine.memory-in-gb". The parts following a
+// setting that holds a map, such as WSL.integrations, are keys in that map.
+func ResolveSettingPath(name string) ([]string, error) {
+	parts := strings.Split(name, ".")
+	path := make([]string, 0, len(parts))
+	typ := reflect.TypeFor[options.ServerSettingsForJSON]()
+	for i, part := range parts {
+		if typ.Kind() == reflect.Map {
+			return append(path, parts[i:]...), nil
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown setting %q: %q has no settings under it", name, strings.Join(path, "."))
+		}
+		field, ok := findSettingField(typ, part)
+		if !ok {
+			return nil, fmt.Errorf("unknown setting %q", name)
+		}
+		path = append(path, jsonFieldName(field))
+		typ = field.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+	}
+	return path, nil
+}
+
+// Returns the field of typ named part, either by its JSON name or by the
+// name used by the flags of `rdctl set`.
+func findSettingField(typ reflect.Type, part string) (reflect.StructField, bool) {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	}
+	for i := range typ.NumField() {
+		if field := typ.Field(i); jsonFieldName(field) == part {
+			return field, true
+		}
+	}
+	for i := range typ.NumField() {
+		if field := typ.Field(i); normalize(jsonFieldName(field)) == normalize(part) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+}
+
+// LookupSetting returns the value at path, as returned by
+// ResolveSettingPath, in settings decoded from JSON.
+func LookupSetting(settings any, path []string) (any, bool) {
+	value := settings
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,125 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+)
+
+func TestParseSettings(t *testing.T) {
+	t.Run("YAML should be accepted", func(t *testing.T) {
+		settings, err := ParseSettings([]byte("kubernetes:\n  version: 1.30.1\nvirtualMachine:\n  memoryInGB: 8\n"))
+		require.NoError(t, err)
+		require.NotNil(t, settings.Kubernetes.Version)
+		assert.Equal(t, "1.30.1", *settings.Kubernetes.Version)
+		require.NotNil(t, settings.VirtualMachine.MemoryInGB)
+		assert.Equal(t, 8, *settings.VirtualMachine.MemoryInGB)
+		assert.Nil(t, settings.Kubernetes.Enabled)
+	})
+
+	t.Run("JSON should be accepted", func(t *testing.T) {
+		settings, err := ParseSettings([]byte(`{"kubernetes": {"enabled": false}}`))
+		require.NoError(t, err)
+		require.NotNil(t, settings.Kubernetes.Enabled)
+		assert.False(t, *settings.Kubernetes.Enabled)
+	})
+
+	t.Run("The version should default to the current one", func(t *testing.T) {
+		settings, err := ParseSettings([]byte(`{"kubernetes": {"enabled": false}}`))
+		require.NoError(t, err)
+		require.NotNil(t, settings.Version)
+		assert.Equal(t, options.CURRENT_SETTINGS_VERSION, *settings.Version)
+		settings, err = ParseSettings([]byte(`{"version": 10}`))
+		require.NoError(t, err)
+		assert.Equal(t, 10, *settings.Version)
+	})
+
+	t.Run("Invalid settings should be rejected", func(t *testing.T) {
+		for input, message := range map[string]string{
+			`{"kubernetes": {"versoin": "1.30.1"}}`:      `unknown field "versoin"`,
+			`{"virtualMachine": {"memoryInGB": "lots"}}`: "memoryInGB",
+			"- kubernetes\n": "must be an object",
+			"":               "no settings",
+			"kubernetes: [":  "failed to parse",
+			`{"kubernetes": {"enabled": true}, "bogus": 1}`: `unknown field "bogus"`,
+		} {
+			_, err := ParseSettings([]byte(input))
+			assert.ErrorContains(t, err, message, input)
+		}
+	})
+}
+
+func TestSettingsDocument(t *testing.T) {
+	settings, err := ParseSettings([]byte(`{"kubernetes": {"enabled": false}}`))
+	require.NoError(t, err)
+	document, err := SettingsDocument(settings)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"version":    float64(options.CURRENT_SETTINGS_VERSION),
+		"kubernetes": map[string]any{"enabled": false},
+	}, document)
+}
+
+func TestMergeSettings(t *testing.T) {
+	base, err := ParseSettings([]byte(`{"kubernetes": {"version": "1.30.1", "enabled": true}}`))
+	require.NoError(t, err)
+	overlay, err := ParseSettings([]byte(`{"kubernetes": {"version": "1.29.4"}}`))
+	require.NoError(t, err)
+	merged, err := MergeSettings(base, overlay)
+	require.NoError(t, err)
+	assert.Equal(t, "1.29.4", *merged.Kubernetes.Version)
+	assert.True(t, *merged.Kubernetes.Enabled)
+	assert.Equal(t, "1.30.1", *base.Kubernetes.Version, "the base should not be modified")
+}
+
+func TestMergeDocuments(t *testing.T) {
+	base := map[string]any{"a": map[string]any{"b": 1, "c": []any{1}}, "d": "e"}
+	merged := MergeDocuments(base, map[string]any{"a": map[string]any{"c": []any{2}}, "f": true})
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": 1, "c": []any{2}}, "d": "e", "f": true}, merged)
+	assert.Equal(t, []any{1}, base["a"].(map[string]any)["c"], "the base should not be modified")
+}
+
+func TestResolveSettingPath(t *testing.T) {
+	for name, expected := range map[string][]string{
+		"kubernetes.version":                                 {"kubernetes", "version"},
+		"virtual-machine.memory-in-gb":                       {"virtualMachine", "memoryInGB"},
+		"experimental.virtual-machine.mount.9p.msize-in-kib": {"experimental", "virtualMachine", "mount", "9p", "msizeInKib"},
+		"kubernetes":              {"kubernetes"},
+		"WSL.integrations.Ubuntu": {"WSL", "integrations", "Ubuntu"},
+		"application.extensions.installed.docker/logs-explorer": {"application", "extensions", "installed", "docker/logs-explorer"},
+	} {
+		path, err := ResolveSettingPath(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, expected, path, name)
+		}
+	}
+	for _, name := range []string{"kubernetes.versoin", "kubernetes.version.major", "", "kubernetes."} {
+		_, err := ResolveSettingPath(name)
+		assert.ErrorContains(t, err, "unknown setting", name)
+	}
+}
+
+func TestSettingNames(t *testing.T) {
+	names := SettingNames()
+	assert.Contains(t, names, "kubernetes.version")
+	assert.Contains(t, names, "WSL.integrations")
+	assert.NotContains(t, names, "kubernetes", "only settings should be listed")
+	for _, name := range names {
+		_, err := ResolveSettingPath(name)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestLookupSetting(t *testing.T) {
+	settings := map[string]any{"kubernetes": map[string]any{"version": "1.30.1"}}
+	value, ok := LookupSetting(settings, []string{"kubernetes", "version"})
+	assert.True(t, ok)
+	assert.Equal(t, "1.30.1", value)
+	_, ok = LookupSetting(settings, []string{"kubernetes", "enabled"})
+	assert.False(t, ok)
+	_, ok = LookupSetting(settings, []string{"kubernetes", "version", "major"})
+	assert.False(t, ok)
+}
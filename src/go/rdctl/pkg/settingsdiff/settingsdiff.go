@@ -0,0 +1,106 @@
+// Package settingsdiff compares settings documents, such as the settings of
+// Rancher Desktop and those saved in a snapshot, value by value.
+package settingsdiff
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Operations in a Change, named as in JSON Patch (RFC 6902).
+const (
+	ChangeAdd     = "add"
+	ChangeRemove  = "remove"
+	ChangeReplace = "replace"
+)
+
+// Change describes one value that differs between two documents.
+type Change struct {
+	// The keys leading to the value, outermost first.
+	Path []string `json:"path"`
+	// One of ChangeAdd, ChangeRemove or ChangeReplace.
+	Op string `json:"op"`
+	// The value in the old document.
+	Old any `json:"old,omitempty"`
+	// The value in the new document.
+	New any `json:"new,omitempty"`
+}
+
+// DottedPath returns the path of the changed value in the form used by
+// `rdctl set`, such as "kubernetes.version".
+func (change Change) DottedPath() string {
+	return strings.Join(change.Path, ".")
+}
+
+// JSONPointer returns the path of the changed value as a JSON Pointer
+// (RFC 6901), such as "/kubernetes/version".
+func (change Change) JSONPointer() string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var builder strings.Builder
+	for _, key := range change.Path {
+		builder.WriteString("/")
+		builder.WriteString(escaper.Replace(key))
+	}
+	return builder.String()
+}
+
+// DiffDocuments returns the changes needed to turn one decoded JSON or YAML
+// document into another.
+func DiffDocuments(oldValue, newValue any) []Change {
+	return diffValues([]string{}, oldValue, newValue)
+}
+
+// Returns the changes needed to turn oldValue into newValue. Objects are
+// compared key by key; any other values, including arrays, are compared as
+// a whole.
+func diffValues(path []string, oldValue, newValue any) []Change {
+	oldMap, oldIsMap := oldValue.(map[string]any)
+	newMap, newIsMap := newValue.(map[string]any)
+	if oldValue == nil && newIsMap {
+		oldMap, oldIsMap = map[string]any{}, true
+	}
+	if newValue == nil && oldIsMap {
+		newMap, newIsMap = map[string]any{}, true
+	}
+	if !oldIsMap || !newIsMap {
+		switch {
+		case reflect.DeepEqual(oldValue, newValue):
+			return []Change{}
+		case oldValue == nil:
+			return []Change{{Path: path, Op: ChangeAdd, New: newValue}}
+		case newValue == nil:
+			return []Change{{Path: path, Op: ChangeRemove, Old: oldValue}}
+		}
+		return []Change{{Path: path, Op: ChangeReplace, Old: oldValue, New: newValue}}
+	}
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for key := range oldMap {
+		keys = append(keys, key)
+	}
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	changes := []Change{}
+	for _, key := range keys {
+		oldChild, inOld := oldMap[key]
+		newChild, inNew := newMap[key]
+		childPath := append(append([]string{}, path...), key)
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Path: childPath, Op: ChangeAdd, New: newChild})
+		case !inNew:
+			changes = append(changes, Change{Path: childPath, Op: ChangeRemove, Old: oldChild})
+		case (oldChild == nil) != (newChild == nil):
+			// Unlike a missing document, a null value is still there, and
+			// is replaced rather than added or removed.
+			changes = append(changes, Change{Path: childPath, Op: ChangeReplace, Old: oldChild, New: newChild})
+		default:
+			changes = append(changes, diffValues(childPath, oldChild, newChild)...)
+		}
+	}
+	return changes
+}
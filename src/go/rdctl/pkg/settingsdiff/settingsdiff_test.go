@@ -0,0 +1,48 @@
+package settingsdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffValues(t *testing.T) {
+	oldValue := map[string]any{
+		"version": 10.0,
+		"kubernetes": map[string]any{
+			"enabled": true,
+			"version": "1.29.4",
+		},
+		"removed": "gone",
+		"list":    []any{"a", "b"},
+	}
+	newValue := map[string]any{
+		"version": 10.0,
+		"kubernetes": map[string]any{
+			"enabled": false,
+			"version": "1.29.4",
+			"port":    6443.0,
+		},
+		"list": []any{"a"},
+	}
+	expected := []Change{
+		{Path: []string{"kubernetes", "enabled"}, Op: ChangeReplace, Old: true, New: false},
+		{Path: []string{"kubernetes", "port"}, Op: ChangeAdd, New: 6443.0},
+		{Path: []string{"list"}, Op: ChangeReplace, Old: []any{"a", "b"}, New: []any{"a"}},
+		{Path: []string{"removed"}, Op: ChangeRemove, Old: "gone"},
+	}
+	assert.Equal(t, expected, diffValues([]string{}, oldValue, newValue))
+	assert.Empty(t, diffValues([]string{}, oldValue, oldValue))
+	assert.Len(t, diffValues([]string{}, nil, newValue), 3, "a missing file should compare as empty")
+	assert.Equal(t, []Change{
+		{Path: []string{"cleared"}, Op: ChangeReplace, Old: "set", New: nil},
+		{Path: []string{"set"}, Op: ChangeReplace, Old: nil, New: "set"},
+	}, diffValues([]string{}, map[string]any{"cleared": "set", "set": nil}, map[string]any{"cleared": nil, "set": "set"}),
+		"null values should be replaced, not added or removed")
+}
+
+func TestChangePaths(t *testing.T) {
+	change := Change{Path: []string{"application", "path/with~chars"}}
+	assert.Equal(t, "application.path/with~chars", change.DottedPath())
+	assert.Equal(t, "/application/path~1with~0chars", change.JSONPointer())
+}
@@ -0,0 +1,566 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+)
+
+const archiveManifestName = "manifest.json"
+const archiveFormatVersion = 1
+
+// Imports are extracted into a directory with this prefix, which List
+// ignores because it is not a UUID.
+const importStagingPrefix = "import-"
+
+// The largest metadata.json or manifest.json we are willing to read from an
+// archive; anything bigger is not something we wrote.
+const maxArchiveJSONSize = 1 << 20
+
+// archiveManifest is written as the last entry of a snapshot archive and
+// describes the rest of its contents.
+type archiveManifest struct {
+	// The version of the archive format.
+	FormatVersion int `json:"formatVersion"`
+	// The operating system the snapshot was created on; snapshots are not
+	// portable between platforms.
+	OS string `json:"os"`
+	// The CPU architecture the snapshot was created on; VM disks are not
+	// portable between architectures.
+	Arch string `json:"arch"`
+	// The files contained in the archive, other than the manifest itself.
+	Files []archiveManifestFile `json:"files"`
+}
+
+type archiveManifestFile struct {
+	Name   string      `json:"name"`
+	Size   int64       `json:"size"`
+	SHA256 string      `json:"sha256"`
+	Mode   os.FileMode `json:"mode"`
+	// For symbolic links, the path they point to; they have no size or
+	// checksum.
+	LinkTarget string `json:"linkTarget,omitempty"`
+}
+
+// Export writes the snapshot with the given name to a compressed archive at
+// archivePath, so that it can be imported on another machine.
+func (manager *Manager) Export(ctx context.Context, name, archivePath string) (err error) {
+	snapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted export does
+	// not leave a truncated archive behind. It is not created with
+	// os.CreateTemp, which would make it only readable by its owner; the
+	// archive gets the usual 0644 less the umask, like any other new file.
+	tempPath := filepath.Join(filepath.Dir(archivePath), ".rdctl-snapshot-export-"+uuid.NewString())
+	tempFile, err := os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer func() {
+		_ = tempFile.Close()
+		if err != nil {
+			_ = os.Remove(tempFile.Name())
+		}
+	}()
+	if err := manager.writeArchive(ctx, snapshot, tempFile); err != nil {
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), archivePath); err != nil {
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return nil
+}
+
+// writeArchive writes a snapshot to writer as a compressed archive.
+func (manager *Manager) writeArchive(ctx context.Context, snapshot Snapshot, writer io.Writer) error {
+	if snapshot.Parent != "" {
+		return fmt.Errorf("snapshot %q is incremental, and must be flattened before it can be exported", snapshot.Name)
+	}
+	snapshotDir := manager.SnapshotDirectory(snapshot)
+	// VM disks are large; favour speed over compression ratio.
+	gzipWriter, err := gzip.NewWriterLevel(writer, gzip.BestSpeed)
+	if err != nil {
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifest := archiveManifest{
+		FormatVersion: archiveFormatVersion,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+	}
+
+	allowed := contentFiles(manager.Paths)
+	snapshotFiles, err := expandContentFiles(snapshotDir, allowed)
+	if err != nil {
+		return err
+	}
+	entries := []contentFile{
+		{Name: metadataFileName, FileMode: 0o644},
+		// Snapshots created by older versions have no contents.json.
+		{Name: contentsFileName, MissingOk: true, FileMode: 0o644},
+	}
+	entries = append(entries, snapshotFiles...)
+	for _, entry := range entries {
+		if contextIsDone(ctx) {
+			return runner.ErrContextDone
+		}
+		var manifestFile archiveManifestFile
+		if entry.LinkTarget != "" {
+			if err := checkLinkTarget(allowed, entry.Name, entry.LinkTarget); err != nil {
+				return err
+			}
+			manifestFile, err = addArchiveLink(tarWriter, entry)
+		} else {
+			manifestFile, err = addArchiveFile(tarWriter, filepath.Join(snapshotDir, filepath.FromSlash(entry.Name)), entry)
+		}
+		if errors.Is(err, os.ErrNotExist) && entry.MissingOk {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to add %q to archive: %w", entry.Name, err)
+		}
+		manifest.Files = append(manifest.Files, manifestFile)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive manifest: %w", err)
+	}
+	header := &tar.Header{
+		Name:    archiveManifestName,
+		Mode:    0o644,
+		Size:    int64(len(manifestBytes)),
+		ModTime: time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	if _, err := tarWriter.Write(manifestBytes); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive compression: %w", err)
+	}
+	return nil
+}
+
+// addArchiveFile writes a single file into the archive, returning its
+// manifest entry.
+func addArchiveFile(tarWriter *tar.Writer, path string, entry contentFile) (archiveManifestFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return archiveManifestFile{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return archiveManifestFile{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return archiveManifestFile{}, fmt.Errorf("%q is not a regular file", path)
+	}
+	header := &tar.Header{
+		Name:    entry.Name,
+		Mode:    int64(entry.FileMode),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return archiveManifestFile{}, err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tarWriter, hash), file); err != nil {
+		return archiveManifestFile{}, err
+	}
+	return archiveManifestFile{
+		Name:   entry.Name,
+		Size:   info.Size(),
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+		Mode:   entry.FileMode,
+	}, nil
+}
+
+// addArchiveLink writes a symbolic link into the archive, returning its
+// manifest entry.
+func addArchiveLink(tarWriter *tar.Writer, entry contentFile) (archiveManifestFile, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     entry.Name,
+		Linkname: entry.LinkTarget,
+		Mode:     int64(entry.FileMode),
+		ModTime:  time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return archiveManifestFile{}, err
+	}
+	return archiveManifestFile{
+		Name:       entry.Name,
+		Mode:       entry.FileMode,
+		LinkTarget: entry.LinkTarget,
+	}, nil
+}
+
+// Checks that the symbolic link name, which points to target, stays within
+// the directory in files that holds it, so that extracting or restoring it
+// can't reach anything else. The target must be relative, and can only go
+// up with leading ".." elements; one after another element would go up
+// from wherever that element points to, if it is itself a link.
+func checkLinkTarget(files []contentFile, name, target string) error {
+	for _, file := range files {
+		if !file.Dir || !strings.HasPrefix(name, file.Name+"/") {
+			continue
+		}
+		elements := strings.Split(target, "/")
+		up := 0
+		for up < len(elements) && elements[up] == ".." {
+			up++
+		}
+		rest := strings.Join(elements[up:], "/")
+		resolved := path.Join(path.Dir(name), target)
+		if target != "" && (rest == "" || filepath.IsLocal(filepath.FromSlash(rest))) &&
+			(resolved == file.Name || strings.HasPrefix(resolved, file.Name+"/")) {
+			return nil
+		}
+		return fmt.Errorf("symbolic link %q points outside %q", name, file.Name)
+	}
+	return fmt.Errorf("symbolic link %q is not in a directory of the snapshot", name)
+}
+
+// Import creates a new snapshot from an archive written by Export. If name is
+// empty, the name recorded in the archive is used. The imported snapshot is
+// given a new ID.
+func (manager *Manager) Import(ctx context.Context, archivePath, name string) (Snapshot, error) {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archiveFile.Close()
+	return manager.readArchive(ctx, archiveFile, name)
+}
+
+// readArchive creates a new snapshot from an archive written by
+// writeArchive, read from reader.
+func (manager *Manager) readArchive(ctx context.Context, reader io.Reader, name string) (snapshot Snapshot, err error) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to generate ID for snapshot: %w", err)
+	}
+	stagingDir := filepath.Join(manager.Snapshots, importStagingPrefix+id.String())
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+		if err != nil && snapshot.ID != "" {
+			_ = os.RemoveAll(manager.SnapshotDirectory(snapshot))
+		}
+	}()
+
+	allowed := map[string]contentFile{
+		metadataFileName: {Name: metadataFileName, FileMode: 0o644},
+		contentsFileName: {Name: contentsFileName, MissingOk: true, FileMode: 0o644},
+	}
+	for _, entry := range contentFiles(manager.Paths) {
+		allowed[entry.Name] = entry
+	}
+	extracted := make(map[string]archiveManifestFile)
+	// The symbolic links extracted so far; nothing may be extracted through
+	// them, as they may point anywhere in their directory.
+	links := make(map[string]bool)
+	var manifestBytes, metadataBytes []byte
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		if contextIsDone(ctx) {
+			return Snapshot{}, runner.ErrContextDone
+		}
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return Snapshot{}, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink {
+			return Snapshot{}, fmt.Errorf("unexpected archive entry %q: not a regular file or symbolic link", header.Name)
+		}
+		if _, ok := extracted[header.Name]; ok || (header.Name == archiveManifestName && manifestBytes != nil) {
+			return Snapshot{}, fmt.Errorf("duplicate archive entry %q", header.Name)
+		}
+		switch header.Name {
+		case archiveManifestName:
+			if manifestBytes, err = readArchiveJSON(tarReader, header); err != nil {
+				return Snapshot{}, err
+			}
+			continue
+		case metadataFileName:
+			if metadataBytes, err = readArchiveJSON(tarReader, header); err != nil {
+				return Snapshot{}, err
+			}
+			extracted[header.Name] = checksumBytes(header.Name, metadataBytes)
+			continue
+		}
+		entry, ok := allowedArchiveEntry(allowed, header)
+		if !ok {
+			return Snapshot{}, fmt.Errorf("unexpected archive entry %q", header.Name)
+		}
+		for parent := path.Dir(header.Name); parent != "."; parent = path.Dir(parent) {
+			if links[parent] {
+				return Snapshot{}, fmt.Errorf("unexpected archive entry %q: inside symbolic link %q", header.Name, parent)
+			}
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			if err := checkLinkTarget(contentFiles(manager.Paths), header.Name, header.Linkname); err != nil {
+				return Snapshot{}, fmt.Errorf("invalid archive: %w", err)
+			}
+			for name := range extracted {
+				if strings.HasPrefix(name, header.Name+"/") {
+					return Snapshot{}, fmt.Errorf("unexpected archive entry %q: inside symbolic link %q", name, header.Name)
+				}
+			}
+			links[header.Name] = true
+			extracted[header.Name], err = extractArchiveLink(stagingDir, entry)
+		} else {
+			extracted[header.Name], err = extractArchiveFile(tarReader, stagingDir, entry)
+		}
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("failed to extract %q: %w", header.Name, err)
+		}
+	}
+
+	if manifestBytes == nil {
+		return Snapshot{}, errors.New("invalid archive: manifest not found")
+	}
+	if metadataBytes == nil {
+		return Snapshot{}, fmt.Errorf("invalid archive: %s not found", metadataFileName)
+	}
+	manifest := archiveManifest{}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+	if err := validateManifest(manifest, extracted, allowed); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid archive: %w", err)
+	}
+	if _, err := readContentsFile(manager.Paths, stagingDir); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid archive: %w", err)
+	}
+	if err := json.Unmarshal(metadataBytes, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid archive %s: %w", metadataFileName, err)
+	}
+	if snapshot.Parent != "" {
+		return Snapshot{}, errors.New("invalid archive: snapshot is incremental")
+	}
+	for key, value := range snapshot.Labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return Snapshot{}, fmt.Errorf("invalid archive: %w", err)
+		}
+	}
+	snapshot.ID = ""
+	if name != "" {
+		snapshot.Name = name
+	}
+	if err := manager.ValidateName(snapshot.Name); err != nil {
+		return Snapshot{}, err
+	}
+	snapshot.ID = id.String()
+	// The manifest checksums have been verified; record them so that the
+	// imported snapshot can be verified before it is restored.
+	snapshot.Files = nil
+	for _, file := range manifest.Files {
+		if file.Name != metadataFileName && file.Name != contentsFileName && file.LinkTarget == "" {
+			snapshot.Files = append(snapshot.Files, SnapshotFile{Name: file.Name, Size: file.Size, SHA256: file.SHA256})
+		}
+	}
+	if err := writeMetadataFileAt(stagingDir, snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if err := manager.moveIntoPlace(ctx, stagingDir, snapshot); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// Moves a snapshot read from an archive from stagingDir into place, and
+// marks it as complete.
+func (manager *Manager) moveIntoPlace(ctx context.Context, stagingDir string, snapshot Snapshot) (err error) {
+	action := fmt.Sprintf("Importing snapshot %q", snapshot.Name)
+	if err := manager.LockMetadata(manager.Paths, action); err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := manager.Unlock(ctx, manager.Paths, false)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+	// Revalidate under the lock, in case another process took the name.
+	if err := manager.ValidateName(snapshot.Name); err != nil {
+		return err
+	}
+	if err := os.Rename(stagingDir, manager.SnapshotDirectory(snapshot)); err != nil {
+		return fmt.Errorf("failed to move snapshot into place: %w", err)
+	}
+	return writeCompleteFile(manager.SnapshotDirectory(snapshot))
+}
+
+// validateManifest checks that the archive was made on a compatible platform,
+// that every file it lists was extracted with the recorded size and checksum,
+// and that no required files are missing.
+func validateManifest(manifest archiveManifest, extracted map[string]archiveManifestFile, allowed map[string]contentFile) error {
+	if manifest.FormatVersion != archiveFormatVersion {
+		return fmt.Errorf("unsupported archive format version %d", manifest.FormatVersion)
+	}
+	if manifest.OS != runtime.GOOS || manifest.Arch != runtime.GOARCH {
+		return fmt.Errorf("snapshot was created on %s/%s and cannot be used on %s/%s",
+			manifest.OS, manifest.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, file := range manifest.Files {
+		actual, ok := extracted[file.Name]
+		if !ok {
+			return fmt.Errorf("file %q listed in manifest is missing", file.Name)
+		}
+		if actual.Size != file.Size {
+			return fmt.Errorf("file %q has size %d, expected %d", file.Name, actual.Size, file.Size)
+		}
+		if actual.SHA256 != file.SHA256 {
+			return fmt.Errorf("file %q has checksum %s, expected %s", file.Name, actual.SHA256, file.SHA256)
+		}
+		if actual.LinkTarget != file.LinkTarget {
+			return fmt.Errorf("file %q links to %q, expected %q", file.Name, actual.LinkTarget, file.LinkTarget)
+		}
+		listed[file.Name] = true
+	}
+	for name := range extracted {
+		if !listed[name] {
+			return fmt.Errorf("file %q is not listed in manifest", name)
+		}
+	}
+	for name, entry := range allowed {
+		if !entry.MissingOk && !listed[name] {
+			return fmt.Errorf("required file %q is missing", name)
+		}
+	}
+	return nil
+}
+
+// allowedArchiveEntry returns how the archive entry described by header is to
+// be extracted, if it is one of the allowed files or is a regular file or
+// symbolic link in one of the allowed directories.
+func allowedArchiveEntry(allowed map[string]contentFile, header *tar.Header) (contentFile, bool) {
+	if entry, ok := allowed[header.Name]; ok && !entry.Dir {
+		return entry, header.Typeflag == tar.TypeReg
+	}
+	if path.IsAbs(header.Name) || path.Clean(header.Name) != header.Name {
+		return contentFile{}, false
+	}
+	for _, entry := range allowed {
+		if entry.Dir && strings.HasPrefix(header.Name, entry.Name+"/") {
+			linkTarget := ""
+			if header.Typeflag == tar.TypeSymlink {
+				linkTarget = header.Linkname
+			}
+			return contentFile{
+				Name:       header.Name,
+				FileMode:   os.FileMode(header.Mode).Perm(),
+				Component:  entry.Component,
+				LinkTarget: linkTarget,
+			}, true
+		}
+	}
+	return contentFile{}, false
+}
+
+// readArchiveJSON reads a small JSON document from the archive.
+func readArchiveJSON(reader io.Reader, header *tar.Header) ([]byte, error) {
+	if header.Size > maxArchiveJSONSize {
+		return nil, fmt.Errorf("archive entry %q is too large", header.Name)
+	}
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from archive: %w", header.Name, err)
+	}
+	return contents, nil
+}
+
+// extractArchiveFile writes the current archive entry into stagingDir,
+// returning its actual size and checksum.
+func extractArchiveFile(reader io.Reader, stagingDir string, entry contentFile) (archiveManifestFile, error) {
+	path := filepath.Join(stagingDir, filepath.FromSlash(entry.Name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return archiveManifestFile{}, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.FileMode)
+	if err != nil {
+		return archiveManifestFile{}, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), reader)
+	if err != nil {
+		return archiveManifestFile{}, err
+	}
+	if err := file.Close(); err != nil {
+		return archiveManifestFile{}, err
+	}
+	return archiveManifestFile{
+		Name:   entry.Name,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+		Mode:   entry.FileMode,
+	}, nil
+}
+
+// extractArchiveLink creates the symbolic link described by entry in
+// stagingDir, returning its manifest entry.
+func extractArchiveLink(stagingDir string, entry contentFile) (archiveManifestFile, error) {
+	linkPath := filepath.Join(stagingDir, filepath.FromSlash(entry.Name))
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0o755); err != nil {
+		return archiveManifestFile{}, err
+	}
+	if err := os.Symlink(filepath.FromSlash(entry.LinkTarget), linkPath); err != nil {
+		return archiveManifestFile{}, err
+	}
+	return archiveManifestFile{
+		Name:       entry.Name,
+		Mode:       entry.FileMode,
+		LinkTarget: entry.LinkTarget,
+	}, nil
+}
+
+func checksumBytes(name string, contents []byte) archiveManifestFile {
+	sum := sha256.Sum256(contents)
+	return archiveManifestFile{
+		Name:   name,
+		Size:   int64(len(contents)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+}
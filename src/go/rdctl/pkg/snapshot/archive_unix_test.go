@@ -0,0 +1,304 @@
+//go:build unix
+
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// racingLock calls beforeLock the first time the metadata lock is taken, to
+// stand in for another process that got there first.
+type racingLock struct {
+	lock.MockBackendLock
+	beforeLock func()
+}
+
+func (racingLock *racingLock) LockMetadata(appPaths *paths.Paths, action string) error {
+	if beforeLock := racingLock.beforeLock; beforeLock != nil {
+		racingLock.beforeLock = nil
+		beforeLock()
+	}
+	return racingLock.MockBackendLock.LockMetadata(appPaths, action)
+}
+
+// rewriteArchive copies the archive at src to dst, passing the header and
+// contents of each entry through modify.
+func rewriteArchive(t *testing.T, src, dst string, modify func(header *tar.Header, contents []byte) []byte) {
+	srcFile, err := os.Open(src)
+	require.NoError(t, err)
+	defer srcFile.Close()
+	gzipReader, err := gzip.NewReader(srcFile)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	dstFile, err := os.Create(dst)
+	require.NoError(t, err)
+	defer dstFile.Close()
+	gzipWriter := gzip.NewWriter(dstFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		contents, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		contents = modify(header, contents)
+		header.Size = int64(len(contents))
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err = tarWriter.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+}
+
+func TestArchive(t *testing.T) {
+	t.Run("Import should recreate an exported snapshot under a new ID", func(t *testing.T) {
+		appPaths, testFiles := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "a description")
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		imported, err := manager.Import(context.Background(), archivePath, "")
+		require.NoError(t, err)
+		assert.Equal(t, original.Name, imported.Name)
+		assert.Equal(t, original.Description, imported.Description)
+		assert.NotEqual(t, original.ID, imported.ID)
+
+		snapshots, err := manager.List(false)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, imported.ID, snapshots[0].ID)
+
+		snapshotDir := manager.SnapshotDirectory(imported)
+		for testFileName, testFile := range testFiles {
+			contents, err := os.ReadFile(filepath.Join(snapshotDir, testFileName))
+			require.NoError(t, err)
+			assert.Equal(t, testFile.Contents, string(contents), "contents of %s", testFileName)
+		}
+	})
+
+	t.Run("Import should use the given name", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+
+		_, err = manager.Import(context.Background(), archivePath, "")
+		assert.ErrorContains(t, err, "already exists")
+		imported, err := manager.Import(context.Background(), archivePath, "renamed")
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", imported.Name)
+		snapshots, err := manager.List(true)
+		require.NoError(t, err)
+		assert.Len(t, snapshots, 2)
+	})
+
+	t.Run("Import should check the name again under the lock", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+
+		manager.BackendLocker = &racingLock{beforeLock: func() {
+			_, err := manager.Create(context.Background(), "renamed", "")
+			require.NoError(t, err)
+		}}
+		_, err = manager.Import(context.Background(), archivePath, "renamed")
+		assert.ErrorContains(t, err, "already exists")
+		snapshots, err := manager.List(true)
+		require.NoError(t, err)
+		assert.Len(t, snapshots, 2, "the imported snapshot should be removed")
+	})
+
+	t.Run("Import should reject an archive whose contents do not match the manifest", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		archiveDir := t.TempDir()
+		archivePath := filepath.Join(archiveDir, "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		tamperedPath := filepath.Join(archiveDir, "tampered.tar.gz")
+		rewriteArchive(t, archivePath, tamperedPath, func(header *tar.Header, contents []byte) []byte {
+			if header.Name == "diffdisk" {
+				return contents[:len(contents)/2]
+			}
+			return contents
+		})
+		_, err = manager.Import(context.Background(), tamperedPath, "")
+		assert.ErrorContains(t, err, `"diffdisk" has size`)
+
+		entries, err := os.ReadDir(manager.Snapshots)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "failed import should not leave files behind")
+	})
+
+	t.Run("Export should create an archive readable by others, less the umask", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		oldUmask := syscall.Umask(0o027)
+		defer syscall.Umask(oldUmask)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		info, err := os.Stat(archivePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	})
+
+	t.Run("Import should reject invalid labels", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		original.Labels = map[string]string{"bad key": "value"}
+		require.NoError(t, writeMetadataFileAt(manager.SnapshotDirectory(original), original))
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+
+		_, err = manager.Import(context.Background(), archivePath, "renamed")
+		assert.ErrorContains(t, err, "invalid label key")
+		snapshots, err := manager.List(true)
+		require.NoError(t, err)
+		assert.Len(t, snapshots, 1)
+	})
+
+	t.Run("Export should fail for a nonexistent snapshot", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		assert.Error(t, manager.Export(context.Background(), "no-such-snapshot", archivePath))
+		_, err := os.Stat(archivePath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("Import should recreate component directories", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		componentFiles := populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions, ComponentShims)
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		imported, err := manager.Import(context.Background(), archivePath, "")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, original.Files, imported.Files)
+		require.NoError(t, manager.Verify(context.Background(), imported))
+		contents, err := readContentsFile(appPaths, manager.SnapshotDirectory(imported))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{ComponentExtensions, ComponentShims}, contents.Components)
+		info, err := os.Stat(filepath.Join(manager.SnapshotDirectory(imported), "containerd-shims", filepath.Base(componentFiles["shim"].Path)))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	})
+
+	t.Run("Import should reject entries outside of component directories", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions)
+		require.NoError(t, err)
+		archiveDir := t.TempDir()
+		archivePath := filepath.Join(archiveDir, "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		tamperedPath := filepath.Join(archiveDir, "tampered.tar.gz")
+		rewriteArchive(t, archivePath, tamperedPath, func(header *tar.Header, contents []byte) []byte {
+			if header.Name == "extensions/ext-id/metadata.json" {
+				header.Name = "extensions/../../escaped.json"
+			}
+			return contents
+		})
+		_, err = manager.Import(context.Background(), tamperedPath, "")
+		assert.ErrorContains(t, err, "unexpected archive entry")
+	})
+
+	t.Run("Import should recreate symbolic links in component directories", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		require.NoError(t, os.Symlink("ext-id/metadata.json", filepath.Join(appPaths.ExtensionRoot, "current")))
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions)
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		imported, err := manager.Import(context.Background(), archivePath, "")
+		require.NoError(t, err)
+		require.NoError(t, manager.Verify(context.Background(), imported))
+		target, err := os.Readlink(filepath.Join(manager.SnapshotDirectory(imported), "extensions", "current"))
+		require.NoError(t, err)
+		assert.Equal(t, "ext-id/metadata.json", target)
+	})
+
+	t.Run("Export should reject symbolic links outside of component directories", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		require.NoError(t, os.Symlink("../settings.json", filepath.Join(appPaths.ExtensionRoot, "escape")))
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions)
+		require.NoError(t, err)
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+		err = manager.Export(context.Background(), original.Name, archivePath)
+		assert.ErrorContains(t, err, "points outside")
+		_, err = os.Stat(archivePath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Import should reject symbolic links outside of component directories", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		require.NoError(t, os.Symlink("ext-id", filepath.Join(appPaths.ExtensionRoot, "current")))
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions)
+		require.NoError(t, err)
+		archiveDir := t.TempDir()
+		archivePath := filepath.Join(archiveDir, "snapshot.tar.gz")
+		require.NoError(t, manager.Export(context.Background(), original.Name, archivePath))
+		require.NoError(t, manager.Delete(context.Background(), original.Name))
+
+		for _, target := range []string{"/etc", "../..", "ext-id/../../..", ""} {
+			tamperedPath := filepath.Join(archiveDir, "tampered.tar.gz")
+			rewriteArchive(t, archivePath, tamperedPath, func(header *tar.Header, contents []byte) []byte {
+				if header.Name == "extensions/current" {
+					header.Linkname = target
+				}
+				return contents
+			})
+			_, err = manager.Import(context.Background(), tamperedPath, "")
+			assert.ErrorContains(t, err, "points outside", "target %q", target)
+		}
+		entries, err := os.ReadDir(manager.Snapshots)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "failed import should not leave files behind")
+	})
+}
@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const autoSnapshotSettingsFileName = "auto-snapshot.json"
+
+// Automatic snapshots are named with this prefix and the time they were
+// taken.
+const (
+	autoSnapshotNamePrefix = "auto-"
+	autoSnapshotTimeFormat = "20060102-150405"
+)
+
+// AutoSnapshotSettings controls when snapshots are taken automatically.
+type AutoSnapshotSettings struct {
+	// Take a snapshot before rdctl commands that discard VM or Kubernetes
+	// state, as if they were given --snapshot-first.
+	BeforeDestructive bool `json:"beforeDestructive"`
+}
+
+// LoadAutoSnapshotSettings returns the saved settings; automatic snapshots
+// are disabled if none have been saved.
+func (manager *Manager) LoadAutoSnapshotSettings() (AutoSnapshotSettings, error) {
+	settings := AutoSnapshotSettings{}
+	contents, err := os.ReadFile(filepath.Join(manager.Snapshots, autoSnapshotSettingsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	} else if err != nil {
+		return settings, fmt.Errorf("failed to read automatic snapshot settings: %w", err)
+	}
+	if err := json.Unmarshal(contents, &settings); err != nil {
+		return settings, fmt.Errorf("failed to parse automatic snapshot settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveAutoSnapshotSettings saves settings for later rdctl commands.
+func (manager *Manager) SaveAutoSnapshotSettings(settings AutoSnapshotSettings) error {
+	if err := os.MkdirAll(manager.Snapshots, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	contents, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal automatic snapshot settings: %w", err)
+	}
+	settingsPath := filepath.Join(manager.Snapshots, autoSnapshotSettingsFileName)
+	if err := os.WriteFile(settingsPath, append(contents, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write automatic snapshot settings: %w", err)
+	}
+	return nil
+}
+
+// CreateAutomatic takes a snapshot before operation, a description of a
+// destructive command such as "rdctl reset --vm". The snapshot is named
+// after the time it was taken, and marked as automatic so that a retention
+// policy can be limited to such snapshots. The backend is restarted
+// afterwards unless restart is false, for operations that would only shut
+// it down again.
+func (manager *Manager) CreateAutomatic(ctx context.Context, operation string, restart bool) (Snapshot, error) {
+	name, err := manager.automaticName(time.Now())
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return manager.create(ctx, Snapshot{
+		Name:        name,
+		Description: fmt.Sprintf("Taken automatically before %s", operation),
+		Automatic:   true,
+	}, nil, restart)
+}
+
+// Returns an unused name for an automatic snapshot taken at the given time.
+func (manager *Manager) automaticName(now time.Time) (string, error) {
+	snapshots, err := manager.List(true)
+	if err != nil {
+		return "", fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	used := make(map[string]bool, len(snapshots))
+	for _, aSnapshot := range snapshots {
+		used[aSnapshot.Name] = true
+	}
+	base := autoSnapshotNamePrefix + now.Format(autoSnapshotTimeFormat)
+	name := base
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name, nil
+}
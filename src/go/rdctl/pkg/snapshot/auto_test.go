@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// restartRecordingLock records whether each unlock restarted the backend.
+type restartRecordingLock struct {
+	lock.MockBackendLock
+	restarts []bool
+}
+
+func (recordingLock *restartRecordingLock) Unlock(ctx context.Context, appPaths *paths.Paths, restart bool) error {
+	recordingLock.restarts = append(recordingLock.restarts, restart)
+	return nil
+}
+
+func TestCreateAutomatic(t *testing.T) {
+	t.Run("Automatic snapshots should be marked and described", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		created, err := manager.CreateAutomatic(context.Background(), "rdctl reset --vm", true)
+		require.NoError(t, err)
+		assert.True(t, created.Automatic)
+		assert.Regexp(t, `^auto-\d{8}-\d{6}$`, created.Name)
+		assert.Equal(t, "Taken automatically before rdctl reset --vm", created.Description)
+
+		listed, err := manager.Snapshot(created.Name)
+		require.NoError(t, err)
+		assert.True(t, listed.Automatic, "automatic flag should be saved in the metadata")
+	})
+
+	t.Run("Automatic snapshots should only restart the backend if asked to", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		recordingLock := &restartRecordingLock{}
+		manager.BackendLocker = recordingLock
+		_, err := manager.CreateAutomatic(context.Background(), "rdctl reset --vm", true)
+		require.NoError(t, err)
+		_, err = manager.CreateAutomatic(context.Background(), "rdctl reset --factory", false)
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false}, recordingLock.restarts)
+	})
+
+	t.Run("Automatic snapshot names should not collide", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		now := time.Now()
+		name, err := manager.automaticName(now)
+		require.NoError(t, err)
+		_, err = manager.Create(context.Background(), name, "")
+		require.NoError(t, err)
+		second, err := manager.automaticName(now)
+		require.NoError(t, err)
+		assert.Equal(t, name+"-2", second)
+	})
+
+	t.Run("Automatic-only retention policies should keep other snapshots", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		day := 24 * time.Hour
+		snapshots := createAgedSnapshots(t, manager, 30*day, 20*day, 10*day, time.Hour)
+		for _, index := range []int{1, 2, 3} {
+			snapshots[index].Automatic = true
+			require.NoError(t, manager.writeMetadataFile(snapshots[index]))
+		}
+
+		result, err := manager.Prune(context.Background(), RetentionPolicy{KeepLast: 1, AutomaticOnly: true}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"snapshot-1", "snapshot-2"}, snapshotNames(result.Snapshots))
+		result, err = manager.Prune(context.Background(), RetentionPolicy{OlderThan: "15d", AutomaticOnly: true}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"snapshot-1"}, snapshotNames(result.Snapshots))
+	})
+}
+
+func TestAutoSnapshotSettings(t *testing.T) {
+	manager := newTestManager(&paths.Paths{Snapshots: t.TempDir()})
+	settings, err := manager.LoadAutoSnapshotSettings()
+	require.NoError(t, err)
+	assert.False(t, settings.BeforeDestructive, "automatic snapshots should be opt-in")
+
+	require.NoError(t, manager.SaveAutoSnapshotSettings(AutoSnapshotSettings{BeforeDestructive: true}))
+	settings, err = manager.LoadAutoSnapshotSettings()
+	require.NoError(t, err)
+	assert.True(t, settings.BeforeDestructive)
+}
@@ -0,0 +1,350 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// The name of the VM disk in a snapshot directory. Incremental snapshots
+// store it as a qcow2 overlay backed by the VM disk of their parent.
+const incrementalDiskName = "diffdisk"
+
+// Returned when incremental snapshots are not supported on this platform.
+var ErrIncrementalUnsupported = errors.New("incremental snapshots are not supported on this platform")
+
+// Returned by Manager.Delete for snapshots that other snapshots depend on.
+var ErrHasDependents = errors.New("other snapshots depend on this snapshot")
+
+// Writes the VM disk at dst from the one at src, in place of copying it.
+type diskWriter func(dst, src string, report ProgressFunc) error
+
+// Implemented by snapshotters that can have the VM disk written by a
+// diskWriter, and so support incremental snapshots.
+type incrementalSnapshotter interface {
+	createFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, components []string, report ProgressFunc, writeDisk diskWriter) error
+	restoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, report ProgressFunc, writeDisk diskWriter) error
+	// Copies a VM disk within a snapshot directory, sharing its blocks
+	// with the original where the filesystem allows.
+	copyDisk(dst, src string) error
+}
+
+func (manager *Manager) incrementalSnapshotter() (incrementalSnapshotter, error) {
+	snapshotter, ok := manager.Snapshotter.(incrementalSnapshotter)
+	if !ok || manager.DiskTool == nil {
+		return nil, ErrIncrementalUnsupported
+	}
+	return snapshotter, nil
+}
+
+// CreateIncremental creates a new snapshot like CreateWithLabels, except
+// that its VM disk only stores what differs from the disk of the snapshot
+// named parent. If parent is empty, the most recently created snapshot is
+// used. The new snapshot can't be restored without its parent; see Flatten.
+func (manager *Manager) CreateIncremental(ctx context.Context, name, description, parent string, labels map[string]string, components ...string) (Snapshot, error) {
+	if _, err := manager.incrementalSnapshotter(); err != nil {
+		return Snapshot{}, err
+	}
+	for key, value := range labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	parentSnapshot, err := manager.incrementalParent(parent)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return manager.create(ctx, Snapshot{
+		Name:        name,
+		Description: description,
+		Labels:      labels,
+		Parent:      parentSnapshot.ID,
+	}, components, true)
+}
+
+// Returns the snapshot named parent, or the most recently created snapshot
+// if parent is empty.
+func (manager *Manager) incrementalParent(parent string) (Snapshot, error) {
+	if parent != "" {
+		return manager.Snapshot(parent)
+	}
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return Snapshot{}, errors.New("there is no snapshot to base an incremental snapshot on")
+	}
+	return slices.MaxFunc(snapshots, func(a, b Snapshot) int {
+		return a.Created.Compare(b.Created)
+	}), nil
+}
+
+// Creates the files of an incremental snapshot, with the VM disk written
+// as an overlay on the disk of its parent. Must be called with the backend
+// locked.
+func (manager *Manager) createIncrementalFiles(ctx context.Context, snapshot Snapshot, components []string) error {
+	snapshotter, err := manager.incrementalSnapshotter()
+	if err != nil {
+		return err
+	}
+	// The parent may have been deleted before the lock was taken.
+	parent, err := manager.snapshotByID(snapshot.Parent)
+	if err != nil {
+		return err
+	}
+	backing := backingDiskPath(parent.ID)
+	writeDisk := func(dst, src string, report ProgressFunc) error {
+		if err := manager.DiskTool.CreateOverlay(ctx, dst, src, backing); err != nil {
+			return fmt.Errorf("failed to create overlay on snapshot %q: %w", parent.Name, err)
+		}
+		return reportDiskWritten(dst, report)
+	}
+	return snapshotter.createFiles(ctx, manager.Paths, manager.SnapshotDirectory(snapshot), components, manager.progressFunc(), writeDisk)
+}
+
+// Restores the files of a snapshot. The VM disk of an incremental snapshot
+// is flattened, so that the working disk does not depend on any snapshot.
+// Must be called with the backend locked.
+func (manager *Manager) restoreSnapshotFiles(ctx context.Context, snapshot Snapshot) error {
+	snapshotDir := manager.SnapshotDirectory(snapshot)
+	if snapshot.Parent == "" {
+		return manager.RestoreFiles(ctx, manager.Paths, snapshotDir, manager.progressFunc())
+	}
+	snapshotter, err := manager.incrementalSnapshotter()
+	if err != nil {
+		return err
+	}
+	writeDisk := func(dst, src string, report ProgressFunc) error {
+		if err := manager.DiskTool.Flatten(ctx, dst, src); err != nil {
+			return err
+		}
+		return reportDiskWritten(dst, report)
+	}
+	return snapshotter.restoreFiles(ctx, manager.Paths, snapshotDir, manager.progressFunc(), writeDisk)
+}
+
+// Reports a disk written by a DiskTool, which does not report progress
+// along the way, as done.
+func reportDiskWritten(path string, report ProgressFunc) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	report(Progress{File: filepath.Base(path), Copied: info.Size(), Total: info.Size()})
+	return nil
+}
+
+// Returns the path of the VM disk of the snapshot with the given ID,
+// relative to the directory of another snapshot.
+func backingDiskPath(id string) string {
+	return filepath.Join("..", id, incrementalDiskName)
+}
+
+// Returns the complete snapshot with the given ID.
+func (manager *Manager) snapshotByID(id string) (Snapshot, error) {
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	for _, candidate := range snapshots {
+		if candidate.ID == id {
+			return candidate, nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("can't find snapshot with ID %q", id)
+}
+
+// Returns snapshot followed by the snapshots that its VM disk is backed by,
+// nearest first. Returns an error if any of them is missing.
+func (manager *Manager) chain(snapshot Snapshot) ([]Snapshot, error) {
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	byID := make(map[string]Snapshot, len(snapshots))
+	for _, aSnapshot := range snapshots {
+		byID[aSnapshot.ID] = aSnapshot
+	}
+	result := []Snapshot{snapshot}
+	for current := snapshot; current.Parent != ""; {
+		parent, ok := byID[current.Parent]
+		if !ok {
+			return nil, fmt.Errorf("snapshot %q depends on snapshot %s, which is missing or incomplete", current.Name, current.Parent)
+		}
+		if len(result) > len(snapshots) {
+			return nil, fmt.Errorf("snapshot %q has a circular chain of parents", snapshot.Name)
+		}
+		result = append(result, parent)
+		current = parent
+	}
+	return result, nil
+}
+
+// Returns the snapshots whose VM disks are directly backed by the VM disk
+// of snapshot.
+func (manager *Manager) dependents(snapshot Snapshot) ([]Snapshot, error) {
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	return slices.DeleteFunc(snapshots, func(aSnapshot Snapshot) bool {
+		return aSnapshot.Parent != snapshot.ID
+	}), nil
+}
+
+// DeleteRebasing deletes a snapshot like Delete, but first rebases the
+// snapshots that depend on it onto its parent, or makes them standalone if
+// it has none, so that they can still be restored.
+func (manager *Manager) DeleteRebasing(ctx context.Context, name string) (err error) {
+	if _, err := manager.Snapshot(name); err != nil {
+		return err
+	}
+	action := fmt.Sprintf("Deleting snapshot %q", name)
+	if err := manager.LockMetadata(manager.Paths, action); err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := manager.Unlock(ctx, manager.Paths, false)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+	// Read the snapshot again, as it may have changed before the lock was
+	// taken.
+	snapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return err
+	}
+	dependents, err := manager.dependents(snapshot)
+	if err != nil {
+		return err
+	}
+	if len(dependents) > 0 {
+		if _, err := manager.incrementalSnapshotter(); err != nil {
+			return err
+		}
+	}
+	for _, dependent := range dependents {
+		if err := manager.rebaseDisk(ctx, dependent, snapshot.Parent); err != nil {
+			return fmt.Errorf("failed to rebase snapshot %q: %w", dependent.Name, err)
+		}
+	}
+	return manager.deleteFiles(snapshot)
+}
+
+// Rebases the VM disk of snapshot onto the disk of the snapshot with ID
+// parentID, or makes it standalone if parentID is empty, and records the
+// change in its metadata. A copy of the disk is rebased, and only replaces
+// the disk once the metadata records it, so an interrupted rebase leaves
+// the snapshot as it was.
+func (manager *Manager) rebaseDisk(ctx context.Context, snapshot Snapshot, parentID string) error {
+	snapshotter, err := manager.incrementalSnapshotter()
+	if err != nil {
+		return err
+	}
+	backing := ""
+	if parentID != "" {
+		backing = backingDiskPath(parentID)
+	}
+	diskPath := filepath.Join(manager.SnapshotDirectory(snapshot), incrementalDiskName)
+	rebasePath := diskPath + ".rebase"
+	defer os.Remove(rebasePath)
+	if err := snapshotter.copyDisk(rebasePath, diskPath); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", incrementalDiskName, err)
+	}
+	if err := manager.DiskTool.Rebase(ctx, rebasePath, backing); err != nil {
+		return err
+	}
+	rebased := snapshot
+	rebased.Parent = parentID
+	rebased.Files = slices.Clone(snapshot.Files)
+	rebased.DiskSizes = maps.Clone(snapshot.DiskSizes)
+	if err := manager.recordDiskChange(&rebased, filepath.Base(rebasePath)); err != nil {
+		return err
+	}
+	if err := manager.replaceMetadataFile(rebased); err != nil {
+		return err
+	}
+	if err := os.Rename(rebasePath, diskPath); err != nil {
+		err = fmt.Errorf("failed to replace %s: %w", incrementalDiskName, err)
+		return errors.Join(err, manager.replaceMetadataFile(snapshot))
+	}
+	return nil
+}
+
+// Flatten makes an incremental snapshot standalone, by writing everything
+// its VM disk is backed by into the disk. Snapshots that depend on it are
+// unaffected, as the contents of its disk do not change. Snapshots that
+// are already standalone are left alone.
+func (manager *Manager) Flatten(ctx context.Context, name string) (Snapshot, error) {
+	snapshot, err := manager.Snapshot(name)
+	if err != nil || snapshot.Parent == "" {
+		return snapshot, err
+	}
+	if _, err := manager.incrementalSnapshotter(); err != nil {
+		return Snapshot{}, err
+	}
+	action := fmt.Sprintf("Flattening snapshot %q", name)
+	return manager.updateMetadata(ctx, name, action, func(snapshot *Snapshot) error {
+		if snapshot.Parent == "" {
+			return nil
+		}
+		if _, err := manager.chain(*snapshot); err != nil {
+			return err
+		}
+		diskPath := filepath.Join(manager.SnapshotDirectory(*snapshot), incrementalDiskName)
+		flatPath := diskPath + ".flatten"
+		defer os.Remove(flatPath)
+		if err := manager.DiskTool.Flatten(ctx, flatPath, diskPath); err != nil {
+			return fmt.Errorf("failed to flatten %s: %w", incrementalDiskName, err)
+		}
+		if err := os.Rename(flatPath, diskPath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", incrementalDiskName, err)
+		}
+		snapshot.Parent = ""
+		return manager.recordDiskChange(snapshot, incrementalDiskName)
+	})
+}
+
+// Records the new size and checksum of the VM disk of snapshot after it
+// was rewritten, reading it from the file diskName in the snapshot
+// directory.
+func (manager *Manager) recordDiskChange(snapshot *Snapshot, diskName string) error {
+	file, err := checksumFile(manager.SnapshotDirectory(*snapshot), diskName)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %q: %w", incrementalDiskName, err)
+	}
+	file.Name = incrementalDiskName
+	for i := range snapshot.Files {
+		if snapshot.Files[i].Name == incrementalDiskName {
+			snapshot.Files[i] = file
+		}
+	}
+	if _, ok := snapshot.DiskSizes[incrementalDiskName]; ok {
+		snapshot.DiskSizes[incrementalDiskName] = file.Size
+	}
+	return nil
+}
+
+// Returns an error wrapping ErrHasDependents naming the snapshots that
+// depend on snapshot, if there are any.
+func (manager *Manager) checkNoDependents(snapshot Snapshot) error {
+	dependents, err := manager.dependents(snapshot)
+	if err != nil || len(dependents) == 0 {
+		return err
+	}
+	names := make([]string, 0, len(dependents))
+	for _, dependent := range dependents {
+		names = append(names, fmt.Sprintf("%q", dependent.Name))
+	}
+	slices.Sort(names)
+	return fmt.Errorf("%w: %s", ErrHasDependents, strings.Join(names, ", "))
+}
@@ -0,0 +1,310 @@
+//go:build unix
+
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDiskTool stands in for qemu-img. Its overlays hold the relative path
+// of their backing file on the first line, followed by the full contents of
+// the disk, so that tests can follow the chain without real disk images.
+type fakeDiskTool struct{}
+
+const fakeBackingPrefix = "backing="
+
+func readFakeDisk(path string) (backing, contents string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	contents = string(data)
+	if strings.HasPrefix(contents, fakeBackingPrefix) {
+		backing, contents, _ = strings.Cut(strings.TrimPrefix(contents, fakeBackingPrefix), "\n")
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), backing)); err != nil {
+			return "", "", fmt.Errorf("broken backing file: %w", err)
+		}
+	}
+	return backing, contents, nil
+}
+
+func writeFakeDisk(path, backing, contents string) error {
+	if backing != "" {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), backing)); err != nil {
+			return fmt.Errorf("missing backing file: %w", err)
+		}
+		contents = fakeBackingPrefix + backing + "\n" + contents
+	}
+	return os.WriteFile(path, []byte(contents), 0o644)
+}
+
+func (fakeDiskTool) CreateOverlay(_ context.Context, dst, src, backing string) error {
+	_, contents, err := readFakeDisk(src)
+	if err != nil {
+		return err
+	}
+	return writeFakeDisk(dst, backing, contents)
+}
+
+func (fakeDiskTool) Flatten(_ context.Context, dst, src string) error {
+	_, contents, err := readFakeDisk(src)
+	if err != nil {
+		return err
+	}
+	return writeFakeDisk(dst, "", contents)
+}
+
+func (fakeDiskTool) Rebase(_ context.Context, path, backing string) error {
+	_, contents, err := readFakeDisk(path)
+	if err != nil {
+		return err
+	}
+	return writeFakeDisk(path, backing, contents)
+}
+
+// interruptedDiskTool rewrites part of the disk it rebases and then fails,
+// like a qemu-img that was interrupted.
+type interruptedDiskTool struct {
+	fakeDiskTool
+}
+
+func (interruptedDiskTool) Rebase(_ context.Context, path, _ string) error {
+	if err := os.WriteFile(path, []byte("partially rebased"), 0o644); err != nil {
+		return err
+	}
+	return errors.New("interrupted")
+}
+
+func newIncrementalTestManager(t *testing.T) (*Manager, string) {
+	appPaths, testFiles := populateFiles(t, true)
+	manager := newTestManager(appPaths)
+	manager.DiskTool = fakeDiskTool{}
+	return manager, testFiles["diffdisk"].Path
+}
+
+// Creates an incremental snapshot after writing contents to the working
+// VM disk.
+func createIncremental(t *testing.T, manager *Manager, diskPath, name, parent, contents string) Snapshot {
+	require.NoError(t, os.WriteFile(diskPath, []byte(contents), 0o644))
+	snapshot, err := manager.CreateIncremental(context.Background(), name, "", parent, nil)
+	require.NoError(t, err)
+	return snapshot
+}
+
+func snapshotDisk(t *testing.T, manager *Manager, snapshot Snapshot) (backing, contents string) {
+	backing, contents, err := readFakeDisk(filepath.Join(manager.SnapshotDirectory(snapshot), incrementalDiskName))
+	require.NoError(t, err)
+	return backing, contents
+}
+
+func TestCreateIncremental(t *testing.T) {
+	t.Run("Incremental snapshots should be overlays on the latest snapshot", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		base, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		base.Created = time.Now().Add(-time.Hour)
+		require.NoError(t, manager.writeMetadataFile(base))
+		latest, err := manager.Create(context.Background(), "latest", "")
+		require.NoError(t, err)
+
+		child := createIncremental(t, manager, diskPath, "child", "", "child contents")
+		assert.Equal(t, latest.ID, child.Parent)
+		backing, contents := snapshotDisk(t, manager, child)
+		assert.Equal(t, backingDiskPath(latest.ID), backing)
+		assert.Equal(t, "child contents", contents)
+		require.NoError(t, manager.Verify(context.Background(), child))
+
+		explicit := createIncremental(t, manager, diskPath, "explicit", "base", "explicit contents")
+		assert.Equal(t, base.ID, explicit.Parent)
+	})
+
+	t.Run("Incremental snapshots need a parent", func(t *testing.T) {
+		manager, _ := newIncrementalTestManager(t)
+		_, err := manager.CreateIncremental(context.Background(), "child", "", "", nil)
+		assert.ErrorContains(t, err, "no snapshot to base an incremental snapshot on")
+		_, err = manager.CreateIncremental(context.Background(), "child", "", "missing", nil)
+		assert.ErrorContains(t, err, `can't find snapshot "missing"`)
+	})
+
+	t.Run("Restoring an incremental snapshot should flatten its disk", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		_, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		createIncremental(t, manager, diskPath, "child", "", "child contents")
+		require.NoError(t, os.WriteFile(diskPath, []byte("later contents"), 0o644))
+
+		require.NoError(t, manager.Restore(context.Background(), "child", false))
+		contents, err := os.ReadFile(diskPath)
+		require.NoError(t, err)
+		assert.Equal(t, "child contents", string(contents))
+	})
+
+	t.Run("Incremental snapshots should not be exported", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		_, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		createIncremental(t, manager, diskPath, "child", "", "child contents")
+		err = manager.Export(context.Background(), "child", filepath.Join(t.TempDir(), "child.tar.gz"))
+		assert.ErrorContains(t, err, "must be flattened")
+	})
+}
+
+func TestDeleteWithDependents(t *testing.T) {
+	t.Run("Delete should refuse to delete a parent", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		_, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		createIncremental(t, manager, diskPath, "child", "", "child contents")
+
+		assert.ErrorIs(t, manager.Delete(context.Background(), "base"), ErrHasDependents)
+		_, err = manager.Snapshot("base")
+		assert.NoError(t, err)
+		assert.NoError(t, manager.Delete(context.Background(), "child"))
+		assert.NoError(t, manager.Delete(context.Background(), "base"))
+	})
+
+	t.Run("DeleteRebasing should rebase dependents onto the grandparent", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		base, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		createIncremental(t, manager, diskPath, "middle", "base", "middle contents")
+		createIncremental(t, manager, diskPath, "top", "middle", "top contents")
+
+		require.NoError(t, manager.DeleteRebasing(context.Background(), "middle"))
+		_, err = manager.Snapshot("middle")
+		assert.Error(t, err)
+		top, err := manager.Snapshot("top")
+		require.NoError(t, err)
+		assert.Equal(t, base.ID, top.Parent)
+		backing, contents := snapshotDisk(t, manager, top)
+		assert.Equal(t, backingDiskPath(base.ID), backing)
+		assert.Equal(t, "top contents", contents)
+		assert.NoError(t, manager.Verify(context.Background(), top))
+	})
+
+	t.Run("DeleteRebasing should make dependents of a full snapshot standalone", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		_, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		createIncremental(t, manager, diskPath, "child", "", "child contents")
+
+		require.NoError(t, manager.DeleteRebasing(context.Background(), "base"))
+		child, err := manager.Snapshot("child")
+		require.NoError(t, err)
+		assert.Empty(t, child.Parent)
+		backing, _ := snapshotDisk(t, manager, child)
+		assert.Empty(t, backing)
+		assert.NoError(t, manager.Verify(context.Background(), child))
+	})
+
+	t.Run("DeleteRebasing should leave dependents usable if rebasing is interrupted", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		base, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		child := createIncremental(t, manager, diskPath, "child", "", "child contents")
+
+		manager.DiskTool = interruptedDiskTool{}
+		assert.Error(t, manager.DeleteRebasing(context.Background(), "base"))
+		_, err = manager.Snapshot("base")
+		assert.NoError(t, err)
+		unchanged, err := manager.Snapshot("child")
+		require.NoError(t, err)
+		assert.Equal(t, child.Parent, unchanged.Parent)
+		assert.Equal(t, child.Files, unchanged.Files)
+		backing, contents := snapshotDisk(t, manager, unchanged)
+		assert.Equal(t, backingDiskPath(base.ID), backing)
+		assert.Equal(t, "child contents", contents)
+		assert.NoError(t, manager.Verify(context.Background(), unchanged))
+	})
+
+	t.Run("Prune should keep parents", func(t *testing.T) {
+		manager, diskPath := newIncrementalTestManager(t)
+		base, err := manager.Create(context.Background(), "base", "")
+		require.NoError(t, err)
+		base.Created = time.Now().Add(-time.Hour)
+		require.NoError(t, manager.writeMetadataFile(base))
+		createIncremental(t, manager, diskPath, "child", "base", "child contents")
+
+		result, err := manager.Prune(context.Background(), RetentionPolicy{KeepLast: 1}, false)
+		require.NoError(t, err)
+		assert.Empty(t, result.Snapshots)
+	})
+}
+
+func TestFlatten(t *testing.T) {
+	manager, diskPath := newIncrementalTestManager(t)
+	_, err := manager.Create(context.Background(), "base", "")
+	require.NoError(t, err)
+	child := createIncremental(t, manager, diskPath, "child", "", "child contents")
+	grandchild := createIncremental(t, manager, diskPath, "grandchild", "child", "grandchild contents")
+
+	flattened, err := manager.Flatten(context.Background(), "child")
+	require.NoError(t, err)
+	assert.Empty(t, flattened.Parent)
+	backing, contents := snapshotDisk(t, manager, flattened)
+	assert.Empty(t, backing)
+	assert.Equal(t, "child contents", contents)
+	assert.NoError(t, manager.Verify(context.Background(), flattened))
+	assert.NotEqual(t, child.Files, flattened.Files, "the new checksum of the disk should be recorded")
+
+	// The base is no longer needed by anything.
+	require.NoError(t, manager.Delete(context.Background(), "base"))
+	_, contents = snapshotDisk(t, manager, grandchild)
+	assert.Equal(t, "grandchild contents", contents)
+
+	unchanged, err := manager.Flatten(context.Background(), "child")
+	require.NoError(t, err)
+	assert.Equal(t, flattened.Files, unchanged.Files)
+}
+
+// Returns a QemuImg that runs a script in place of qemu-img. `qemu-img info`
+// reports the given chain of formats, nearest first, and the arguments of
+// every other command are written to the returned log.
+func newFakeQemuImg(t *testing.T, formats ...string) (QemuImg, string) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "qemu-img.log")
+	var images []string
+	for i, format := range formats {
+		images = append(images, fmt.Sprintf(`{"filename": "disk%d", "format": %q}`, i, format))
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = info ]; then
+  echo '[%s]'
+  exit 0
+fi
+echo "$@" >> %q
+`, strings.Join(images, ", "), logPath)
+	scriptPath := filepath.Join(dir, "qemu-img")
+	require.NoError(t, os.WriteFile(scriptPath, []byte(script), 0o755))
+	return QemuImg{path: scriptPath}, logPath
+}
+
+func TestQemuImgFlatten(t *testing.T) {
+	testCases := []struct {
+		name     string
+		formats  []string
+		expected string
+	}{
+		{name: "raw base disk (VZ)", formats: []string{"qcow2", "qcow2", "raw"}, expected: "convert -O raw src dst"},
+		{name: "qcow2 base disk (QEMU)", formats: []string{"qcow2", "qcow2"}, expected: "convert -O qcow2 src dst"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			qemuImg, logPath := newFakeQemuImg(t, testCase.formats...)
+			require.NoError(t, qemuImg.Flatten(context.Background(), "dst", "src"))
+			log, err := os.ReadFile(logPath)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, strings.TrimSpace(string(log)))
+		})
+	}
+}
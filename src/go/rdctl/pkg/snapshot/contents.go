@@ -0,0 +1,241 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// The name of the file in a snapshot directory that records what the
+// snapshot contains, so that restoring it puts back exactly that.
+const contentsFileName = "contents.json"
+
+// Optional components that can be included in a snapshot in addition to
+// the VM and settings, which are always included.
+const (
+	ComponentExtensions = "extensions"
+	ComponentShims      = "shims"
+)
+
+// OptionalComponents lists the components that can be passed to
+// Manager.Create.
+var OptionalComponents = []string{ComponentExtensions, ComponentShims}
+
+// snapshotContents is written to contents.json in each snapshot directory.
+// Snapshots created before it was introduced have no contents.json, and
+// contain only the files that are always included.
+type snapshotContents struct {
+	// The optional components included in the snapshot.
+	Components []string `json:"components"`
+	// The files and directories in the snapshot directory that hold the
+	// snapshot's state.
+	Entries []contentsEntry `json:"entries"`
+}
+
+type contentsEntry struct {
+	// The name of the file or directory, relative to the snapshot directory.
+	Name      string `json:"name"`
+	Directory bool   `json:"directory,omitempty"`
+	// The optional component the entry belongs to, if any.
+	Component string `json:"component,omitempty"`
+}
+
+// A directory that is included in a snapshot as part of an optional
+// component.
+type componentDirectory struct {
+	Component string
+	// The name of the directory in a snapshot directory.
+	Name string
+	// The path that Rancher Desktop uses.
+	WorkingPath string
+}
+
+// ValidateComponents checks that each of components can be included in
+// a snapshot.
+func ValidateComponents(components []string) error {
+	for _, component := range components {
+		if !slices.Contains(OptionalComponents, component) {
+			return fmt.Errorf("invalid component %q: must be one of %s", component, strings.Join(OptionalComponents, ", "))
+		}
+	}
+	return nil
+}
+
+// Returns the directories that the optional components are made up of.
+func componentDirectories(appPaths *paths.Paths) []componentDirectory {
+	return []componentDirectory{
+		{Component: ComponentExtensions, Name: "extensions", WorkingPath: appPaths.ExtensionRoot},
+		{Component: ComponentShims, Name: "containerd-shims", WorkingPath: appPaths.ContainerdShims},
+	}
+}
+
+// Returns the directories of the given optional components.
+func selectedComponentDirectories(appPaths *paths.Paths, components []string) []componentDirectory {
+	result := []componentDirectory{}
+	for _, dir := range componentDirectories(appPaths) {
+		if slices.Contains(components, dir.Component) {
+			result = append(result, dir)
+		}
+	}
+	return result
+}
+
+// Returns the entries of files that are either always included, or that
+// belong to one of components.
+func selectContentFiles(files []contentFile, components []string) []contentFile {
+	result := make([]contentFile, 0, len(files))
+	for _, file := range files {
+		if file.Component == "" || slices.Contains(components, file.Component) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// Writes contents.json for a snapshot that includes components, listing the
+// entries that are present in snapshotDir.
+func writeContentsFile(appPaths *paths.Paths, snapshotDir string, components []string) error {
+	contents := snapshotContents{Components: components, Entries: []contentsEntry{}}
+	if contents.Components == nil {
+		contents.Components = []string{}
+	}
+	for _, file := range selectContentFiles(contentFiles(appPaths), components) {
+		if _, err := os.Lstat(filepath.Join(snapshotDir, file.Name)); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to stat %q: %w", file.Name, err)
+		}
+		contents.Entries = append(contents.Entries, contentsEntry{
+			Name:      file.Name,
+			Directory: file.Dir,
+			Component: file.Component,
+		})
+	}
+	contentsBytes, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", contentsFileName, err)
+	}
+	if err := os.WriteFile(filepath.Join(snapshotDir, contentsFileName), contentsBytes, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", contentsFileName, err)
+	}
+	return nil
+}
+
+// Reads contents.json from snapshotDir, and checks that this version knows
+// how to restore everything it lists.
+func readContentsFile(appPaths *paths.Paths, snapshotDir string) (snapshotContents, error) {
+	contents := snapshotContents{}
+	contentsBytes, err := os.ReadFile(filepath.Join(snapshotDir, contentsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return contents, nil
+	} else if err != nil {
+		return contents, fmt.Errorf("failed to read %s: %w", contentsFileName, err)
+	}
+	if err := json.Unmarshal(contentsBytes, &contents); err != nil {
+		return contents, fmt.Errorf("failed to parse %s: %w", contentsFileName, err)
+	}
+	if err := ValidateComponents(contents.Components); err != nil {
+		return contents, fmt.Errorf("snapshot can't be restored by this version: %w", err)
+	}
+	known := map[string]bool{}
+	for _, file := range selectContentFiles(contentFiles(appPaths), contents.Components) {
+		known[file.Name] = true
+	}
+	for _, entry := range contents.Entries {
+		if !known[entry.Name] {
+			return contents, fmt.Errorf("snapshot contains %q, which this version can't restore", entry.Name)
+		}
+	}
+	return contents, nil
+}
+
+// Expands the directory entries in files to the regular files and symbolic
+// links under them in snapshotDir, named by their slash-separated path
+// relative to snapshotDir. Directories that are allowed to be missing and are not
+// present are skipped; other entries are returned as they are.
+func expandContentFiles(snapshotDir string, files []contentFile) ([]contentFile, error) {
+	result := make([]contentFile, 0, len(files))
+	for _, file := range files {
+		if !file.Dir {
+			result = append(result, file)
+			continue
+		}
+		root := filepath.Join(snapshotDir, file.Name)
+		err := filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) && path == root && file.MissingOk {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			linkTarget := ""
+			if dirEntry.Type()&fs.ModeSymlink != 0 {
+				target, err := os.Readlink(path)
+				if err != nil {
+					return err
+				}
+				linkTarget = filepath.ToSlash(target)
+			} else if !dirEntry.Type().IsRegular() {
+				return nil
+			}
+			info, err := dirEntry.Info()
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(snapshotDir, path)
+			if err != nil {
+				return err
+			}
+			result = append(result, contentFile{
+				Name:       filepath.ToSlash(relPath),
+				FileMode:   info.Mode().Perm(),
+				Component:  file.Component,
+				LinkTarget: linkTarget,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %q: %w", file.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// Copies the tree at src to dst, which must not exist yet, using copyFn for
+// each regular file. Symbolic links are recreated; other special files are
+// skipped.
+func copyTree(dst, src string, copyFn func(dst, src string, fileMode os.FileMode) error) error {
+	return filepath.WalkDir(src, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, relPath)
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(dstPath, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dstPath)
+		case info.Mode().IsRegular():
+			return copyFn(dstPath, path, info.Mode().Perm())
+		}
+		return nil
+	})
+}
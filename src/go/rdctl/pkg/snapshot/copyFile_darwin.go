@@ -14,8 +14,9 @@ import (
 // use clonefile syscall to do the copy. If clonefile is not supported
 // by the underlying filesystem, or src and dst are on different
 // drives, falls back to a plain copy. If copyOnWrite is false, does a
-// plain copy.
-func copyFile(dst, src string, copyOnWrite bool, fileMode os.FileMode) error {
+// plain copy. Progress is reported to report as the file is copied,
+// under name, the path of the file relative to the snapshot directory.
+func copyFile(dst, src, name string, copyOnWrite bool, fileMode os.FileMode, report ProgressFunc) error {
 	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 		return fmt.Errorf("failed to create destination parent dir: %w", err)
 	}
@@ -24,6 +25,9 @@ func copyFile(dst, src string, copyOnWrite bool, fileMode os.FileMode) error {
 			return fmt.Errorf("failed to remove existing destination file: %w", err)
 		}
 		if err := unix.Clonefile(src, dst, 0); err == nil {
+			if info, err := os.Stat(dst); err == nil {
+				report(Progress{File: name, Copied: info.Size(), Total: info.Size()})
+			}
 			return nil
 		} else if !errors.Is(err, unix.ENOTSUP) && !errors.Is(err, unix.EXDEV) {
 			return fmt.Errorf("failed to clone src to dest: %w", err)
@@ -39,8 +43,25 @@ func copyFile(dst, src string, copyOnWrite bool, fileMode os.FileMode) error {
 		return fmt.Errorf("failed to open destination file: %w", err)
 	}
 	defer dstFd.Close()
-	if _, err := io.Copy(dstFd, srcFd); err != nil {
-		return fmt.Errorf("failed to copy contents of src to dst: %w", err)
+	info, err := srcFd.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+	progress := Progress{File: name, Total: info.Size()}
+	report(progress)
+	for {
+		// Copying straight into the file lets it use the fastest copy
+		// available; the limit lets progress be reported in between.
+		n, err := dstFd.ReadFrom(io.LimitReader(srcFd, copyChunkSize))
+		if err != nil {
+			return fmt.Errorf("failed to copy contents of src to dst: %w", err)
+		}
+		if n > 0 {
+			progress.Copied += n
+			report(progress)
+		}
+		if n < copyChunkSize {
+			return nil
+		}
 	}
-	return nil
 }
@@ -13,9 +13,12 @@ import (
 // Copies a file from src to dst. If copyOnWrite is true, attempts to
 // use ioctl FICLONE to do the copy. If ioctl FICLONE is not supported
 // by the underlying filesystem, falls back to a plain copy. If
-// copyOnWrite is false, does a plain copy. fileMode specifies the
-// permissions that are applied to the destination file.
-func copyFile(dst, src string, copyOnWrite bool, fileMode os.FileMode) error {
+// copyOnWrite is false, does a plain copy, which the kernel does
+// without reading the file into rdctl where it can. fileMode specifies
+// the permissions that are applied to the destination file. Progress is
+// reported to report as the file is copied, under name, the path of
+// the file relative to the snapshot directory.
+func copyFile(dst, src, name string, copyOnWrite bool, fileMode os.FileMode, report ProgressFunc) error {
 	srcFd, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("failed to open source file: %w", err)
@@ -29,15 +32,37 @@ func copyFile(dst, src string, copyOnWrite bool, fileMode os.FileMode) error {
 		return fmt.Errorf("failed to open destination file: %w", err)
 	}
 	defer dstFd.Close()
+	info, err := srcFd.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
 	if copyOnWrite {
 		if err := unix.IoctlFileClone(int(dstFd.Fd()), int(srcFd.Fd())); err == nil {
+			report(Progress{File: name, Copied: info.Size(), Total: info.Size()})
 			return nil
 		} else if !errors.Is(err, unix.ENOTSUP) {
 			return fmt.Errorf("failed to ioctl_ficlone file: %w", err)
 		}
 	}
-	if _, err := io.Copy(dstFd, srcFd); err != nil {
-		return fmt.Errorf("failed to copy contents of src to dst: %w", err)
+	progress := Progress{File: name, Total: info.Size()}
+	report(progress)
+	for {
+		n, err := unix.CopyFileRange(int(srcFd.Fd()), nil, int(dstFd.Fd()), nil, copyChunkSize, 0)
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOTSUP) {
+			// The kernel can't copy between these files; copy the rest by
+			// reading it, from the offsets the kernel has advanced to.
+			writer := &progressWriter{Writer: dstFd, progress: progress, report: report}
+			if _, err := io.Copy(writer, srcFd); err != nil {
+				return fmt.Errorf("failed to copy contents of src to dst: %w", err)
+			}
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to copy contents of src to dst: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		progress.Copied += int64(n)
+		report(progress)
 	}
-	return nil
 }
@@ -0,0 +1,109 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
+)
+
+// The format of a configuration file that can be compared with Diff.
+type documentFormat int
+
+const (
+	formatJSON documentFormat = iota
+	formatYAML
+)
+
+// A configuration file that is compared by Diff.
+type diffFile struct {
+	// The name of the file in the snapshot directory.
+	Name string
+	// The path that Rancher Desktop uses.
+	WorkingPath string
+	Format      documentFormat
+}
+
+// FileDiff holds the changes that restoring a snapshot would make to one
+// configuration file.
+type FileDiff struct {
+	// The name of the file, such as "settings.json".
+	File string `json:"file"`
+	// The path of the working copy of the file.
+	WorkingPath string `json:"workingPath"`
+	// The changes that restoring the snapshot would make: Old is the working
+	// value, and New the value in the snapshot.
+	Changes []settingsdiff.Change `json:"changes"`
+}
+
+// Diff compares the configuration files in a snapshot with their working
+// copies. A file that is missing on one side is treated as empty.
+func (manager *Manager) Diff(snapshot Snapshot) ([]FileDiff, error) {
+	snapshotDir := manager.SnapshotDirectory(snapshot)
+	result := []FileDiff{}
+	for _, file := range diffFiles(manager.Paths) {
+		working, err := readDocument(file.WorkingPath, file.Format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read working %s: %w", file.Name, err)
+		}
+		snapshotted, err := readDocument(filepath.Join(snapshotDir, file.Name), file.Format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from snapshot: %w", file.Name, err)
+		}
+		result = append(result, FileDiff{
+			File:        file.Name,
+			WorkingPath: file.WorkingPath,
+			Changes:     settingsdiff.DiffDocuments(working, snapshotted),
+		})
+	}
+	return result, nil
+}
+
+// Reads a JSON or YAML document; a missing file is returned as nil.
+func readDocument(path string, format documentFormat) (any, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var document any
+	switch format {
+	case formatJSON:
+		err = json.Unmarshal(contents, &document)
+	case formatYAML:
+		err = yaml.Unmarshal(contents, &document)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return document, nil
+}
+
+// RestorePlan describes what restoring a snapshot would do.
+type RestorePlan struct {
+	// The changes to configuration files.
+	Diffs []FileDiff `json:"diffs"`
+	// The working paths that would be replaced (or removed, for files
+	// that are not in the snapshot).
+	Replaced []string `json:"replaced"`
+}
+
+// PlanRestore describes what restoring a snapshot would change, without
+// changing anything.
+func (manager *Manager) PlanRestore(snapshot Snapshot) (RestorePlan, error) {
+	diffs, err := manager.Diff(snapshot)
+	if err != nil {
+		return RestorePlan{}, err
+	}
+	contents, err := readContentsFile(manager.Paths, manager.SnapshotDirectory(snapshot))
+	if err != nil {
+		return RestorePlan{}, err
+	}
+	return RestorePlan{Diffs: diffs, Replaced: restoredPaths(manager.Paths, contents.Components)}, nil
+}
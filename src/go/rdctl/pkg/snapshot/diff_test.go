@@ -0,0 +1,39 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsdiff"
+)
+
+func TestDiff(t *testing.T) {
+	appPaths, testFiles := populateFiles(t, true)
+	manager := newTestManager(appPaths)
+	snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+	require.NoError(t, err)
+
+	diffs, err := manager.Diff(snapshot)
+	require.NoError(t, err)
+	for _, diff := range diffs {
+		assert.Empty(t, diff.Changes, "unchanged %s", diff.File)
+	}
+
+	settingsPath := testFiles["settings.json"].Path
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"test": "changed"}`), 0o644))
+	diffs, err = manager.Diff(snapshot)
+	require.NoError(t, err)
+	require.NotEmpty(t, diffs)
+	assert.Equal(t, "settings.json", diffs[0].File)
+	assert.Equal(t, []settingsdiff.Change{
+		{Path: []string{"test"}, Op: settingsdiff.ChangeReplace, Old: "changed", New: "settings.json"},
+	}, diffs[0].Changes)
+
+	plan, err := manager.PlanRestore(snapshot)
+	require.NoError(t, err)
+	assert.Contains(t, plan.Replaced, settingsPath)
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+)
+
+// DiskTool manipulates the qcow2 disk images that make up the backing
+// chains of incremental snapshots. Backing files are given relative to the
+// directory of the image they back, so that the chain stays valid if the
+// snapshots directory is moved.
+type DiskTool interface {
+	// CreateOverlay writes the contents of the disk image src to dst as a
+	// qcow2 image backed by backing, storing only what differs from it.
+	CreateOverlay(ctx context.Context, dst, src, backing string) error
+	// Flatten writes the contents of the disk image src, including
+	// everything in its backing chain, to dst as a standalone image in the
+	// format of the image at the root of the chain, which is the format of
+	// the VM disk the chain was made from (raw for the VZ driver).
+	Flatten(ctx context.Context, dst, src string) error
+	// Rebase changes the backing file of the qcow2 image at path to
+	// backing without changing its contents, copying in whatever the new
+	// backing chain lacks. If backing is empty, the image is made
+	// standalone.
+	Rebase(ctx context.Context, path, backing string) error
+}
+
+// QemuImg is a DiskTool that runs the qemu-img shipped with Rancher Desktop.
+type QemuImg struct {
+	// The qemu-img to run instead of the shipped one, for tests.
+	path string
+}
+
+func (qemuImg QemuImg) CreateOverlay(ctx context.Context, dst, src, backing string) error {
+	backingPath := filepath.Join(filepath.Dir(dst), backing)
+	backingFormat, err := qemuImg.format(ctx, backingPath)
+	if err != nil {
+		return err
+	}
+	// qemu-img convert records the backing file as given; write it with
+	// the absolute path, and then switch to the relative one, which is
+	// only valid from the directory of dst.
+	if err := qemuImg.run(ctx, "convert", "-O", "qcow2", "-B", backingPath, "-F", backingFormat, src, dst); err != nil {
+		return err
+	}
+	return qemuImg.run(ctx, "rebase", "-u", "-b", backing, "-F", backingFormat, dst)
+}
+
+func (qemuImg QemuImg) Flatten(ctx context.Context, dst, src string) error {
+	format, err := qemuImg.rootFormat(ctx, src)
+	if err != nil {
+		return err
+	}
+	return qemuImg.run(ctx, "convert", "-O", format, src, dst)
+}
+
+func (qemuImg QemuImg) Rebase(ctx context.Context, path, backing string) error {
+	if backing == "" {
+		return qemuImg.run(ctx, "rebase", "-b", "", path)
+	}
+	backingFormat, err := qemuImg.format(ctx, filepath.Join(filepath.Dir(path), backing))
+	if err != nil {
+		return err
+	}
+	return qemuImg.run(ctx, "rebase", "-b", backing, "-F", backingFormat, path)
+}
+
+// The part of the output of `qemu-img info --output=json` that is used.
+type qemuImgInfo struct {
+	Format string `json:"format"`
+}
+
+// Returns the format of the disk image at path, such as "qcow2" or "raw".
+func (qemuImg QemuImg) format(ctx context.Context, path string) (string, error) {
+	var info qemuImgInfo
+	if err := qemuImg.info(ctx, &info, path); err != nil {
+		return "", err
+	}
+	return info.Format, nil
+}
+
+// Returns the format of the disk image at the root of the backing chain of
+// the image at path.
+func (qemuImg QemuImg) rootFormat(ctx context.Context, path string) (string, error) {
+	var chain []qemuImgInfo
+	if err := qemuImg.info(ctx, &chain, "--backing-chain", path); err != nil {
+		return "", err
+	}
+	if len(chain) == 0 {
+		return "", fmt.Errorf("qemu-img info reported no images for %q", path)
+	}
+	return chain[len(chain)-1].Format, nil
+}
+
+// Runs `qemu-img info` with args, ending with the path of the image, and
+// decodes its output into result.
+func (qemuImg QemuImg) info(ctx context.Context, result any, args ...string) error {
+	path := args[len(args)-1]
+	cmd, err := qemuImg.command(ctx, append([]string{"info", "--output=json", "--force-share"}, args...)...)
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to get format of %q: %w", path, err)
+	}
+	if err := json.Unmarshal(output, result); err != nil {
+		return fmt.Errorf("failed to parse qemu-img info output: %w", err)
+	}
+	return nil
+}
+
+func (qemuImg QemuImg) run(ctx context.Context, args ...string) error {
+	cmd, err := qemuImg.command(ctx, args...)
+	if err != nil {
+		return err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("qemu-img %s failed: %w: %s", args[0], err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// qemu-img is installed alongside limactl, and needs the libraries that
+// are installed with it.
+func (qemuImg QemuImg) command(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	if qemuImg.path != "" {
+		return exec.CommandContext(ctx, qemuImg.path, args...), nil
+	}
+	limactlPath, err := directories.GetLimactlPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find qemu-img: %w", err)
+	}
+	limaDir := filepath.Dir(filepath.Dir(limactlPath))
+	cmd := exec.CommandContext(ctx, filepath.Join(limaDir, "bin", "qemu-img"), args...)
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+filepath.Join(limaDir, "lib"))
+	return cmd, nil
+}
@@ -0,0 +1,113 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// MetadataChanges describes the changes Edit makes to a snapshot.
+type MetadataChanges struct {
+	// The new description, if not nil.
+	Description *string
+	// Labels to add, or to change the values of.
+	SetLabels map[string]string
+	// Keys of labels to remove.
+	RemoveLabels []string
+}
+
+// Rename gives the snapshot with the given name a new name, which must not
+// be in use by another snapshot.
+func (manager *Manager) Rename(ctx context.Context, name, newName string) (Snapshot, error) {
+	// Report on invalid names before taking the lock.
+	if err := manager.ValidateName(newName); err != nil {
+		return Snapshot{}, err
+	}
+	action := fmt.Sprintf("Renaming snapshot %q", name)
+	return manager.updateMetadata(ctx, name, action, func(snapshot *Snapshot) error {
+		// Revalidate under the lock, in case another process took the name.
+		if err := manager.ValidateName(newName); err != nil {
+			return err
+		}
+		snapshot.Name = newName
+		return nil
+	})
+}
+
+// Edit changes the description and labels of the snapshot with the given
+// name.
+func (manager *Manager) Edit(ctx context.Context, name string, changes MetadataChanges) (Snapshot, error) {
+	for key, value := range changes.SetLabels {
+		if err := ValidateLabel(key, value); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	action := fmt.Sprintf("Editing snapshot %q", name)
+	return manager.updateMetadata(ctx, name, action, func(snapshot *Snapshot) error {
+		if changes.Description != nil {
+			snapshot.Description = *changes.Description
+		}
+		for _, key := range changes.RemoveLabels {
+			delete(snapshot.Labels, key)
+		}
+		for key, value := range changes.SetLabels {
+			if snapshot.Labels == nil {
+				snapshot.Labels = map[string]string{}
+			}
+			snapshot.Labels[key] = value
+		}
+		if len(snapshot.Labels) == 0 {
+			snapshot.Labels = nil
+		}
+		return nil
+	})
+}
+
+// Applies update to the metadata of the snapshot with the given name while
+// holding the backend lock, so that other snapshot operations can't see
+// the snapshot change under them. The backend keeps running.
+func (manager *Manager) updateMetadata(ctx context.Context, name, action string, update func(*Snapshot) error) (_ Snapshot, err error) {
+	if _, err := manager.Snapshot(name); err != nil {
+		return Snapshot{}, err
+	}
+	if err := manager.LockMetadata(manager.Paths, action); err != nil {
+		return Snapshot{}, err
+	}
+	defer func() {
+		unlockErr := manager.Unlock(ctx, manager.Paths, false)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+	// Read the snapshot again, as it may have changed before the lock was
+	// taken.
+	snapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := update(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if err := manager.replaceMetadataFile(snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// Replaces the metadata file of a complete snapshot, such that it is never
+// seen partially written.
+func (manager *Manager) replaceMetadataFile(snapshot Snapshot) error {
+	contents := &bytes.Buffer{}
+	encoder := json.NewEncoder(contents)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	metadataPath := filepath.Join(manager.SnapshotDirectory(snapshot), metadataFileName)
+	if err := writeFileAtomically(metadataPath, contents); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	return nil
+}
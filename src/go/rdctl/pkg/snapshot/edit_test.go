@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	t.Run("Rename should change only the name", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		original, err := manager.Create(context.Background(), "old", "a description")
+		require.NoError(t, err)
+
+		renamed, err := manager.Rename(context.Background(), "old", "new")
+		require.NoError(t, err)
+		assert.Equal(t, "new", renamed.Name)
+		_, err = manager.Snapshot("old")
+		assert.Error(t, err)
+		listed, err := manager.Snapshot("new")
+		require.NoError(t, err)
+		assert.Equal(t, original.ID, listed.ID)
+		assert.Equal(t, original.Description, listed.Description)
+		assert.Equal(t, original.Files, listed.Files)
+		require.NoError(t, manager.Verify(context.Background(), listed))
+	})
+
+	t.Run("Rename should refuse names that are in use or invalid", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		for _, name := range []string{"first", "second"} {
+			_, err := manager.Create(context.Background(), name, "")
+			require.NoError(t, err)
+		}
+		_, err := manager.Rename(context.Background(), "first", "second")
+		assert.ErrorContains(t, err, "already exists")
+		_, err = manager.Rename(context.Background(), "first", " leading space")
+		assert.ErrorContains(t, err, "white-space")
+		_, err = manager.Rename(context.Background(), "missing", "third")
+		assert.ErrorContains(t, err, `can't find snapshot "missing"`)
+	})
+}
+
+func TestEdit(t *testing.T) {
+	appPaths, _ := populateFiles(t, true)
+	manager := newTestManager(appPaths)
+	_, err := manager.CreateWithLabels(context.Background(), "env", "old description", map[string]string{"team": "qa", "tier": "gold"})
+	require.NoError(t, err)
+
+	description := "new description"
+	edited, err := manager.Edit(context.Background(), "env", MetadataChanges{
+		Description:  &description,
+		SetLabels:    map[string]string{"team": "dev", "owner": "someone"},
+		RemoveLabels: []string{"tier"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, description, edited.Description)
+	assert.Equal(t, map[string]string{"team": "dev", "owner": "someone"}, edited.Labels)
+
+	listed, err := manager.Snapshot("env")
+	require.NoError(t, err)
+	assert.Equal(t, description, listed.Description)
+	assert.Equal(t, edited.Labels, listed.Labels)
+	entries, err := os.ReadDir(manager.SnapshotDirectory(listed))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".rdctl-snapshot-", "temporary files should be cleaned up")
+	}
+	assert.FileExists(t, filepath.Join(manager.SnapshotDirectory(listed), metadataFileName))
+
+	edited, err = manager.Edit(context.Background(), "env", MetadataChanges{RemoveLabels: []string{"team", "owner"}})
+	require.NoError(t, err)
+	assert.Nil(t, edited.Labels)
+	assert.Equal(t, description, edited.Description, "description should be kept when not given")
+
+	_, err = manager.Edit(context.Background(), "env", MetadataChanges{SetLabels: map[string]string{"bad key": "x"}})
+	assert.ErrorContains(t, err, "invalid label key")
+}
@@ -0,0 +1,11 @@
+package snapshot
+
+// Snapshot disks are cloned with clonefile, so they may share data with
+// other snapshots and the working files.
+const copiesShareExtents = true
+
+// APFS does not expose which clones share data, so the exclusive size of a
+// snapshot can't be determined.
+func fileExtents(path string) ([]extent, error) {
+	return nil, errExtentsUnsupported
+}
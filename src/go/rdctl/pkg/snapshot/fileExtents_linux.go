@@ -0,0 +1,102 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Snapshot disks are cloned with ioctl_ficlone where the filesystem supports
+// it, so they may share data with other snapshots and the working files.
+const copiesShareExtents = true
+
+// Definitions from linux/fiemap.h and linux/fs.h, which golang.org/x/sys
+// does not provide.
+const (
+	fsIocFiemap = 0xC020660B // _IOWR('f', 11, struct fiemap)
+
+	fiemapFlagSync  = 0x1
+	fiemapMaxOffset = ^uint64(0)
+
+	fiemapExtentLast       = 0x1
+	fiemapExtentUnknown    = 0x2
+	fiemapExtentDelalloc   = 0x4
+	fiemapExtentEncoded    = 0x8
+	fiemapExtentNotAligned = 0x100
+	fiemapExtentDataInline = 0x200
+	fiemapExtentDataTail   = 0x400
+
+	// The number of extents requested per ioctl call.
+	fiemapBatchSize = 256
+)
+
+type fiemapExtent struct {
+	Logical    uint64
+	Physical   uint64
+	Length     uint64
+	Reserved64 [2]uint64
+	Flags      uint32
+	Reserved   [3]uint32
+}
+
+type fiemapRequest struct {
+	Start         uint64
+	Length        uint64
+	Flags         uint32
+	MappedExtents uint32
+	ExtentCount   uint32
+	Reserved      uint32
+	Extents       [fiemapBatchSize]fiemapExtent
+}
+
+// Returns the physical extents of a file, using the FIEMAP ioctl.
+func fileExtents(path string) ([]extent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var stat unix.Stat_t
+	if err := unix.Fstat(int(file.Fd()), &stat); err != nil {
+		return nil, err
+	}
+	unknownFlags := uint32(fiemapExtentUnknown | fiemapExtentDelalloc | fiemapExtentEncoded |
+		fiemapExtentNotAligned | fiemapExtentDataInline | fiemapExtentDataTail)
+
+	result := []extent{}
+	request := &fiemapRequest{}
+	var start uint64
+	for {
+		*request = fiemapRequest{
+			Start:       start,
+			Length:      fiemapMaxOffset - start,
+			Flags:       fiemapFlagSync,
+			ExtentCount: fiemapBatchSize,
+		}
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, file.Fd(), fsIocFiemap, uintptr(unsafe.Pointer(request)))
+		if errno != 0 {
+			if errors.Is(errno, unix.EOPNOTSUPP) || errors.Is(errno, unix.ENOTTY) {
+				return nil, errExtentsUnsupported
+			}
+			return nil, errno
+		}
+		if request.MappedExtents == 0 {
+			return result, nil
+		}
+		for _, fileExtent := range request.Extents[:request.MappedExtents] {
+			result = append(result, extent{
+				Device:   uint64(stat.Dev), //nolint:unconvert // Dev is not uint64 on every architecture
+				Physical: int64(fileExtent.Physical),
+				Length:   int64(fileExtent.Length),
+				Known:    fileExtent.Flags&unknownFlags == 0,
+			})
+			if fileExtent.Flags&fiemapExtentLast != 0 {
+				return result, nil
+			}
+		}
+		last := request.Extents[request.MappedExtents-1]
+		start = last.Logical + last.Length
+	}
+}
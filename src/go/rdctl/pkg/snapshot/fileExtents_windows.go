@@ -0,0 +1,9 @@
+package snapshot
+
+// Snapshot files are plain copies on Windows, so each snapshot's files take
+// up their full size.
+const copiesShareExtents = false
+
+func fileExtents(path string) ([]extent, error) {
+	return nil, errExtentsUnsupported
+}
@@ -0,0 +1,119 @@
+package snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Label keys and values follow the rules for Kubernetes labels, without
+// the length limits, so that selectors can be written the same way.
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+)
+
+// ValidateLabel checks that key and value can be used as a label.
+func ValidateLabel(key, value string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q: must consist of letters, digits, '.', '_', '/' and '-', and start and end with a letter or digit", key)
+	}
+	if !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("invalid value %q for label %q: must be empty, or consist of letters, digits, '.', '_' and '-', and start and end with a letter or digit", value, key)
+	}
+	return nil
+}
+
+// ParseLabels parses labels given as key=value strings.
+func ParseLabels(labels []string) (map[string]string, error) {
+	result := make(map[string]string, len(labels))
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label %q: must be key=value", label)
+		}
+		if err := ValidateLabel(key, value); err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+type selectorOperator int
+
+const (
+	selectorEquals selectorOperator = iota
+	selectorNotEquals
+	selectorExists
+	selectorNotExists
+)
+
+type selectorRequirement struct {
+	Key      string
+	Operator selectorOperator
+	Value    string
+}
+
+// Selector selects snapshots by their labels. A snapshot matches if it
+// meets every requirement in the selector.
+type Selector []selectorRequirement
+
+// ParseSelector parses a comma-separated list of requirements, each one of
+// key=value (or key==value), key!=value, key (the label is set) or !key
+// (the label is not set). key!=value also matches snapshots without the
+// label. An empty selector matches every snapshot.
+func ParseSelector(selector string) (Selector, error) {
+	result := Selector{}
+	if strings.TrimSpace(selector) == "" {
+		return result, nil
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		requirement := selectorRequirement{}
+		switch {
+		case strings.Contains(term, "!="):
+			requirement.Key, requirement.Value, _ = strings.Cut(term, "!=")
+			requirement.Operator = selectorNotEquals
+		case strings.Contains(term, "=="):
+			requirement.Key, requirement.Value, _ = strings.Cut(term, "==")
+		case strings.Contains(term, "="):
+			requirement.Key, requirement.Value, _ = strings.Cut(term, "=")
+		case strings.HasPrefix(term, "!"):
+			requirement.Key = strings.TrimSpace(strings.TrimPrefix(term, "!"))
+			requirement.Operator = selectorNotExists
+		default:
+			requirement.Key = term
+			requirement.Operator = selectorExists
+		}
+		requirement.Key = strings.TrimSpace(requirement.Key)
+		requirement.Value = strings.TrimSpace(requirement.Value)
+		if err := ValidateLabel(requirement.Key, requirement.Value); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		result = append(result, requirement)
+	}
+	return result, nil
+}
+
+// Matches reports whether a snapshot with the given labels is selected.
+func (selector Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range selector {
+		value, ok := labels[requirement.Key]
+		var matches bool
+		switch requirement.Operator {
+		case selectorEquals:
+			matches = ok && value == requirement.Value
+		case selectorNotEquals:
+			matches = !ok || value != requirement.Value
+		case selectorExists:
+			matches = ok
+		case selectorNotExists:
+			matches = !ok
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
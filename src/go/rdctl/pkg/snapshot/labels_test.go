@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]string{"team=qa", "example.com/env=staging", "empty="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "qa", "example.com/env": "staging", "empty": ""}, labels)
+
+	for _, label := range []string{"team", "=qa", "team=q a", "-team=qa", "team=qa-", "te am=qa"} {
+		_, err := ParseLabels([]string{label})
+		assert.Error(t, err, label)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"team": "qa", "env": "staging"}
+	testCases := map[string]bool{
+		"":                     true,
+		"team=qa":              true,
+		"team==qa":             true,
+		"team=dev":             false,
+		"team!=dev":            true,
+		"owner!=someone":       true,
+		"team":                 true,
+		"owner":                false,
+		"!owner":               true,
+		"!team":                false,
+		"team=qa, env=staging": true,
+		"team=qa,env=prod":     false,
+		"team=qa,env,!owner":   true,
+		"team=qa,env!=staging": false,
+	}
+	for input, expected := range testCases {
+		selector, err := ParseSelector(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, selector.Matches(labels), input)
+		}
+	}
+	for _, input := range []string{"team=q a", ",", "team=qa,", "!", "=qa"} {
+		_, err := ParseSelector(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -18,6 +18,7 @@ import (
 )
 
 const completeFileName = "complete.txt"
+const metadataFileName = "metadata.json"
 const completeFileContents = "The presence of this file indicates that this snapshot is complete and valid."
 const maxNameLength = 250
 const nameDisplayCutoffSize = 30
@@ -27,6 +28,11 @@ type Manager struct {
 	Snapshotter
 	*paths.Paths
 	lock.BackendLocker
+	// Manipulates the VM disks of incremental snapshots.
+	DiskTool DiskTool
+	// If set, called as files are copied while creating or restoring a
+	// snapshot.
+	Progress ProgressFunc
 }
 
 func NewManager() (*Manager, error) {
@@ -38,6 +44,7 @@ func NewManager() (*Manager, error) {
 		Paths:         appPaths,
 		Snapshotter:   NewSnapshotterImpl(),
 		BackendLocker: &lock.BackendLock{},
+		DiskTool:      QemuImg{},
 	}
 	return manager, nil
 }
@@ -100,11 +107,16 @@ func (manager *Manager) ValidateName(name string) error {
 }
 
 func (manager *Manager) writeMetadataFile(snapshot Snapshot) error {
-	snapshotDir := manager.SnapshotDirectory(snapshot)
+	return writeMetadataFileAt(manager.SnapshotDirectory(snapshot), snapshot)
+}
+
+// writeMetadataFileAt writes the metadata for snapshot into snapshotDir,
+// which need not be the snapshot's final location.
+func writeMetadataFileAt(snapshotDir string, snapshot Snapshot) error {
 	if err := os.MkdirAll(snapshotDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create snapshot directory: %w", err)
 	}
-	metadataPath := filepath.Join(snapshotDir, "metadata.json")
+	metadataPath := filepath.Join(snapshotDir, metadataFileName)
 	metadataFile, err := os.Create(metadataPath)
 	if err != nil {
 		return fmt.Errorf("failed to create metadata file: %w", err)
@@ -118,18 +130,37 @@ func (manager *Manager) writeMetadataFile(snapshot Snapshot) error {
 	return nil
 }
 
-// Create a new snapshot.
-func (manager *Manager) Create(ctx context.Context, name, description string) (Snapshot, error) {
+// Create a new snapshot. The given optional components are included in
+// addition to the VM and settings.
+func (manager *Manager) Create(ctx context.Context, name, description string, components ...string) (Snapshot, error) {
+	return manager.create(ctx, Snapshot{Name: name, Description: description}, components, true)
+}
+
+// CreateWithLabels creates a new snapshot, like Create, with the given
+// labels.
+func (manager *Manager) CreateWithLabels(ctx context.Context, name, description string, labels map[string]string, components ...string) (Snapshot, error) {
+	for key, value := range labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	return manager.create(ctx, Snapshot{Name: name, Description: description, Labels: labels}, components, true)
+}
+
+// create makes a new snapshot from the name, description and other
+// user-supplied fields of snapshot. The backend is restarted afterwards
+// unless restart is false.
+func (manager *Manager) create(ctx context.Context, snapshot Snapshot, components []string, restart bool) (_ Snapshot, err error) {
+	if err := ValidateComponents(components); err != nil {
+		return Snapshot{}, err
+	}
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return Snapshot{}, fmt.Errorf("failed to generate ID for snapshot: %w", err)
 	}
-	snapshot := Snapshot{
-		Created:     time.Now(),
-		Name:        name,
-		ID:          id.String(),
-		Description: description,
-	}
+	name := snapshot.Name
+	snapshot.Created = time.Now()
+	snapshot.ID = id.String()
 	action := fmt.Sprintf("Creating snapshot %q", name)
 	if err := manager.Lock(ctx, manager.Paths, action); err != nil {
 		return snapshot, err
@@ -138,7 +169,7 @@ func (manager *Manager) Create(ctx context.Context, name, description string) (S
 		if err != nil {
 			os.RemoveAll(manager.SnapshotDirectory(snapshot))
 		}
-		unlockErr := manager.Unlock(ctx, manager.Paths, true)
+		unlockErr := manager.Unlock(ctx, manager.Paths, restart)
 		if err == nil {
 			err = unlockErr
 		}
@@ -147,12 +178,46 @@ func (manager *Manager) Create(ctx context.Context, name, description string) (S
 	if err := manager.ValidateName(name); err != nil {
 		return snapshot, err
 	}
-	if err = manager.writeMetadataFile(snapshot); err == nil {
-		err = manager.CreateFiles(ctx, manager.Paths, manager.SnapshotDirectory(snapshot))
+	snapshotDir := manager.SnapshotDirectory(snapshot)
+	if err = manager.writeMetadataFile(snapshot); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Parent != "" {
+		err = manager.createIncrementalFiles(ctx, snapshot, components)
+	} else {
+		err = manager.CreateFiles(ctx, manager.Paths, snapshotDir, components, manager.progressFunc())
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	if err = writeContentsFile(manager.Paths, snapshotDir, components); err != nil {
+		return snapshot, err
 	}
+	// Record the checksums so that the snapshot can be verified before
+	// it is restored.
+	if snapshot.Files, err = manager.checksumFiles(ctx, snapshotDir); err != nil {
+		return snapshot, err
+	}
+	if err = manager.recordMetadata(&snapshot, snapshotDir); err != nil {
+		return snapshot, err
+	}
+	if err = manager.writeMetadataFile(snapshot); err != nil {
+		return snapshot, err
+	}
+	err = writeCompleteFile(snapshotDir)
 	return snapshot, err
 }
 
+// Create complete.txt file. This must be done last because its presence
+// signifies a complete and valid snapshot.
+func writeCompleteFile(snapshotDir string) error {
+	completeFilePath := filepath.Join(snapshotDir, completeFileName)
+	if err := os.WriteFile(completeFilePath, []byte(completeFileContents), 0o644); err != nil {
+		return fmt.Errorf("failed to write %q: %w", completeFileName, err)
+	}
+	return nil
+}
+
 // List snapshots that are present on the system. If includeIncomplete is
 // true, includes snapshots that are currently being created, are currently
 // being deleted, or are otherwise incomplete and cannot be restored from.
@@ -167,7 +232,7 @@ func (manager *Manager) List(includeIncomplete bool) ([]Snapshot, error) {
 			continue
 		}
 		snapshot := Snapshot{}
-		metadataPath := filepath.Join(manager.Snapshots, dirEntry.Name(), "metadata.json")
+		metadataPath := filepath.Join(manager.Snapshots, dirEntry.Name(), metadataFileName)
 		contents, err := os.ReadFile(metadataPath)
 		if err != nil {
 			return []Snapshot{}, fmt.Errorf("failed to read %q: %w", metadataPath, err)
@@ -191,50 +256,119 @@ func (manager *Manager) List(includeIncomplete bool) ([]Snapshot, error) {
 	return snapshots, nil
 }
 
-// Delete a snapshot.
-func (manager *Manager) Delete(name string) error {
+// Delete a snapshot. Snapshots that other snapshots depend on are not
+// deleted; see DeleteRebasing.
+func (manager *Manager) Delete(ctx context.Context, name string) (err error) {
+	if _, err := manager.Snapshot(name); err != nil {
+		return err
+	}
+	action := fmt.Sprintf("Deleting snapshot %q", name)
+	if err := manager.LockMetadata(manager.Paths, action); err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := manager.Unlock(ctx, manager.Paths, false)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+	return manager.deleteLocked(name)
+}
+
+// Deletes a snapshot like Delete, for callers that hold the lock. The
+// snapshot is read again, as it may have changed before the lock was taken.
+func (manager *Manager) deleteLocked(name string) error {
 	snapshot, err := manager.Snapshot(name)
 	if err != nil {
 		return err
 	}
+	if err := manager.checkNoDependents(snapshot); err != nil {
+		return err
+	}
+	return manager.deleteFiles(snapshot)
+}
+
+func (manager *Manager) deleteFiles(snapshot Snapshot) error {
 	snapshotDir := manager.SnapshotDirectory(snapshot)
 	// Remove complete.txt file. This must be done first because restoring
 	// from a partially-deleted snapshot could result in errors.
-	err = os.RemoveAll(filepath.Join(snapshotDir, completeFileName))
+	err := os.RemoveAll(filepath.Join(snapshotDir, completeFileName))
 	return errors.Join(err, os.RemoveAll(snapshotDir))
 }
 
-// Restore Rancher Desktop to the state saved in a snapshot.
-func (manager *Manager) Restore(ctx context.Context, name string) (err error) {
+// Restore Rancher Desktop to the state saved in a snapshot. Unless force is
+// true, snapshots that CheckCompatibility reports as incompatible are not
+// restored.
+func (manager *Manager) Restore(ctx context.Context, name string, force bool) error {
 	snapshot, err := manager.Snapshot(name)
 	if err != nil {
 		return err
 	}
+	if _, err := manager.CheckCompatibility(snapshot); err != nil && !force {
+		return err
+	}
+	// Check the snapshot, and those its VM disk is backed by, before
+	// touching anything; on Windows, a failure partway through restoring
+	// results in a data reset.
+	chain, err := manager.chain(snapshot)
+	if err != nil {
+		return err
+	}
+	for _, link := range chain {
+		if err := manager.Verify(ctx, link); err != nil && !errors.Is(err, ErrNoChecksums) {
+			return err
+		}
+	}
 
 	action := fmt.Sprintf("Restoring snapshot %q", name)
 	if err := manager.Lock(ctx, manager.Paths, action); err != nil {
 		return err
 	}
-	defer func() {
-		// Restart the backend only if a data reset occurred
-		unlockErr := manager.Unlock(ctx, manager.Paths, !errors.Is(err, ErrDataReset))
-		if err == nil {
-			err = unlockErr
-		}
-	}()
 	// If the context is marked done (i.e. the user cancelled the
 	// operation) we can avoid running RestoreFiles() and thus avoid
 	// an unnecessary data reset.
 	if contextIsDone(ctx) {
+		_ = manager.Unlock(ctx, manager.Paths, true)
 		return runner.ErrContextDone
 	}
-	if err = manager.RestoreFiles(ctx, manager.Paths, manager.SnapshotDirectory(snapshot)); err != nil {
-		return fmt.Errorf("failed to restore files: %w", err)
+	if err := manager.restoreSnapshotFiles(ctx, snapshot); err != nil {
+		// Restart the backend unless a data reset occurred.
+		unlockErr := manager.Unlock(ctx, manager.Paths, !errors.Is(err, ErrDataReset))
+		return errors.Join(fmt.Errorf("failed to restore files: %w", err), unlockErr)
+	}
+	if err := manager.Unlock(ctx, manager.Paths, true); err != nil {
+		return manager.rollbackRestore(ctx, name, err)
+	}
+	if err := manager.WaitForStarted(ctx); err != nil {
+		if contextIsDone(ctx) {
+			// Leave the previous files in place; they are cleaned up
+			// by the next restore.
+			return runner.ErrContextDone
+		}
+		return manager.rollbackRestore(ctx, name, err)
+	}
+	if err := manager.CommitRestore(manager.Paths); err != nil {
+		return fmt.Errorf("failed to clean up after restore: %w", err)
 	}
-
 	return nil
 }
 
+// Puts back the files that were in use before a snapshot was restored, after
+// the backend failed to start with the restored files.
+func (manager *Manager) rollbackRestore(ctx context.Context, name string, startErr error) error {
+	startErr = fmt.Errorf("failed to start with restored snapshot %q: %w", name, startErr)
+	action := fmt.Sprintf("Rolling back restore of snapshot %q", name)
+	if err := manager.LockFailed(ctx, manager.Paths, action); err != nil {
+		return errors.Join(startErr, fmt.Errorf("failed to roll back: %w", err))
+	}
+	rollbackErr := manager.RollbackRestore(manager.Paths)
+	if rollbackErr != nil {
+		rollbackErr = fmt.Errorf("failed to roll back: %w", rollbackErr)
+	}
+	unlockErr := manager.Unlock(ctx, manager.Paths, true)
+	return errors.Join(startErr, rollbackErr, unlockErr)
+}
+
 func checkForInvalidCharacter(name string) error {
 	for idx, c := range name {
 		if !unicode.IsPrint(c) {
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
 )
 
@@ -197,7 +198,7 @@ func TestManager(t *testing.T) {
 		if len(snapshots) != 1 {
 			t.Fatalf("unexpected length of snapshots slice before delete %d", len(snapshots))
 		}
-		if err := manager.Delete(snapshot.Name); err != nil {
+		if err := manager.Delete(context.Background(), snapshot.Name); err != nil {
 			t.Fatalf("failed to delete snapshot: %s", err)
 		}
 		snapshots, err = manager.List(false)
@@ -209,10 +210,29 @@ func TestManager(t *testing.T) {
 		}
 	})
 
+	t.Run("Delete should not delete a snapshot while the backend is locked", func(t *testing.T) {
+		paths, _ := populateFiles(t, true)
+		manager := newTestManager(paths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot-delete-locked", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		if err := os.WriteFile(filepath.Join(paths.AppHome, "backend.lock"), []byte("{}"), 0o644); err != nil {
+			t.Fatalf("failed to create lock file: %s", err)
+		}
+		manager.BackendLocker = &lock.BackendLock{}
+		if err := manager.Delete(context.Background(), snapshot.Name); err == nil {
+			t.Errorf("deleted a snapshot while the backend was locked")
+		}
+		if _, err := manager.Snapshot(snapshot.Name); err != nil {
+			t.Errorf("failed to find snapshot after failed delete: %s", err)
+		}
+	})
+
 	t.Run("Restore should return an error if asked to restore a nonexistent snapshot", func(t *testing.T) {
 		paths, _ := populateFiles(t, true)
 		manager := newTestManager(paths)
-		if err := manager.Restore(context.Background(), "no-such-snapshot-id"); err == nil {
+		if err := manager.Restore(context.Background(), "no-such-snapshot-id", false); err == nil {
 			t.Errorf("Failed to complain when asked to restore a nonexistent snapshot")
 		}
 	})
@@ -228,7 +248,7 @@ func TestManager(t *testing.T) {
 		if err := os.Remove(completeFilePath); err != nil {
 			t.Fatalf("failed to remove %q: %s", completeFileName, err)
 		}
-		if err := manager.Restore(context.Background(), snapshot.Name); err == nil {
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err == nil {
 			t.Errorf("Failed to complain when asked to restore an incomplete snapshot")
 		}
 	})
@@ -243,25 +263,88 @@ func TestManager(t *testing.T) {
 		}
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
-		if err := manager.Restore(ctx, snapshotName); !errors.Is(err, runner.ErrContextDone) {
+		if err := manager.Restore(ctx, snapshotName, false); !errors.Is(err, runner.ErrContextDone) {
 			t.Errorf("Error is of unexpected type: %q", err)
 		}
 	})
 
-	t.Run("Restore should return data reset error when RestoreFiles encounters an error and resets data", func(t *testing.T) {
+	t.Run("Create should record checksums that Verify accepts", func(t *testing.T) {
+		paths, _ := populateFiles(t, true)
+		manager := newTestManager(paths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot-verify", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		snapshot, err = manager.Snapshot(snapshot.Name)
+		if err != nil {
+			t.Fatalf("failed to read snapshot: %s", err)
+		}
+		if len(snapshot.Files) == 0 {
+			t.Fatalf("no checksums were recorded")
+		}
+		if err := manager.Verify(context.Background(), snapshot); err != nil {
+			t.Errorf("failed to verify snapshot: %s", err)
+		}
+	})
+
+	t.Run("Verify should return ErrNoChecksums for snapshots without checksums", func(t *testing.T) {
 		paths, _ := populateFiles(t, true)
 		manager := newTestManager(paths)
-		snapshotName := "test-snapshot-error"
+		snapshot, err := manager.Create(context.Background(), "test-snapshot-verify", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		snapshot.Files = nil
+		if err := manager.Verify(context.Background(), snapshot); !errors.Is(err, ErrNoChecksums) {
+			t.Errorf("Error is of unexpected type: %q", err)
+		}
+	})
+
+	t.Run("Restore should refuse a corrupt snapshot without resetting data", func(t *testing.T) {
+		paths, testFiles := populateFiles(t, true)
+		manager := newTestManager(paths)
+		snapshotName := "test-snapshot-corrupt"
 		snapshot, err := manager.Create(context.Background(), snapshotName, "")
 		if err != nil {
 			t.Fatalf("failed to create snapshot: %s", err)
 		}
 		snapshotSettingsPath := filepath.Join(paths.Snapshots, snapshot.ID, "settings.json")
-		if err := os.RemoveAll(snapshotSettingsPath); err != nil {
-			t.Fatalf("failed to remove settings.json: %s", err)
+		if err := os.WriteFile(snapshotSettingsPath, []byte(`{"test": "corrupted"}`), 0o644); err != nil {
+			t.Fatalf("failed to modify settings.json: %s", err)
 		}
-		if err := manager.Restore(context.Background(), snapshotName); !errors.Is(err, ErrDataReset) {
+		err = manager.Restore(context.Background(), snapshotName, false)
+		if !errors.Is(err, ErrCorrupt) {
 			t.Errorf("Error is of unexpected type: %q", err)
 		}
+		if errors.Is(err, ErrDataReset) {
+			t.Errorf("Restore reset data for a corrupt snapshot")
+		}
+		contents, err := os.ReadFile(testFiles["settings.json"].Path)
+		if err != nil {
+			t.Fatalf("failed to read working settings.json: %s", err)
+		}
+		if string(contents) != testFiles["settings.json"].Contents {
+			t.Errorf("working settings.json was modified")
+		}
+	})
+
+	t.Run("Create should report the progress of each file", func(t *testing.T) {
+		paths, _ := populateFiles(t, true)
+		manager := newTestManager(paths)
+		progress := map[string]Progress{}
+		manager.Progress = func(update Progress) {
+			progress[update.File] = update
+		}
+		if _, err := manager.Create(context.Background(), "test-snapshot-progress", ""); err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		for _, file := range selectContentFiles(contentFiles(paths), nil) {
+			update, ok := progress[file.Name]
+			if !ok {
+				t.Errorf("no progress reported for %s", file.Name)
+			} else if update.Copied != update.Total {
+				t.Errorf("progress for %s stopped at %d of %d bytes", file.Name, update.Copied, update.Total)
+			}
+		}
 	})
 }
@@ -17,10 +17,12 @@ import (
 func populateFiles(t *testing.T, includeOverrideYaml bool) (*paths.Paths, map[string]TestFile) {
 	baseDir := t.TempDir()
 	appPaths := paths.Paths{
-		AppHome:   baseDir,
-		Config:    filepath.Join(baseDir, "config"),
-		Lima:      filepath.Join(baseDir, "lima"),
-		Snapshots: filepath.Join(baseDir, "snapshots"),
+		AppHome:         baseDir,
+		Config:          filepath.Join(baseDir, "config"),
+		Lima:            filepath.Join(baseDir, "lima"),
+		Snapshots:       filepath.Join(baseDir, "snapshots"),
+		ExtensionRoot:   filepath.Join(baseDir, "extensions"),
+		ContainerdShims: filepath.Join(baseDir, "containerd-shims"),
 	}
 	testFiles := map[string]TestFile{
 		"settings.json": {
@@ -119,7 +121,7 @@ func TestManagerUnix(t *testing.T) {
 					t.Fatalf("failed to modify %s: %s", testFileName, err)
 				}
 			}
-			if err := manager.Restore(context.Background(), snapshot.Name); err != nil {
+			if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
 				t.Fatalf("failed to restore snapshot: %s", err)
 			}
 			for testFileName, testFile := range testFiles {
@@ -149,7 +151,7 @@ func TestManagerUnix(t *testing.T) {
 				t.Fatalf("failed to modify %s: %s", testFileName, err)
 			}
 		}
-		if err := manager.Restore(context.Background(), snapshot.Name); err != nil {
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
 			t.Fatalf("failed to restore snapshot: %s", err)
 		}
 		overrideYamlPath := testFiles["override.yaml"].Path
@@ -180,8 +182,315 @@ func TestManagerUnix(t *testing.T) {
 				t.Fatalf("failed to remove directory: %s", err)
 			}
 		}
-		if err := manager.Restore(context.Background(), snapshot.Name); err != nil {
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
 			t.Fatalf("failed to restore snapshot: %s", err)
 		}
 	})
+
+	t.Run("Restore should leave working files intact when RestoreFiles fails", func(t *testing.T) {
+		appPaths, testFiles := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		// Drop the recorded checksums, as for a snapshot created by an
+		// older version, so that the error is only found by RestoreFiles.
+		snapshot.Files = nil
+		if err := manager.writeMetadataFile(snapshot); err != nil {
+			t.Fatalf("failed to rewrite metadata: %s", err)
+		}
+		if err := os.Remove(filepath.Join(manager.SnapshotDirectory(snapshot), "diffdisk")); err != nil {
+			t.Fatalf("failed to remove diffdisk: %s", err)
+		}
+		err = manager.Restore(context.Background(), snapshot.Name, false)
+		if err == nil {
+			t.Fatalf("failed to complain about missing diffdisk")
+		}
+		if errors.Is(err, ErrDataReset) {
+			t.Errorf("Restore reset data: %s", err)
+		}
+		for testFileName, testFile := range testFiles {
+			contents, err := os.ReadFile(testFile.Path)
+			if err != nil {
+				t.Fatalf("failed to read contents of %s: %s", testFileName, err)
+			}
+			if string(contents) != testFile.Contents {
+				t.Errorf("contents of %s were modified", testFileName)
+			}
+		}
+		for _, path := range []string{appPaths.Lima + restoreStagingSuffix, appPaths.Lima + restoreBackupSuffix} {
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%q was left behind", path)
+			}
+		}
+	})
+
+	t.Run("Restore should keep files that are not part of the snapshot", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		logPath := filepath.Join(appPaths.Lima, "0", "serial.log")
+		if err := os.WriteFile(logPath, []byte("log contents"), 0o644); err != nil {
+			t.Fatalf("failed to write log file: %s", err)
+		}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
+			t.Fatalf("failed to restore snapshot: %s", err)
+		}
+		if contents, err := os.ReadFile(logPath); err != nil || string(contents) != "log contents" {
+			t.Errorf("log file was not carried over: %q, %v", contents, err)
+		}
+		for _, path := range []string{appPaths.Lima + restoreBackupSuffix, filepath.Join(appPaths.Config, "settings.json"+restoreBackupSuffix)} {
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%q was not removed after restore", path)
+			}
+		}
+	})
+
+	t.Run("Restore should roll back when the backend fails to start", func(t *testing.T) {
+		appPaths, testFiles := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		for testFileName, testFile := range testFiles {
+			if err := os.WriteFile(testFile.Path, []byte(`{"something": "different"}`), 0o644); err != nil {
+				t.Fatalf("failed to modify %s: %s", testFileName, err)
+			}
+		}
+		startErr := errors.New("backend failed to start")
+		manager.BackendLocker = &lock.MockBackendLock{StartError: startErr}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); !errors.Is(err, startErr) {
+			t.Fatalf("Error is of unexpected type: %q", err)
+		}
+		for testFileName, testFile := range testFiles {
+			contents, err := os.ReadFile(testFile.Path)
+			if err != nil {
+				t.Fatalf("failed to read contents of %s: %s", testFileName, err)
+			}
+			if string(contents) != `{"something": "different"}` {
+				t.Errorf("contents of %s were not rolled back", testFileName)
+			}
+		}
+		if _, err := os.Stat(appPaths.Lima + restoreBackupSuffix); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("backup was left behind after rollback")
+		}
+	})
+
+	t.Run("Restore should recover from an interrupted swap", func(t *testing.T) {
+		appPaths, testFiles := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		// Simulate a restore that was interrupted after moving the Lima
+		// directory out of the way but before swapping in the staged one.
+		if err := os.Rename(appPaths.Lima, appPaths.Lima+restoreBackupSuffix); err != nil {
+			t.Fatalf("failed to move Lima directory: %s", err)
+		}
+		if err := os.MkdirAll(appPaths.Lima+restoreStagingSuffix, 0o755); err != nil {
+			t.Fatalf("failed to create staging directory: %s", err)
+		}
+		if err := recoverInterruptedRestore(appPaths); err != nil {
+			t.Fatalf("failed to recover: %s", err)
+		}
+		contents, err := os.ReadFile(testFiles["diffdisk"].Path)
+		if err != nil || string(contents) != testFiles["diffdisk"].Contents {
+			t.Errorf("Lima directory was not put back: %q, %v", contents, err)
+		}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
+			t.Fatalf("failed to restore snapshot: %s", err)
+		}
+	})
+}
+
+func populateComponentFiles(t *testing.T, appPaths *paths.Paths) map[string]TestFile {
+	testFiles := map[string]TestFile{
+		"extension": {
+			Path:     filepath.Join(appPaths.ExtensionRoot, "ext-id", "metadata.json"),
+			Contents: `{"test": "extension"}`,
+		},
+		"shim": {
+			Path:     filepath.Join(appPaths.ContainerdShims, "containerd-shim-test-v1"),
+			Contents: "shim binary",
+		},
+	}
+	for _, file := range testFiles {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0o755); err != nil {
+			t.Fatalf("failed to create dir for %q: %s", file.Path, err)
+		}
+		if err := os.WriteFile(file.Path, []byte(file.Contents), 0o755); err != nil {
+			t.Fatalf("failed to create test file %q: %s", file.Path, err)
+		}
+	}
+	return testFiles
+}
+
+func TestManagerComponents(t *testing.T) {
+	t.Run("Restore should put back included components", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		componentFiles := populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions, ComponentShims)
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		for _, dir := range []string{appPaths.ExtensionRoot, appPaths.ContainerdShims} {
+			if err := os.RemoveAll(dir); err != nil {
+				t.Fatalf("failed to remove %q: %s", dir, err)
+			}
+		}
+		addedPath := filepath.Join(appPaths.ExtensionRoot, "other-ext", "metadata.json")
+		if err := os.MkdirAll(filepath.Dir(addedPath), 0o755); err != nil {
+			t.Fatalf("failed to create extension dir: %s", err)
+		}
+		if err := os.WriteFile(addedPath, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("failed to create extension: %s", err)
+		}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
+			t.Fatalf("failed to restore snapshot: %s", err)
+		}
+		for name, file := range componentFiles {
+			contents, err := os.ReadFile(file.Path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %s", name, err)
+			}
+			if string(contents) != file.Contents {
+				t.Errorf("contents of %s appear to have not been restored", name)
+			}
+		}
+		if info, err := os.Stat(componentFiles["shim"].Path); err != nil || info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("shim was not restored as executable")
+		}
+		if _, err := os.Stat(addedPath); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("extension installed after the snapshot was not removed")
+		}
+		for _, dir := range []string{appPaths.ExtensionRoot, appPaths.ContainerdShims} {
+			for _, suffix := range []string{restoreStagingSuffix, restoreBackupSuffix, restoreAbsentSuffix} {
+				if _, err := os.Lstat(dir + suffix); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("%q was left behind", dir+suffix)
+				}
+			}
+		}
+	})
+
+	t.Run("Restore should leave components that are not in the snapshot alone", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		componentFiles := populateComponentFiles(t, appPaths)
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
+			t.Fatalf("failed to restore snapshot: %s", err)
+		}
+		for name, file := range componentFiles {
+			if _, err := os.Stat(file.Path); err != nil {
+				t.Errorf("%s was removed: %s", name, err)
+			}
+		}
+	})
+
+	t.Run("Rollback should remove components that did not exist before", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions)
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		if err := os.RemoveAll(appPaths.ExtensionRoot); err != nil {
+			t.Fatalf("failed to remove extensions: %s", err)
+		}
+		manager.BackendLocker = &lock.MockBackendLock{StartError: errors.New("failed to start")}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err == nil {
+			t.Fatalf("restore should have failed")
+		}
+		if _, err := os.Stat(appPaths.ExtensionRoot); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("restored extensions were not rolled back")
+		}
+		if _, err := os.Stat(appPaths.ContainerdShims); err != nil {
+			t.Errorf("shims that are not in the snapshot were touched: %s", err)
+		}
+	})
+
+	t.Run("Create should record the contents of the snapshot", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "", ComponentShims)
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		contents, err := readContentsFile(appPaths, manager.SnapshotDirectory(snapshot))
+		if err != nil {
+			t.Fatalf("failed to read contents: %s", err)
+		}
+		if len(contents.Components) != 1 || contents.Components[0] != ComponentShims {
+			t.Errorf("unexpected components %v", contents.Components)
+		}
+		for _, entry := range contents.Entries {
+			if entry.Name == "override.yaml" || entry.Name == "extensions" {
+				t.Errorf("%s is listed but not in the snapshot", entry.Name)
+			}
+		}
+		if err := manager.Verify(context.Background(), snapshot); err != nil {
+			t.Errorf("failed to verify snapshot: %s", err)
+		}
+	})
+
+	t.Run("Progress should name component files by their path in the snapshot", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		populateComponentFiles(t, appPaths)
+		manager := newTestManager(appPaths)
+		progress := map[string]Progress{}
+		manager.Progress = func(update Progress) {
+			progress[update.File] = update
+		}
+		if _, err := manager.Create(context.Background(), "test-snapshot", "", ComponentExtensions, ComponentShims); err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		for _, name := range []string{
+			filepath.Join("extensions", "ext-id", "metadata.json"),
+			filepath.Join("containerd-shims", "containerd-shim-test-v1"),
+		} {
+			if _, ok := progress[name]; !ok {
+				t.Errorf("no progress reported for %s", name)
+			}
+		}
+	})
+
+	t.Run("Create should reject unknown components", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		if _, err := manager.Create(context.Background(), "test-snapshot", "", "images"); err == nil {
+			t.Errorf("failed to reject unknown component")
+		}
+	})
+
+	t.Run("Restore should refuse snapshots with unknown contents", func(t *testing.T) {
+		appPaths, testFiles := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		contentsPath := filepath.Join(manager.SnapshotDirectory(snapshot), contentsFileName)
+		if err := os.WriteFile(contentsPath, []byte(`{"components": [], "entries": [{"name": "images"}]}`), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %s", contentsFileName, err)
+		}
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err == nil {
+			t.Fatalf("failed to refuse unknown contents")
+		}
+		contents, err := os.ReadFile(testFiles["settings.json"].Path)
+		if err != nil || string(contents) != testFiles["settings.json"].Contents {
+			t.Errorf("working settings.json was modified")
+		}
+	})
 }
@@ -15,10 +15,13 @@ import (
 func populateFiles(t *testing.T, _ bool) (*paths.Paths, map[string]TestFile) {
 	baseDir := t.TempDir()
 	appPaths := paths.Paths{
-		Config:        filepath.Join(baseDir, "config"),
-		Snapshots:     filepath.Join(baseDir, "snapshots"),
-		WslDistro:     filepath.Join(baseDir, "wslDistro"),
-		WslDistroData: filepath.Join(baseDir, "wslDistroData"),
+		AppHome:         baseDir,
+		Config:          filepath.Join(baseDir, "config"),
+		Snapshots:       filepath.Join(baseDir, "snapshots"),
+		WslDistro:       filepath.Join(baseDir, "wslDistro"),
+		WslDistroData:   filepath.Join(baseDir, "wslDistroData"),
+		ExtensionRoot:   filepath.Join(baseDir, "extensions"),
+		ContainerdShims: filepath.Join(baseDir, "containerd-shims"),
 	}
 	testFiles := map[string]TestFile{
 		"settings.json": {
@@ -84,7 +87,7 @@ func TestManagerWindows(t *testing.T) {
 				t.Fatalf("failed to modify %s: %s", testFileName, err)
 			}
 		}
-		if err := manager.Restore(context.Background(), snapshot.Name); err != nil {
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
 			t.Fatalf("failed to restore snapshot: %s", err)
 		}
 		for testFileName, testFile := range testFiles {
@@ -115,7 +118,7 @@ func TestManagerWindows(t *testing.T) {
 				t.Fatalf("failed to remove test directory %q: %s", testDir, err)
 			}
 		}
-		if err := manager.Restore(context.Background(), snapshot.Name); err != nil {
+		if err := manager.Restore(context.Background(), snapshot.Name, false); err != nil {
 			t.Fatalf("failed to restore snapshot: %s", err)
 		}
 		for _, testDir := range testDirs {
@@ -124,4 +127,27 @@ func TestManagerWindows(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("Restore should return data reset error when RestoreFiles encounters an error and resets data", func(t *testing.T) {
+		paths, _ := populateFiles(t, true)
+		manager := newTestManager(paths)
+		snapshotName := "test-snapshot-error"
+		snapshot, err := manager.Create(context.Background(), snapshotName, "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		// Drop the recorded checksums, as for a snapshot created by an
+		// older version, so that the error is only found by RestoreFiles.
+		snapshot.Files = nil
+		if err := manager.writeMetadataFile(snapshot); err != nil {
+			t.Fatalf("failed to rewrite metadata: %s", err)
+		}
+		snapshotSettingsPath := filepath.Join(paths.Snapshots, snapshot.ID, "settings.json")
+		if err := os.RemoveAll(snapshotSettingsPath); err != nil {
+			t.Fatalf("failed to remove settings.json: %s", err)
+		}
+		if err := manager.Restore(context.Background(), snapshotName, false); !errors.Is(err, ErrDataReset) {
+			t.Errorf("Error is of unexpected type: %q", err)
+		}
+	})
 }
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
+)
+
+// Returned by Manager.CheckCompatibility for snapshots that the running
+// version of Rancher Desktop is not expected to be able to restore.
+var ErrIncompatible = errors.New("snapshot is incompatible with this version of Rancher Desktop")
+
+// The version reported by development builds, which can't be compared.
+const developmentVersion = "0.0.0"
+
+var appVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
+
+// KubernetesMetadata records the Kubernetes settings of a snapshot.
+type KubernetesMetadata struct {
+	Enabled bool   `json:"enabled"`
+	Version string `json:"version,omitempty"`
+}
+
+// The parts of settings.json that are recorded in snapshot metadata.
+type snapshotSettings struct {
+	Version         int `json:"version"`
+	ContainerEngine struct {
+		Name string `json:"name"`
+	} `json:"containerEngine"`
+	Kubernetes *KubernetesMetadata `json:"kubernetes"`
+}
+
+// Fills in the metadata that describes what a snapshot holds, from the
+// settings.json in the snapshot directory and the recorded file sizes.
+func (manager *Manager) recordMetadata(snapshot *Snapshot, snapshotDir string) error {
+	snapshot.AppVersion = version.Version
+	contents, err := os.ReadFile(filepath.Join(snapshotDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read settings.json: %w", err)
+	}
+	var settings snapshotSettings
+	if err := json.Unmarshal(contents, &settings); err != nil {
+		return fmt.Errorf("failed to parse settings.json: %w", err)
+	}
+	snapshot.SettingsVersion = settings.Version
+	snapshot.ContainerEngine = settings.ContainerEngine.Name
+	snapshot.Kubernetes = settings.Kubernetes
+	snapshot.DiskSizes = map[string]int64{}
+	for _, entry := range contentFiles(manager.Paths) {
+		if !entry.Disk {
+			continue
+		}
+		for _, file := range snapshot.Files {
+			if file.Name == entry.Name {
+				snapshot.DiskSizes[file.Name] = file.Size
+			}
+		}
+	}
+	return nil
+}
+
+// CheckCompatibility compares the versions recorded in a snapshot with the
+// running version of Rancher Desktop. It returns warnings about differences
+// that may cause problems, and an error wrapping ErrIncompatible if restoring
+// the snapshot is expected to fail.
+func (manager *Manager) CheckCompatibility(snapshot Snapshot) ([]string, error) {
+	warnings := []string{}
+	if snapshot.SettingsVersion > options.CURRENT_SETTINGS_VERSION {
+		return warnings, fmt.Errorf("%w: its settings are version %d, but at most version %d is supported",
+			ErrIncompatible, snapshot.SettingsVersion, options.CURRENT_SETTINGS_VERSION)
+	}
+	if snapshot.AppVersion == "" {
+		warnings = append(warnings, "snapshot does not record the version of Rancher Desktop that created it")
+		return warnings, nil
+	}
+	snapshotVersion, ok := parseAppVersion(snapshot.AppVersion)
+	if !ok {
+		return warnings, nil
+	}
+	currentVersion, ok := parseAppVersion(version.Version)
+	if !ok {
+		return warnings, nil
+	}
+	switch {
+	case snapshotVersion[0] != currentVersion[0]:
+		return warnings, fmt.Errorf("%w: it was created by version %s, but this is version %s",
+			ErrIncompatible, snapshot.AppVersion, version.Version)
+	case snapshotVersion[1] > currentVersion[1]:
+		return warnings, fmt.Errorf("%w: it was created by newer version %s, but this is version %s",
+			ErrIncompatible, snapshot.AppVersion, version.Version)
+	case snapshotVersion != currentVersion:
+		warnings = append(warnings, fmt.Sprintf("snapshot was created by version %s of Rancher Desktop, but this is version %s",
+			snapshot.AppVersion, version.Version))
+	}
+	return warnings, nil
+}
+
+// Returns the major, minor and patch numbers of a Rancher Desktop version.
+// Development builds, and versions that can't be parsed, are not ok.
+func parseAppVersion(appVersion string) ([3]int, bool) {
+	var result [3]int
+	matches := appVersionPattern.FindStringSubmatch(appVersion)
+	if matches == nil || appVersion == developmentVersion {
+		return result, false
+	}
+	for i := range result {
+		number, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return result, false
+		}
+		result[i] = number
+	}
+	return result, true
+}
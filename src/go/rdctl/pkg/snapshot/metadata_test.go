@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
+)
+
+func setVersion(t *testing.T, appVersion string) {
+	previous := version.Version
+	version.Version = appVersion
+	t.Cleanup(func() { version.Version = previous })
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("Create should record metadata from the snapshotted settings", func(t *testing.T) {
+		setVersion(t, "1.18.0")
+		appPaths, _ := populateFiles(t, true)
+		settings := `{"version": 10, "containerEngine": {"name": "moby"}, "kubernetes": {"enabled": true, "version": "1.29.4"}}`
+		require.NoError(t, os.WriteFile(filepath.Join(appPaths.Config, "settings.json"), []byte(settings), 0o644))
+		manager := newTestManager(appPaths)
+		_, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+
+		snapshot, err := manager.Snapshot("test-snapshot")
+		require.NoError(t, err)
+		assert.Equal(t, "1.18.0", snapshot.AppVersion)
+		assert.Equal(t, 10, snapshot.SettingsVersion)
+		assert.Equal(t, "moby", snapshot.ContainerEngine)
+		assert.Equal(t, &KubernetesMetadata{Enabled: true, Version: "1.29.4"}, snapshot.Kubernetes)
+		for _, entry := range contentFiles(appPaths) {
+			if entry.Disk {
+				assert.Contains(t, snapshot.DiskSizes, entry.Name)
+			}
+		}
+	})
+
+	t.Run("CheckCompatibility should compare versions", func(t *testing.T) {
+		setVersion(t, "1.18.2")
+		manager := newTestManager(nil)
+		testCases := []struct {
+			Description  string
+			Snapshot     Snapshot
+			Incompatible bool
+			Warnings     int
+		}{
+			{Description: "same version", Snapshot: Snapshot{AppVersion: "1.18.2"}},
+			{Description: "older patch release", Snapshot: Snapshot{AppVersion: "1.18.0"}, Warnings: 1},
+			{Description: "older minor release", Snapshot: Snapshot{AppVersion: "1.16.0"}, Warnings: 1},
+			{Description: "newer minor release", Snapshot: Snapshot{AppVersion: "1.19.0"}, Incompatible: true},
+			{Description: "different major release", Snapshot: Snapshot{AppVersion: "2.0.0"}, Incompatible: true},
+			{Description: "unknown version", Snapshot: Snapshot{}, Warnings: 1},
+			{Description: "development build", Snapshot: Snapshot{AppVersion: developmentVersion}},
+			{
+				Description:  "newer settings",
+				Snapshot:     Snapshot{AppVersion: "1.18.2", SettingsVersion: options.CURRENT_SETTINGS_VERSION + 1},
+				Incompatible: true,
+			},
+		}
+		for _, testCase := range testCases {
+			warnings, err := manager.CheckCompatibility(testCase.Snapshot)
+			if testCase.Incompatible {
+				assert.ErrorIs(t, err, ErrIncompatible, testCase.Description)
+			} else {
+				assert.NoError(t, err, testCase.Description)
+			}
+			assert.Len(t, warnings, testCase.Warnings, testCase.Description)
+		}
+	})
+
+	t.Run("Restore should refuse incompatible snapshots unless forced", func(t *testing.T) {
+		setVersion(t, "1.18.0")
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		require.NoError(t, err)
+		snapshot.AppVersion = "1.99.0"
+		require.NoError(t, manager.writeMetadataFile(snapshot))
+
+		assert.ErrorIs(t, manager.Restore(context.Background(), snapshot.Name, false), ErrIncompatible)
+		assert.NoError(t, manager.Restore(context.Background(), snapshot.Name, true))
+	})
+}
@@ -0,0 +1,66 @@
+package snapshot
+
+import (
+	"io"
+	"sync"
+)
+
+// The number of snapshot files that are copied at the same time.
+const maxParallelCopies = 4
+
+// The number of bytes the kernel is asked to copy at a time when copying a
+// file without reading it, so that progress can be reported in between.
+const copyChunkSize = 64 << 20
+
+// Progress describes how much of one file has been copied while creating or
+// restoring a snapshot.
+type Progress struct {
+	// The path of the file relative to the snapshot directory, such as
+	// "diffdisk" or a file in the directory of an optional component.
+	File string `json:"file"`
+	// The number of bytes copied so far.
+	Copied int64 `json:"copied"`
+	// The size of the file.
+	Total int64 `json:"total"`
+}
+
+// ProgressFunc is called as snapshot files are copied. Calls are never made
+// concurrently, even when several files are being copied at once.
+type ProgressFunc func(progress Progress)
+
+// Returns a ProgressFunc that serializes calls to the Manager's progress
+// callback, or one that does nothing if no callback was set.
+func (manager *Manager) progressFunc() ProgressFunc {
+	if manager.Progress == nil {
+		return func(Progress) {}
+	}
+	var mutex sync.Mutex
+	return func(progress Progress) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		manager.Progress(progress)
+	}
+}
+
+// progressWriter counts the bytes written through it and reports them.
+type progressWriter struct {
+	io.Writer
+	progress Progress
+	report   ProgressFunc
+}
+
+func newProgressWriter(writer io.Writer, file string, total int64, report ProgressFunc) *progressWriter {
+	report(Progress{File: file, Total: total})
+	return &progressWriter{
+		Writer:   writer,
+		progress: Progress{File: file, Total: total},
+		report:   report,
+	}
+}
+
+func (writer *progressWriter) Write(buf []byte) (int, error) {
+	n, err := writer.Writer.Write(buf)
+	writer.progress.Copied += int64(n)
+	writer.report(writer.progress)
+	return n, err
+}
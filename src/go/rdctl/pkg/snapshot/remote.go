@@ -0,0 +1,288 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3"
+)
+
+const remotesFileName = "remotes.json"
+
+// The region used for S3 remotes that don't name one, unless AWS_REGION is set.
+const defaultS3Region = "us-east-1"
+
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Remote is a named Storage that snapshots can be pushed to and pulled from.
+type Remote struct {
+	Name string `json:"name"`
+	// Either the path of a directory (optionally as a file: URL), or an
+	// s3://bucket/prefix URL. S3 URLs may have the query parameters
+	// "endpoint", for S3-compatible stores other than Amazon S3, and
+	// "region". Credentials are never stored; they are read from the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	// environment variables.
+	URL string `json:"url"`
+}
+
+// Validate checks that the remote has a valid name and a URL that
+// NewStorage accepts.
+func (remote Remote) Validate() error {
+	if !remoteNamePattern.MatchString(remote.Name) {
+		return fmt.Errorf("invalid remote name %q: must consist of letters, digits, '.', '_' and '-'", remote.Name)
+	}
+	_, err := parseRemoteURL(remote.URL)
+	return err
+}
+
+// Remotes returns the configured remotes.
+func (manager *Manager) Remotes() ([]Remote, error) {
+	contents, err := os.ReadFile(filepath.Join(manager.Snapshots, remotesFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Remote{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read remotes: %w", err)
+	}
+	remotes := []Remote{}
+	if err := json.Unmarshal(contents, &remotes); err != nil {
+		return nil, fmt.Errorf("failed to parse remotes: %w", err)
+	}
+	return remotes, nil
+}
+
+func (manager *Manager) saveRemotes(remotes []Remote) error {
+	if err := os.MkdirAll(manager.Snapshots, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	contents, err := json.MarshalIndent(remotes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal remotes: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(manager.Snapshots, remotesFileName), append(contents, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write remotes: %w", err)
+	}
+	return nil
+}
+
+// AddRemote adds a remote, which must not have the name of an existing one.
+func (manager *Manager) AddRemote(remote Remote) error {
+	if err := remote.Validate(); err != nil {
+		return err
+	}
+	remotes, err := manager.Remotes()
+	if err != nil {
+		return err
+	}
+	for _, existing := range remotes {
+		if existing.Name == remote.Name {
+			return fmt.Errorf("remote %q already exists", remote.Name)
+		}
+	}
+	return manager.saveRemotes(append(remotes, remote))
+}
+
+// RemoveRemote removes the remote with the given name. Snapshots stored in
+// it are left alone.
+func (manager *Manager) RemoveRemote(name string) error {
+	remotes, err := manager.Remotes()
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(remotes, func(remote Remote) bool { return remote.Name == name })
+	if index < 0 {
+		return fmt.Errorf("can't find remote %q", name)
+	}
+	return manager.saveRemotes(slices.Delete(remotes, index, index+1))
+}
+
+// RemoteStorage returns the storage of the remote with the given name.
+func (manager *Manager) RemoteStorage(name string) (Storage, error) {
+	remotes, err := manager.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range remotes {
+		if remote.Name == name {
+			return NewStorage(remote.URL)
+		}
+	}
+	return nil, fmt.Errorf("can't find remote %q", name)
+}
+
+// NewStorage returns the storage for a remote URL, as described by
+// Remote.URL.
+func NewStorage(remoteURL string) (Storage, error) {
+	parsed, err := parseRemoteURL(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed == nil {
+		return &LocalStorage{Directory: remoteURL}, nil
+	}
+	if parsed.Scheme == "file" {
+		return &LocalStorage{Directory: fileURLPath(parsed)}, nil
+	}
+	credentials := s3.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to use an S3 remote")
+	}
+	query := parsed.Query()
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+	client := &s3.Client{
+		Region:      region,
+		Bucket:      parsed.Host,
+		Credentials: credentials,
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		// Stand-ins for S3 generally don't support virtual-hosted buckets.
+		if client.Endpoint, err = url.Parse(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+		client.PathStyle = true
+	} else {
+		client.Endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("s3.%s.amazonaws.com", region)}
+	}
+	return &S3Storage{Client: client, Prefix: strings.Trim(parsed.Path, "/")}, nil
+}
+
+// Parses a remote URL, returning nil for a plain directory path.
+func parseRemoteURL(remoteURL string) (*url.URL, error) {
+	if remoteURL == "" {
+		return nil, errors.New("remote URL must not be empty")
+	}
+	if filepath.IsAbs(remoteURL) {
+		return nil, nil
+	}
+	parsed, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL %q: %w", remoteURL, err)
+	}
+	switch parsed.Scheme {
+	case "file":
+		if !filepath.IsAbs(fileURLPath(parsed)) || (parsed.Host != "" && parsed.Host != "localhost") {
+			return nil, fmt.Errorf("invalid remote URL %q: must be file:///path", remoteURL)
+		}
+	case "s3":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid remote URL %q: must name a bucket", remoteURL)
+		}
+		if parsed.User != nil {
+			return nil, fmt.Errorf("invalid remote URL %q: credentials must be given in the environment", remoteURL)
+		}
+		if endpoint := parsed.Query().Get("endpoint"); endpoint != "" {
+			endpointURL, err := url.Parse(endpoint)
+			if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+				return nil, fmt.Errorf("invalid remote URL %q: endpoint must be an http or https URL", remoteURL)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid remote URL %q: must be an absolute path, a file: URL or an s3: URL", remoteURL)
+	}
+	return parsed, nil
+}
+
+// findStored returns the snapshot in storage with the given name.
+func findStored(ctx context.Context, storage Storage, name string) (Snapshot, bool, error) {
+	snapshots, err := storage.List(ctx)
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to list remote snapshots: %w", err)
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, true, nil
+		}
+	}
+	return Snapshot{}, false, nil
+}
+
+// Returns the local path of a file: URL; on Windows, file:///C:/dir is C:\dir.
+func fileURLPath(fileURL *url.URL) string {
+	dirPath := fileURL.Path
+	if runtime.GOOS == "windows" {
+		dirPath = strings.TrimPrefix(dirPath, "/")
+	}
+	return filepath.FromSlash(dirPath)
+}
+
+// Push stores the snapshot with the given name in storage, which must not
+// already hold a snapshot with that name. The archive is streamed to the
+// storage as it is written, without a temporary copy.
+func (manager *Manager) Push(ctx context.Context, name string, storage Storage) error {
+	snapshot, err := manager.Snapshot(name)
+	if err != nil {
+		return err
+	}
+	if _, found, err := findStored(ctx, storage, name); err != nil {
+		return err
+	} else if found {
+		return fmt.Errorf("remote already has a snapshot named %q", name)
+	}
+
+	reader, writer := io.Pipe()
+	writeErr := make(chan error, 1)
+	go func() {
+		err := manager.writeArchive(ctx, snapshot, writer)
+		_ = writer.CloseWithError(err)
+		writeErr <- err
+	}()
+	err = storage.Put(ctx, snapshot, reader)
+	// Unblock the writer if the storage stopped reading early.
+	_ = reader.CloseWithError(errors.New("upload stopped"))
+	if archiveErr := <-writeErr; archiveErr != nil && err == nil {
+		err = archiveErr
+	}
+	if contextIsDone(ctx) {
+		return runner.ErrContextDone
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return nil
+}
+
+// Pull creates a local snapshot from the snapshot in storage with the given
+// name. The local snapshot is named newName, or has the same name if
+// newName is empty.
+func (manager *Manager) Pull(ctx context.Context, storage Storage, name, newName string) (Snapshot, error) {
+	stored, found, err := findStored(ctx, storage, name)
+	if err != nil {
+		return Snapshot{}, err
+	} else if !found {
+		return Snapshot{}, fmt.Errorf("can't find remote snapshot %q", name)
+	}
+	if newName == "" {
+		newName = name
+	}
+	// Check the name before downloading anything.
+	if err := manager.ValidateName(newName); err != nil {
+		return Snapshot{}, err
+	}
+	archive, err := storage.Get(ctx, stored)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	defer archive.Close()
+	return manager.readArchive(ctx, archive, newName)
+}
@@ -0,0 +1,204 @@
+//go:build unix
+
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3/s3test"
+)
+
+// Set to an s3:// remote URL, such as
+// s3://bucket/prefix?endpoint=http://localhost:9000, to also run the remote
+// tests against a real S3-compatible store such as MinIO. The credentials
+// are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY as usual.
+const testS3RemoteEnv = "RDCTL_TEST_S3_REMOTE"
+
+func testStorages(t *testing.T) map[string]func(t *testing.T) Storage {
+	storages := map[string]func(t *testing.T) Storage{
+		"local": func(t *testing.T) Storage {
+			return &LocalStorage{Directory: filepath.Join(t.TempDir(), "remote")}
+		},
+		"s3": func(t *testing.T) Storage {
+			server := s3test.NewServer()
+			t.Cleanup(server.Close)
+			client := server.Client()
+			// Make sure that archives are uploaded in several parts.
+			client.PartSize = 64
+			return &S3Storage{Client: client, Prefix: "snapshots"}
+		},
+	}
+	if remoteURL := os.Getenv(testS3RemoteEnv); remoteURL != "" {
+		storages["external s3"] = func(t *testing.T) Storage {
+			storage, err := NewStorage(remoteURL)
+			require.NoError(t, err)
+			s3Storage := storage.(*S3Storage)
+			// Keep each test's snapshots apart.
+			s3Storage.Prefix = strings.TrimPrefix(s3Storage.Prefix+"/"+strings.ReplaceAll(t.Name(), "/", "-"), "/")
+			return s3Storage
+		}
+	}
+	return storages
+}
+
+func TestRemote(t *testing.T) {
+	for storageName, newStorage := range testStorages(t) {
+		t.Run(storageName, func(t *testing.T) {
+			t.Run("Pull should recreate a pushed snapshot", func(t *testing.T) {
+				appPaths, testFiles := populateFiles(t, true)
+				manager := newTestManager(appPaths)
+				storage := newStorage(t)
+				original, err := manager.Create(context.Background(), "golden", "a description")
+				require.NoError(t, err)
+				require.NoError(t, manager.Push(context.Background(), original.Name, storage))
+
+				stored, err := storage.List(context.Background())
+				require.NoError(t, err)
+				require.Len(t, stored, 1)
+				assert.Equal(t, original.Name, stored[0].Name)
+				assert.Equal(t, original.Description, stored[0].Description)
+
+				require.NoError(t, manager.Delete(context.Background(), original.Name))
+				pulled, err := manager.Pull(context.Background(), storage, original.Name, "")
+				require.NoError(t, err)
+				assert.Equal(t, original.Name, pulled.Name)
+				assert.NotEqual(t, original.ID, pulled.ID)
+				require.NoError(t, manager.Verify(context.Background(), pulled))
+				snapshotDir := manager.SnapshotDirectory(pulled)
+				for testFileName, testFile := range testFiles {
+					contents, err := os.ReadFile(filepath.Join(snapshotDir, testFileName))
+					require.NoError(t, err)
+					assert.Equal(t, testFile.Contents, string(contents), "contents of %s", testFileName)
+				}
+			})
+
+			t.Run("Pull should use the given name", func(t *testing.T) {
+				appPaths, _ := populateFiles(t, true)
+				manager := newTestManager(appPaths)
+				storage := newStorage(t)
+				_, err := manager.Create(context.Background(), "golden", "")
+				require.NoError(t, err)
+				require.NoError(t, manager.Push(context.Background(), "golden", storage))
+
+				_, err = manager.Pull(context.Background(), storage, "golden", "")
+				assert.ErrorContains(t, err, "already exists")
+				pulled, err := manager.Pull(context.Background(), storage, "golden", "copy")
+				require.NoError(t, err)
+				assert.Equal(t, "copy", pulled.Name)
+			})
+
+			t.Run("Push should refuse a name that is already stored", func(t *testing.T) {
+				appPaths, _ := populateFiles(t, true)
+				manager := newTestManager(appPaths)
+				storage := newStorage(t)
+				_, err := manager.Create(context.Background(), "golden", "")
+				require.NoError(t, err)
+				require.NoError(t, manager.Push(context.Background(), "golden", storage))
+				assert.ErrorContains(t, manager.Push(context.Background(), "golden", storage), "already has a snapshot")
+			})
+
+			t.Run("Pull should fail for unknown snapshots", func(t *testing.T) {
+				appPaths, _ := populateFiles(t, true)
+				manager := newTestManager(appPaths)
+				_, err := manager.Pull(context.Background(), newStorage(t), "missing", "")
+				assert.ErrorContains(t, err, `can't find remote snapshot "missing"`)
+			})
+		})
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	t.Run("List should ignore incompletely stored snapshots", func(t *testing.T) {
+		storage := &LocalStorage{Directory: t.TempDir()}
+		require.NoError(t, os.MkdirAll(filepath.Join(storage.Directory, "incomplete"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(storage.Directory, "incomplete", storageArchiveName), []byte("partial"), 0o644))
+		snapshots, err := storage.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, snapshots)
+	})
+	t.Run("List should return an empty list for a missing directory", func(t *testing.T) {
+		storage := &LocalStorage{Directory: filepath.Join(t.TempDir(), "missing")}
+		snapshots, err := storage.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, snapshots)
+	})
+}
+
+func TestRemotes(t *testing.T) {
+	t.Run("Remotes should be added and removed", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, false)
+		manager := newTestManager(appPaths)
+		remotes, err := manager.Remotes()
+		require.NoError(t, err)
+		assert.Empty(t, remotes)
+
+		local := Remote{Name: "shared", URL: filepath.Join(t.TempDir(), "shared")}
+		bucket := Remote{Name: "bucket", URL: "s3://bucket/golden?endpoint=http://localhost:9000"}
+		require.NoError(t, manager.AddRemote(local))
+		require.NoError(t, manager.AddRemote(bucket))
+		assert.ErrorContains(t, manager.AddRemote(local), "already exists")
+		remotes, err = manager.Remotes()
+		require.NoError(t, err)
+		assert.Equal(t, []Remote{local, bucket}, remotes)
+
+		storage, err := manager.RemoteStorage("shared")
+		require.NoError(t, err)
+		assert.Equal(t, &LocalStorage{Directory: local.URL}, storage)
+
+		require.NoError(t, manager.RemoveRemote("shared"))
+		assert.ErrorContains(t, manager.RemoveRemote("shared"), `can't find remote "shared"`)
+		_, err = manager.RemoteStorage("shared")
+		assert.ErrorContains(t, err, `can't find remote "shared"`)
+	})
+
+	t.Run("NewStorage should configure S3 remotes", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "key")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "")
+		t.Setenv("AWS_REGION", "")
+
+		storage, err := NewStorage("s3://bucket/some/prefix/?endpoint=http://localhost:9000&region=eu-west-1")
+		require.NoError(t, err)
+		s3Storage := storage.(*S3Storage)
+		assert.Equal(t, "some/prefix", s3Storage.Prefix)
+		assert.Equal(t, "bucket", s3Storage.Client.Bucket)
+		assert.Equal(t, "eu-west-1", s3Storage.Client.Region)
+		assert.Equal(t, "http://localhost:9000", s3Storage.Client.Endpoint.String())
+		assert.True(t, s3Storage.Client.PathStyle)
+		assert.Equal(t, "key", s3Storage.Client.Credentials.AccessKeyID)
+
+		storage, err = NewStorage("s3://bucket")
+		require.NoError(t, err)
+		s3Storage = storage.(*S3Storage)
+		assert.Equal(t, "", s3Storage.Prefix)
+		assert.Equal(t, "https://s3.us-east-1.amazonaws.com", s3Storage.Client.Endpoint.String())
+		assert.False(t, s3Storage.Client.PathStyle)
+
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		_, err = NewStorage("s3://bucket")
+		assert.ErrorContains(t, err, "must be set")
+	})
+
+	t.Run("Invalid remotes should be rejected", func(t *testing.T) {
+		for _, remote := range []Remote{
+			{Name: "", URL: "/shared"},
+			{Name: "with space", URL: "/shared"},
+			{Name: "relative", URL: "shared"},
+			{Name: "scheme", URL: "https://example.com/shared"},
+			{Name: "no-bucket", URL: "s3:///prefix"},
+			{Name: "credentials", URL: "s3://key:secret@bucket"},
+			{Name: "endpoint", URL: "s3://bucket?endpoint=localhost:9000"},
+			{Name: "file-host", URL: "file://host/shared"},
+		} {
+			assert.Error(t, remote.Validate(), "remote %q with URL %q", remote.Name, remote.URL)
+		}
+		assert.NoError(t, Remote{Name: "file", URL: "file:///shared"}.Validate())
+	})
+}
@@ -0,0 +1,248 @@
+//go:build unix
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+const (
+	// Appended to a working path to get the path that restored files are
+	// staged at before they are swapped in.
+	restoreStagingSuffix = ".restore"
+	// Appended to a working path to get the path that the files replaced by
+	// a restore are kept at until the restore is committed or rolled back.
+	restoreBackupSuffix = ".backup"
+	// Appended to a working path to get the path of a marker recording that
+	// nothing existed at the working path before a restore swapped something
+	// into it.
+	restoreAbsentSuffix = ".absent"
+)
+
+// Returns the path that a working file is staged at during a restore. Files
+// in the Lima directory are staged in a copy of that directory, so that the
+// whole directory can be swapped in with a single rename.
+func stagingPath(appPaths *paths.Paths, workingPath string) string {
+	if relPath, ok := limaRelativePath(appPaths, workingPath); ok {
+		return filepath.Join(appPaths.Lima+restoreStagingSuffix, relPath)
+	}
+	return workingPath + restoreStagingSuffix
+}
+
+// Returns the paths that are renamed when staged files are swapped in: the
+// Lima directory, followed by each file or directory that lives outside of
+// it.
+func swappedPaths(appPaths *paths.Paths, files []snapshotFile) []string {
+	result := []string{appPaths.Lima}
+	for _, file := range files {
+		if _, ok := limaRelativePath(appPaths, file.WorkingPath); !ok {
+			result = append(result, file.WorkingPath)
+		}
+	}
+	return result
+}
+
+func limaRelativePath(appPaths *paths.Paths, workingPath string) (string, bool) {
+	relPath, err := filepath.Rel(appPaths.Lima, workingPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relPath, true
+}
+
+// Cleans up after a restore that was interrupted before it could be
+// committed or rolled back. Anything swapped in is assumed to be complete,
+// so leftover backups are discarded; if the swap itself was interrupted, the
+// backups are put back so that the working files are left in a known state.
+func recoverInterruptedRestore(appPaths *paths.Paths) error {
+	files := SnapshotterImpl{}.Files(appPaths, "", OptionalComponents)
+	for _, workingPath := range swappedPaths(appPaths, files) {
+		if err := os.RemoveAll(stagingPath(appPaths, workingPath)); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(workingPath + restoreAbsentSuffix); err != nil {
+			return err
+		}
+		backupPath := workingPath + restoreBackupSuffix
+		if _, err := os.Lstat(backupPath); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(workingPath); errors.Is(err, os.ErrNotExist) {
+			if err := os.Rename(backupPath, workingPath); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if err := os.RemoveAll(backupPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recreates the tree at srcDir in dstDir, hard linking regular files where
+// possible and copying them otherwise. Paths in skip are left out, as are
+// sockets and other special files. If srcDir does not exist, dstDir is
+// created empty.
+func linkTree(dstDir, srcDir string, skip map[string]bool) error {
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return err
+	}
+	return filepath.WalkDir(srcDir, func(path string, dirEntry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == srcDir {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if skip[path] {
+			return nil
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dstDir, relPath)
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.IsDir():
+			if relPath == "." {
+				return os.Chmod(dstDir, info.Mode().Perm())
+			}
+			return os.Mkdir(dstPath, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dstPath)
+		case info.Mode().IsRegular():
+			if err := os.Link(path, dstPath); err == nil {
+				return nil
+			}
+			return copyRegularFile(dstPath, path, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyRegularFile(dst, src string, fileMode os.FileMode) error {
+	srcFd, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFd.Close()
+	dstFd, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFd, srcFd); err != nil {
+		dstFd.Close()
+		return err
+	}
+	return dstFd.Close()
+}
+
+// Removes everything staged by RestoreFiles.
+func discardStagedFiles(appPaths *paths.Paths, files []snapshotFile) {
+	for _, workingPath := range swappedPaths(appPaths, files) {
+		_ = os.RemoveAll(stagingPath(appPaths, workingPath))
+	}
+}
+
+// Moves the working files out of the way and the staged files into their
+// place. If any rename fails, the ones that were done are undone, so that
+// either all of the staged files are swapped in or none are.
+func swapInStagedFiles(appPaths *paths.Paths, files []snapshotFile) error {
+	swapped := []string{}
+	for _, workingPath := range swappedPaths(appPaths, files) {
+		if err := swap(stagingPath(appPaths, workingPath), workingPath); err != nil {
+			for _, swappedPath := range swapped {
+				_ = unswap(swappedPath)
+			}
+			return fmt.Errorf("failed to swap in restored %q: %w", filepath.Base(workingPath), err)
+		}
+		swapped = append(swapped, workingPath)
+	}
+	return nil
+}
+
+// Moves the working path out of the way and the staged path into its place.
+// If nothing was staged, because the snapshot does not have the file, the
+// working path is only moved out of the way.
+func swap(stagedPath, workingPath string) error {
+	backupPath := workingPath + restoreBackupSuffix
+	absentPath := workingPath + restoreAbsentSuffix
+	if err := os.RemoveAll(backupPath); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(absentPath); err != nil {
+		return err
+	}
+	staged := true
+	if _, err := os.Lstat(stagedPath); errors.Is(err, os.ErrNotExist) {
+		staged = false
+	} else if err != nil {
+		return err
+	}
+	if err := os.Rename(workingPath, backupPath); errors.Is(err, os.ErrNotExist) {
+		if !staged {
+			return nil
+		}
+		// Record that there is nothing to put back, so that a rollback
+		// removes what is swapped in.
+		if err := os.WriteFile(absentPath, []byte{}, 0o644); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if !staged {
+		return nil
+	}
+	if err := os.Rename(stagedPath, workingPath); err != nil {
+		_ = unswap(workingPath)
+		return err
+	}
+	return nil
+}
+
+// Undoes swap: replaces a swapped-in working path with its backup, or removes
+// it if nothing existed at that path before. Paths that were not swapped are
+// left alone.
+func unswap(workingPath string) error {
+	backupPath := workingPath + restoreBackupSuffix
+	absentPath := workingPath + restoreAbsentSuffix
+	if _, err := os.Lstat(backupPath); err == nil {
+		if err := os.RemoveAll(workingPath); err != nil {
+			return fmt.Errorf("failed to remove restored %q: %w", filepath.Base(workingPath), err)
+		}
+		if err := os.Rename(backupPath, workingPath); err != nil {
+			return fmt.Errorf("failed to put back previous %q: %w", filepath.Base(workingPath), err)
+		}
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if _, err := os.Lstat(absentPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(workingPath); err != nil {
+		return fmt.Errorf("failed to remove restored %q: %w", filepath.Base(workingPath), err)
+	}
+	return os.Remove(absentPath)
+}
@@ -0,0 +1,315 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+)
+
+const retentionPolicyFileName = "retention.json"
+
+// Incomplete snapshot directories that were modified more recently than
+// this are left alone, as they may belong to an operation in progress.
+const incompleteGracePeriod = time.Hour
+
+var ageDaysPattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// RetentionPolicy describes which snapshots Prune should delete. When both
+// rules are set, a snapshot is only deleted if both apply to it.
+type RetentionPolicy struct {
+	// Keep this many of the most recently created snapshots; zero means
+	// no limit.
+	KeepLast int `json:"keepLast,omitempty"`
+	// Only delete snapshots older than this, in a form accepted by
+	// ParseAge; empty means no limit.
+	OlderThan string `json:"olderThan,omitempty"`
+	// Only apply the rules to automatic snapshots, so that snapshots taken
+	// by the user are always kept. KeepLast then counts automatic
+	// snapshots only.
+	AutomaticOnly bool `json:"automaticOnly,omitempty"`
+}
+
+// IsEmpty reports whether the policy has no rules, and so would not delete
+// any complete snapshots.
+func (policy RetentionPolicy) IsEmpty() bool {
+	return policy.KeepLast <= 0 && policy.OlderThan == ""
+}
+
+// Validate checks that the policy can be applied.
+func (policy RetentionPolicy) Validate() error {
+	if policy.KeepLast < 0 {
+		return fmt.Errorf("invalid keep-last value %d: must not be negative", policy.KeepLast)
+	}
+	if policy.OlderThan != "" {
+		if _, err := ParseAge(policy.OlderThan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAge parses a duration such as "30d", "2w" or "12h". In addition to
+// the units accepted by time.ParseDuration, "d" (days) and "w" (weeks) are
+// supported.
+func ParseAge(age string) (time.Duration, error) {
+	if matches := ageDaysPattern.FindStringSubmatch(age); matches != nil {
+		count, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q: %w", age, err)
+		}
+		days := count
+		if matches[2] == "w" {
+			days *= 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q: must be a number followed by a unit such as d, w or h", age)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid age %q: must not be negative", age)
+	}
+	return duration, nil
+}
+
+// PruneResult describes what Prune deleted (or would delete, in a dry run).
+type PruneResult struct {
+	// Complete snapshots matched by the retention policy.
+	Snapshots []Snapshot `json:"snapshots"`
+	// Names of directories in the snapshots directory that hold incomplete
+	// snapshots left behind by interrupted operations.
+	Incomplete []string `json:"incomplete"`
+}
+
+// LoadRetentionPolicy returns the saved retention policy, or nil if none
+// has been saved.
+func (manager *Manager) LoadRetentionPolicy() (*RetentionPolicy, error) {
+	contents, err := os.ReadFile(filepath.Join(manager.Snapshots, retentionPolicyFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read retention policy: %w", err)
+	}
+	policy := &RetentionPolicy{}
+	if err := json.Unmarshal(contents, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse retention policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SaveRetentionPolicy saves a retention policy so that it is applied after
+// each snapshot is created. Saving an empty policy removes the saved policy.
+func (manager *Manager) SaveRetentionPolicy(policy RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	policyPath := filepath.Join(manager.Snapshots, retentionPolicyFileName)
+	if policy.IsEmpty() {
+		if err := os.Remove(policyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove retention policy: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(manager.Snapshots, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	contents, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal retention policy: %w", err)
+	}
+	if err := os.WriteFile(policyPath, append(contents, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write retention policy: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes the complete snapshots selected by policy, along with any
+// incomplete snapshots left behind by interrupted operations. Snapshots that
+// incremental snapshots depend on are not deleted. The lock is held while
+// deleting, so Prune fails if another operation holds it. If dryRun is
+// true, nothing is deleted, but the result describes what would be.
+func (manager *Manager) Prune(ctx context.Context, policy RetentionPolicy, dryRun bool) (_ PruneResult, err error) {
+	if err := policy.Validate(); err != nil {
+		return PruneResult{}, err
+	}
+	result := PruneResult{Snapshots: []Snapshot{}, Incomplete: []string{}}
+	if dryRun {
+		return manager.pruneDryRun(policy)
+	}
+	if err := manager.LockMetadata(manager.Paths, "Pruning snapshots"); err != nil {
+		return result, err
+	}
+	defer func() {
+		unlockErr := manager.Unlock(ctx, manager.Paths, false)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+	candidates, err := manager.pruneCandidates(policy, time.Now())
+	if err != nil {
+		return result, err
+	}
+	// Snapshots being created or restored hold the lock, so nothing can be
+	// working on the incomplete snapshots now.
+	incomplete, err := manager.staleIncompleteDirectories(time.Now())
+	if err != nil {
+		return result, err
+	}
+	var errs []error
+	for _, candidate := range candidates {
+		if err := manager.deleteLocked(candidate.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete snapshot %q: %w", candidate.Name, err))
+			continue
+		}
+		result.Snapshots = append(result.Snapshots, candidate)
+	}
+	for _, dirName := range incomplete {
+		if err := os.RemoveAll(filepath.Join(manager.Snapshots, dirName)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove incomplete snapshot %q: %w", dirName, err))
+			continue
+		}
+		result.Incomplete = append(result.Incomplete, dirName)
+	}
+	return result, errors.Join(errs...)
+}
+
+// pruneDryRun returns what Prune would delete, without taking the lock.
+func (manager *Manager) pruneDryRun(policy RetentionPolicy) (PruneResult, error) {
+	result := PruneResult{Incomplete: []string{}}
+	var err error
+	if result.Snapshots, err = manager.pruneCandidates(policy, time.Now()); err != nil {
+		return result, err
+	}
+	// Snapshots being created or restored hold the lock; incomplete
+	// snapshots are only stale if nothing holds it.
+	locked, err := lock.IsLocked(manager.Paths)
+	if err != nil || locked {
+		return result, err
+	}
+	result.Incomplete, err = manager.staleIncompleteDirectories(time.Now())
+	return result, err
+}
+
+// pruneCandidates returns the complete snapshots selected by policy, oldest
+// first.
+func (manager *Manager) pruneCandidates(policy RetentionPolicy, now time.Time) ([]Snapshot, error) {
+	candidates := []Snapshot{}
+	if policy.IsEmpty() {
+		return candidates, nil
+	}
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	// Snapshots that incremental snapshots depend on are kept.
+	parents := map[string]bool{}
+	for _, aSnapshot := range snapshots {
+		parents[aSnapshot.Parent] = true
+	}
+	if policy.AutomaticOnly {
+		snapshots = slices.DeleteFunc(snapshots, func(aSnapshot Snapshot) bool { return !aSnapshot.Automatic })
+	}
+	// Newest first, so that the first KeepLast entries are the ones kept.
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	var maxAge time.Duration
+	if policy.OlderThan != "" {
+		if maxAge, err = ParseAge(policy.OlderThan); err != nil {
+			return nil, err
+		}
+	}
+	for i, aSnapshot := range snapshots {
+		if policy.KeepLast > 0 && i < policy.KeepLast {
+			continue
+		}
+		if policy.OlderThan != "" && now.Sub(aSnapshot.Created) <= maxAge {
+			continue
+		}
+		if parents[aSnapshot.ID] {
+			continue
+		}
+		candidates = append(candidates, aSnapshot)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Created.Before(candidates[j].Created)
+	})
+	return candidates, nil
+}
+
+// staleIncompleteDirectories returns the names of directories in the
+// snapshots directory that hold incomplete snapshots (or staged imports)
+// that no running operation can still be working on. Must be called while
+// nothing else holds the lock.
+func (manager *Manager) staleIncompleteDirectories(now time.Time) ([]string, error) {
+	stale := []string{}
+	dirEntries, err := os.ReadDir(manager.Snapshots)
+	if errors.Is(err, os.ErrNotExist) {
+		return stale, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		name := dirEntry.Name()
+		dirPath := filepath.Join(manager.Snapshots, name)
+		if _, err := uuid.Parse(name); err == nil {
+			if _, err := os.Stat(filepath.Join(dirPath, completeFileName)); err == nil {
+				continue
+			}
+		} else if !strings.HasPrefix(name, importStagingPrefix) {
+			continue
+		}
+		modified, err := latestModification(dirPath)
+		if err != nil {
+			return nil, err
+		}
+		if now.Sub(modified) < incompleteGracePeriod {
+			continue
+		}
+		stale = append(stale, name)
+	}
+	return stale, nil
+}
+
+// latestModification returns the most recent modification time of a
+// directory or any file directly inside it.
+func latestModification(dirPath string) (time.Time, error) {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	latest := info.ModTime()
+	dirEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
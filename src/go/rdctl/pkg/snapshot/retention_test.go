@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/lock"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// createAgedSnapshots creates one snapshot per age, named after its index,
+// with its creation time set that far in the past.
+func createAgedSnapshots(t *testing.T, manager *Manager, ages ...time.Duration) []Snapshot {
+	snapshots := make([]Snapshot, 0, len(ages))
+	for i, age := range ages {
+		snapshot, err := manager.Create(context.Background(), fmt.Sprintf("snapshot-%d", i), "")
+		require.NoError(t, err)
+		snapshot.Created = time.Now().Add(-age)
+		require.NoError(t, manager.writeMetadataFile(snapshot))
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+func snapshotNames(snapshots []Snapshot) []string {
+	names := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names
+}
+
+func TestParseAge(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for input, expected := range testCases {
+		actual, err := ParseAge(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, actual, input)
+		}
+	}
+	for _, input := range []string{"", "d", "30", "-1h", "1y"} {
+		_, err := ParseAge(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	testCases := []struct {
+		Description string
+		Policy      RetentionPolicy
+		Expected    []string
+	}{
+		{
+			Description: "empty policy",
+			Policy:      RetentionPolicy{},
+			Expected:    []string{},
+		},
+		{
+			Description: "keep last",
+			Policy:      RetentionPolicy{KeepLast: 2},
+			Expected:    []string{"snapshot-0", "snapshot-1"},
+		},
+		{
+			Description: "older than",
+			Policy:      RetentionPolicy{OlderThan: "7d"},
+			Expected:    []string{"snapshot-0", "snapshot-1"},
+		},
+		{
+			Description: "keep last and older than",
+			Policy:      RetentionPolicy{KeepLast: 3, OlderThan: "7d"},
+			Expected:    []string{"snapshot-0"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			appPaths, _ := populateFiles(t, true)
+			manager := newTestManager(appPaths)
+			createAgedSnapshots(t, manager, 30*day, 10*day, 3*day, time.Hour)
+
+			dryRunResult, err := manager.Prune(context.Background(), testCase.Policy, true)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.Expected, snapshotNames(dryRunResult.Snapshots))
+			remaining, err := manager.List(false)
+			require.NoError(t, err)
+			assert.Len(t, remaining, 4, "dry run should not delete snapshots")
+
+			result, err := manager.Prune(context.Background(), testCase.Policy, false)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.Expected, snapshotNames(result.Snapshots))
+			remaining, err = manager.List(false)
+			require.NoError(t, err)
+			assert.Len(t, remaining, 4-len(testCase.Expected))
+		})
+	}
+
+	t.Run("Prune should remove stale incomplete snapshots", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		snapshots := createAgedSnapshots(t, manager, time.Hour, time.Hour)
+		require.NoError(t, os.Remove(filepath.Join(manager.SnapshotDirectory(snapshots[0]), completeFileName)))
+		stagingDir := filepath.Join(manager.Snapshots, importStagingPrefix+"test")
+		require.NoError(t, os.MkdirAll(stagingDir, 0o755))
+
+		stale, err := manager.staleIncompleteDirectories(time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, stale, "recently modified directories should be kept")
+
+		stale, err = manager.staleIncompleteDirectories(time.Now().Add(2 * incompleteGracePeriod))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{snapshots[0].ID, filepath.Base(stagingDir)}, stale)
+	})
+
+	t.Run("Prune should not remove incomplete snapshots while the backend is locked", func(t *testing.T) {
+		appPaths, _ := populateFiles(t, true)
+		manager := newTestManager(appPaths)
+		stagingDir := filepath.Join(manager.Snapshots, importStagingPrefix+"test")
+		require.NoError(t, os.MkdirAll(stagingDir, 0o755))
+		require.NoError(t, os.Chtimes(stagingDir, time.Now().Add(-2*incompleteGracePeriod), time.Now().Add(-2*incompleteGracePeriod)))
+		require.NoError(t, os.WriteFile(filepath.Join(appPaths.AppHome, "backend.lock"), []byte("{}"), 0o644))
+		manager.BackendLocker = &lock.BackendLock{}
+
+		dryRunResult, err := manager.Prune(context.Background(), RetentionPolicy{}, true)
+		require.NoError(t, err)
+		assert.Empty(t, dryRunResult.Incomplete)
+		_, err = manager.Prune(context.Background(), RetentionPolicy{}, false)
+		assert.Error(t, err)
+		assert.DirExists(t, stagingDir)
+	})
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	t.Run("Saved policies should round-trip", func(t *testing.T) {
+		manager := newTestManager(&paths.Paths{Snapshots: t.TempDir()})
+		policy, err := manager.LoadRetentionPolicy()
+		require.NoError(t, err)
+		assert.Nil(t, policy)
+
+		expected := RetentionPolicy{KeepLast: 5, OlderThan: "30d"}
+		require.NoError(t, manager.SaveRetentionPolicy(expected))
+		policy, err = manager.LoadRetentionPolicy()
+		require.NoError(t, err)
+		assert.Equal(t, &expected, policy)
+
+		require.NoError(t, manager.SaveRetentionPolicy(RetentionPolicy{}))
+		policy, err = manager.LoadRetentionPolicy()
+		require.NoError(t, err)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("Invalid policies should not be saved", func(t *testing.T) {
+		manager := newTestManager(&paths.Paths{Snapshots: t.TempDir()})
+		assert.Error(t, manager.SaveRetentionPolicy(RetentionPolicy{OlderThan: "soon"}))
+		assert.Error(t, manager.SaveRetentionPolicy(RetentionPolicy{KeepLast: -1}))
+	})
+}
@@ -10,6 +10,36 @@ type Snapshot struct {
 	Name        string    `json:"name"`
 	ID          string    `json:"id,omitempty"`
 	Description string    `json:"description"`
+	// Set for snapshots that were taken automatically before a destructive
+	// operation, rather than by the user.
+	Automatic bool `json:"automatic,omitempty"`
+	// Labels for organizing snapshots, which can be used to select them.
+	Labels map[string]string `json:"labels,omitempty"`
+	// For incremental snapshots, the ID of the snapshot whose VM disk
+	// backs the VM disk of this one.
+	Parent string `json:"parent,omitempty"`
+	// The version of Rancher Desktop that created the snapshot.
+	AppVersion string `json:"appVersion,omitempty"`
+	// The version of the settings.json format in the snapshot.
+	SettingsVersion int `json:"settingsVersion,omitempty"`
+	// The container engine selected in the snapshot's settings.
+	ContainerEngine string              `json:"containerEngine,omitempty"`
+	Kubernetes      *KubernetesMetadata `json:"kubernetes,omitempty"`
+	// The size in bytes of each disk image in the snapshot.
+	DiskSizes map[string]int64 `json:"diskSizes,omitempty"`
+	// The size and checksum of each file in the snapshot, recorded when
+	// the snapshot was created.
+	Files []SnapshotFile `json:"files,omitempty"`
+}
+
+// SnapshotFile records the size and checksum of a file in a snapshot.
+type SnapshotFile struct {
+	// The name of the file, relative to the snapshot directory.
+	Name string `json:"name"`
+	// The size of the file in bytes.
+	Size int64 `json:"size"`
+	// The hex-encoded SHA-256 checksum of the file.
+	SHA256 string `json:"sha256"`
 }
 
 func (s *Snapshot) getTimeString() string {
@@ -3,6 +3,7 @@ package snapshot
 import (
 	"context"
 	"errors"
+	"os"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
 )
@@ -13,15 +14,48 @@ import (
 type Snapshotter interface {
 	// Does all of the things that can fail when creating a snapshot,
 	// so that the snapshot creation can easily be rolled back upon
-	// a failure.
-	CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error
+	// a failure. The optional components are included in addition to
+	// the files that are always included. Progress copying each file is
+	// reported to report.
+	CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, components []string, report ProgressFunc) error
 	// Like CreateFiles, but for restoring: does all of the things
 	// that can fail when restoring a snapshot so that restoration can
-	// easily be rolled back in the event of a failure. Returns ErrDataReset
-	// when data has been reset due to an error in this process.
-	RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error
+	// easily be rolled back in the event of a failure. Only the optional
+	// components recorded in the snapshot's contents.json are put back.
+	// Where possible, the files are staged first and the working files
+	// are only replaced once everything has been copied; the replaced
+	// files are kept until CommitRestore or RollbackRestore is called.
+	// Returns ErrDataReset when data has been reset due to an error in
+	// this process.
+	RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, report ProgressFunc) error
+	// Discards the files that the last successful RestoreFiles replaced.
+	CommitRestore(appPaths *paths.Paths) error
+	// Puts back the files that the last successful RestoreFiles replaced.
+	RollbackRestore(appPaths *paths.Paths) error
 }
 
 // Returned by Snapshotter.RestoreFiles when data has been reset
 // due to an error restoring the files.
 var ErrDataReset = errors.New("data reset")
+
+// Describes a file in a snapshot directory that holds part of the
+// snapshot's state, as opposed to bookkeeping files like complete.txt.
+type contentFile struct {
+	// The name of the file, relative to the snapshot directory.
+	Name string
+	// Whether it is ok for the file to not be present.
+	MissingOk bool
+	// The permissions the file should have.
+	FileMode os.FileMode
+	// Whether the file is a disk image.
+	Disk bool
+	// Whether this is a directory, the whole tree of which is part of
+	// the snapshot.
+	Dir bool
+	// The optional component the file belongs to; empty if it is always
+	// included.
+	Component string
+	// For a symbolic link in a directory, the slash-separated path it
+	// points to.
+	LinkTarget string
+}
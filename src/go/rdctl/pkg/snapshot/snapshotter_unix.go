@@ -26,6 +26,14 @@ type snapshotFile struct {
 	MissingOk bool
 	// The permissions the file should have.
 	FileMode os.FileMode
+	// Whether this is a directory, which is copied with everything in it.
+	IsDir bool
+	// The optional component the file belongs to; empty if it is always
+	// included.
+	Component string
+	// Whether this is the VM disk, which incremental snapshots store as a
+	// qcow2 overlay rather than as a copy.
+	Incremental bool
 }
 
 // SnapshotterImpl also works as a *Manager receiver
@@ -36,7 +44,9 @@ func NewSnapshotterImpl() Snapshotter {
 	return SnapshotterImpl{}
 }
 
-func (snapshotter SnapshotterImpl) Files(appPaths *paths.Paths, snapshotDir string) []snapshotFile {
+// Returns the files that make up a snapshot that includes the given
+// optional components.
+func (snapshotter SnapshotterImpl) Files(appPaths *paths.Paths, snapshotDir string, components []string) []snapshotFile {
 	files := []snapshotFile{
 		{
 			WorkingPath:  filepath.Join(appPaths.Config, "settings.json"),
@@ -65,6 +75,7 @@ func (snapshotter SnapshotterImpl) Files(appPaths *paths.Paths, snapshotDir stri
 			CopyOnWrite:  true,
 			MissingOk:    false,
 			FileMode:     0o644,
+			Incremental:  true,
 		},
 		{
 			WorkingPath:  filepath.Join(appPaths.Lima, "_config", "user"),
@@ -88,15 +99,100 @@ func (snapshotter SnapshotterImpl) Files(appPaths *paths.Paths, snapshotDir stri
 			FileMode:     0o644,
 		},
 	}
+	for _, dir := range selectedComponentDirectories(appPaths, components) {
+		files = append(files, snapshotFile{
+			WorkingPath:  dir.WorkingPath,
+			SnapshotPath: filepath.Join(snapshotDir, dir.Name),
+			CopyOnWrite:  false,
+			MissingOk:    true,
+			FileMode:     0o755,
+			IsDir:        true,
+			Component:    dir.Component,
+		})
+	}
 	return files
 }
 
-func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error {
-	taskRunner := runner.NewTaskRunner(ctx)
-	files := snapshotter.Files(appPaths, snapshotDir)
+// Returns the files that make up the contents of a snapshot directory,
+// including those of every optional component.
+func contentFiles(appPaths *paths.Paths) []contentFile {
+	files := SnapshotterImpl{}.Files(appPaths, "", OptionalComponents)
+	result := make([]contentFile, 0, len(files))
+	for _, file := range files {
+		result = append(result, contentFile{
+			Name:      file.SnapshotPath,
+			MissingOk: file.MissingOk,
+			FileMode:  file.FileMode,
+			Disk:      file.CopyOnWrite,
+			Dir:       file.IsDir,
+			Component: file.Component,
+		})
+	}
+	return result
+}
+
+// Returns the configuration files that Diff compares.
+func diffFiles(appPaths *paths.Paths) []diffFile {
+	return []diffFile{
+		{Name: "settings.json", WorkingPath: filepath.Join(appPaths.Config, "settings.json"), Format: formatJSON},
+		{Name: "override.yaml", WorkingPath: filepath.Join(appPaths.Lima, "_config", "override.yaml"), Format: formatYAML},
+	}
+}
+
+// Returns the working paths that RestoreFiles replaces when restoring a
+// snapshot that includes the given optional components.
+func restoredPaths(appPaths *paths.Paths, components []string) []string {
+	files := SnapshotterImpl{}.Files(appPaths, "", components)
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		result = append(result, file.WorkingPath)
+	}
+	return result
+}
+
+// Copies a file, or a directory and everything in it, from src to dst.
+func copySnapshotFile(dst, src string, file snapshotFile, report ProgressFunc) error {
+	name := filepath.Base(file.SnapshotPath)
+	if !file.IsDir {
+		return copyFile(dst, src, name, file.CopyOnWrite, file.FileMode, report)
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return copyTree(dst, src, func(fileDst, fileSrc string, fileMode os.FileMode) error {
+		relPath, err := filepath.Rel(src, fileSrc)
+		if err != nil {
+			return err
+		}
+		return copyFile(fileDst, fileSrc, filepath.Join(name, relPath), file.CopyOnWrite, fileMode, report)
+	})
+}
+
+// Copies the file, unless it is the VM disk and writeDisk is set, in which
+// case writeDisk writes it instead.
+func writeSnapshotFile(dst, src string, file snapshotFile, report ProgressFunc, writeDisk diskWriter) error {
+	if file.Incremental && writeDisk != nil {
+		return writeDisk(dst, src, report)
+	}
+	return copySnapshotFile(dst, src, file, report)
+}
+
+func (snapshotter SnapshotterImpl) copyDisk(dst, src string) error {
+	return copyFile(dst, src, filepath.Base(dst), true, 0o644, func(Progress) {})
+}
+
+func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, components []string, report ProgressFunc) error {
+	return snapshotter.createFiles(ctx, appPaths, snapshotDir, components, report, nil)
+}
+
+// Like CreateFiles, but if writeDisk is not nil, it writes the VM disk
+// instead of the disk being copied.
+func (snapshotter SnapshotterImpl) createFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, components []string, report ProgressFunc, writeDisk diskWriter) error {
+	taskRunner := runner.NewParallelTaskRunner(ctx, maxParallelCopies)
+	files := snapshotter.Files(appPaths, snapshotDir, components)
 	for _, file := range files {
 		taskRunner.Add(func() error {
-			err := copyFile(file.SnapshotPath, file.WorkingPath, file.CopyOnWrite, file.FileMode)
+			err := writeSnapshotFile(file.SnapshotPath, file.WorkingPath, file, report, writeDisk)
 			if errors.Is(err, os.ErrNotExist) && file.MissingOk {
 				return nil
 			} else if err != nil {
@@ -106,32 +202,51 @@ func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *pa
 		})
 	}
 
-	// Create complete.txt file. This is done last because its presence
-	// signifies a complete and valid snapshot.
-	taskRunner.Add(func() error {
-		completeFilePath := filepath.Join(snapshotDir, completeFileName)
-		if err := os.WriteFile(completeFilePath, []byte(completeFileContents), 0o644); err != nil {
-			return fmt.Errorf("failed to write %q: %w", completeFileName, err)
-		}
-		return nil
-	})
-
 	return taskRunner.Wait()
 }
 
 // Restores the files from their location in a snapshot directory
-// to their working location.
-func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error {
-	taskRunner := runner.NewTaskRunner(ctx)
-	files := snapshotter.Files(appPaths, snapshotDir)
+// to their working location. The Lima directory is rebuilt in a staging
+// directory next to it, and is swapped in only after every file has been
+// copied; the previous Lima directory, settings and component directories
+// are kept until CommitRestore or RollbackRestore is called. Components
+// that the snapshot does not include are left alone.
+func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, report ProgressFunc) error {
+	return snapshotter.restoreFiles(ctx, appPaths, snapshotDir, report, nil)
+}
+
+// Like RestoreFiles, but if writeDisk is not nil, it writes the VM disk
+// instead of the disk being copied.
+func (snapshotter SnapshotterImpl) restoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, report ProgressFunc, writeDisk diskWriter) error {
+	contents, err := readContentsFile(appPaths, snapshotDir)
+	if err != nil {
+		return err
+	}
+	if err := recoverInterruptedRestore(appPaths); err != nil {
+		return fmt.Errorf("failed to clean up after previous restore: %w", err)
+	}
+	files := snapshotter.Files(appPaths, snapshotDir, contents.Components)
+	stagingLima := appPaths.Lima + restoreStagingSuffix
+	managed := make(map[string]bool, len(files))
+	for _, file := range files {
+		managed[file.WorkingPath] = true
+	}
+
+	// Carry over the files in the Lima directory that are not part of
+	// the snapshot, such as logs and network configuration.
+	if err := linkTree(stagingLima, appPaths.Lima, managed); err != nil {
+		discardStagedFiles(appPaths, files)
+		return fmt.Errorf("failed to stage Lima directory: %w", err)
+	}
+
+	taskRunner := runner.NewParallelTaskRunner(ctx, maxParallelCopies)
 	for _, file := range files {
 		taskRunner.Add(func() error {
 			filename := filepath.Base(file.WorkingPath)
-			err := copyFile(file.WorkingPath, file.SnapshotPath, file.CopyOnWrite, file.FileMode)
+			stagedPath := stagingPath(appPaths, file.WorkingPath)
+			err := writeSnapshotFile(stagedPath, file.SnapshotPath, file, report, writeDisk)
 			if errors.Is(err, os.ErrNotExist) && file.MissingOk {
-				if err := os.RemoveAll(file.WorkingPath); err != nil {
-					return fmt.Errorf("failed to remove %q: %w", filename, err)
-				}
+				return nil
 			} else if err != nil {
 				return fmt.Errorf("failed to restore %q: %w", filename, err)
 			}
@@ -139,11 +254,41 @@ func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *p
 		})
 	}
 	if err := taskRunner.Wait(); err != nil {
-		for _, file := range files {
-			_ = os.Remove(file.WorkingPath)
-		}
-		_ = os.RemoveAll(appPaths.Lima)
-		return fmt.Errorf("%w: %w", ErrDataReset, err)
+		// The working files have not been touched; just discard the
+		// staged files.
+		discardStagedFiles(appPaths, files)
+		return err
+	}
+	if err := swapInStagedFiles(appPaths, files); err != nil {
+		discardStagedFiles(appPaths, files)
+		return err
 	}
 	return nil
 }
+
+// Discards the previous state kept by RestoreFiles.
+func (snapshotter SnapshotterImpl) CommitRestore(appPaths *paths.Paths) error {
+	files := snapshotter.Files(appPaths, "", OptionalComponents)
+	var errs []error
+	for _, workingPath := range swappedPaths(appPaths, files) {
+		if err := os.RemoveAll(workingPath + restoreBackupSuffix); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove previous %q: %w", filepath.Base(workingPath), err))
+		}
+		if err := os.RemoveAll(workingPath + restoreAbsentSuffix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Puts back the previous state kept by RestoreFiles.
+func (snapshotter SnapshotterImpl) RollbackRestore(appPaths *paths.Paths) error {
+	files := snapshotter.Files(appPaths, "", OptionalComponents)
+	var errs []error
+	for _, workingPath := range swappedPaths(appPaths, files) {
+		if err := unswap(workingPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
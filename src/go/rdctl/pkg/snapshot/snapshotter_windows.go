@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -38,11 +39,66 @@ func (snapshotter SnapshotterImpl) WSLDistros(appPaths *paths.Paths) []wslDistro
 	}
 }
 
+// Returns the files that make up the contents of a snapshot directory,
+// including those of every optional component.
+func contentFiles(appPaths *paths.Paths) []contentFile {
+	files := []contentFile{{Name: "settings.json", FileMode: 0o644}}
+	for _, distro := range (SnapshotterImpl{}).WSLDistros(appPaths) {
+		files = append(files, contentFile{Name: distro.Name + ".tar", FileMode: 0o644, Disk: true})
+	}
+	for _, dir := range componentDirectories(appPaths) {
+		files = append(files, contentFile{Name: dir.Name, MissingOk: true, FileMode: 0o755, Dir: true, Component: dir.Component})
+	}
+	return files
+}
+
+// Returns the configuration files that Diff compares.
+func diffFiles(appPaths *paths.Paths) []diffFile {
+	return []diffFile{
+		{Name: "settings.json", WorkingPath: filepath.Join(appPaths.Config, "settings.json"), Format: formatJSON},
+	}
+}
+
+// Returns the working paths that RestoreFiles replaces when restoring a
+// snapshot that includes the given optional components.
+func restoredPaths(appPaths *paths.Paths, components []string) []string {
+	result := []string{filepath.Join(appPaths.Config, "settings.json")}
+	for _, distro := range (SnapshotterImpl{}).WSLDistros(appPaths) {
+		result = append(result, distro.WorkingDirPath)
+	}
+	for _, dir := range selectedComponentDirectories(appPaths, components) {
+		result = append(result, dir.WorkingPath)
+	}
+	return result
+}
+
+// Replaces the tree at dst with a copy of the one at src. If src does not
+// exist, dst is only removed. Progress is reported under name, the path of
+// the tree relative to the snapshot directory.
+func replaceTree(dst, src, name string, report ProgressFunc) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	err := copyTree(dst, src, func(fileDst, fileSrc string, _ os.FileMode) error {
+		relPath, err := filepath.Rel(src, fileSrc)
+		if err != nil {
+			return err
+		}
+		return copyFile(fileDst, fileSrc, filepath.Join(name, relPath), report)
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		if _, statErr := os.Stat(src); errors.Is(statErr, os.ErrNotExist) {
+			return nil
+		}
+	}
+	return err
+}
+
 // Note: on Windows, there are system calls such as CopyFile and CopyFileEx
 // that may speed up the process of copying a file, but they appear to require
 // loading DLL's. This approach works fine for copying smaller files, but if
 // we need to copy big files it may be worth the complexity to use the syscall.
-func copyFile(dst, src string) error {
+func copyFile(dst, src, name string, report ProgressFunc) error {
 	srcFd, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("failed to open source file: %w", err)
@@ -56,7 +112,12 @@ func copyFile(dst, src string) error {
 		return fmt.Errorf("failed to open destination file: %w", err)
 	}
 	defer dstFd.Close()
-	if _, err := io.Copy(dstFd, srcFd); err != nil {
+	info, err := srcFd.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+	writer := newProgressWriter(dstFd, name, info.Size(), report)
+	if _, err := io.Copy(writer, srcFd); err != nil {
 		return fmt.Errorf("failed to copy contents of src to dst: %w", err)
 	}
 	return nil
@@ -68,7 +129,15 @@ func NewSnapshotterImpl() SnapshotterImpl {
 	}
 }
 
-func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error {
+// Reports a file that WSL has finished exporting or importing as fully
+// copied; WSL gives no feedback while it is working.
+func reportFileDone(path string, report ProgressFunc) {
+	if info, err := os.Stat(path); err == nil {
+		report(Progress{File: filepath.Base(path), Copied: info.Size(), Total: info.Size()})
+	}
+}
+
+func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, components []string, report ProgressFunc) error {
 	taskRunner := runner.NewTaskRunner(ctx)
 
 	// export WSL distros to snapshot directory
@@ -78,6 +147,7 @@ func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *pa
 			if err := snapshotter.ExportDistro(ctx, distro.Name, snapshotDistroPath); err != nil {
 				return fmt.Errorf("failed to export WSL distro %q: %w", distro.Name, err)
 			}
+			reportFileDone(snapshotDistroPath, report)
 			return nil
 		})
 	}
@@ -86,26 +156,30 @@ func (snapshotter SnapshotterImpl) CreateFiles(ctx context.Context, appPaths *pa
 	taskRunner.Add(func() error {
 		workingSettingsPath := filepath.Join(appPaths.Config, "settings.json")
 		snapshotSettingsPath := filepath.Join(snapshotDir, "settings.json")
-		if err := copyFile(snapshotSettingsPath, workingSettingsPath); err != nil {
+		if err := copyFile(snapshotSettingsPath, workingSettingsPath, "settings.json", report); err != nil {
 			return fmt.Errorf("failed to copy %q to snapshot directory: %w", workingSettingsPath, err)
 		}
 		return nil
 	})
 
-	// Create complete.txt file. This is done last because its presence
-	// signifies a complete and valid snapshot.
-	taskRunner.Add(func() error {
-		completeFilePath := filepath.Join(snapshotDir, completeFileName)
-		if err := os.WriteFile(completeFilePath, []byte(completeFileContents), 0o644); err != nil {
-			return fmt.Errorf("failed to write %q: %w", completeFileName, err)
-		}
-		return nil
-	})
+	// copy optional component directories to snapshot directory
+	for _, dir := range selectedComponentDirectories(appPaths, components) {
+		taskRunner.Add(func() error {
+			if err := replaceTree(filepath.Join(snapshotDir, dir.Name), dir.WorkingPath, dir.Name, report); err != nil {
+				return fmt.Errorf("failed to copy %q to snapshot directory: %w", dir.WorkingPath, err)
+			}
+			return nil
+		})
+	}
 
 	return taskRunner.Wait()
 }
 
-func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string) error {
+func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *paths.Paths, snapshotDir string, report ProgressFunc) error {
+	contents, err := readContentsFile(appPaths, snapshotDir)
+	if err != nil {
+		return err
+	}
 	tr := runner.NewTaskRunner(ctx)
 
 	// unregister WSL distros
@@ -126,6 +200,7 @@ func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *p
 			if err := snapshotter.ImportDistro(ctx, distro.Name, distro.WorkingDirPath, snapshotDistroPath); err != nil {
 				return fmt.Errorf("failed to import WSL distro %q: %w", distro.Name, err)
 			}
+			reportFileDone(snapshotDistroPath, report)
 			return nil
 		})
 	}
@@ -134,11 +209,22 @@ func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *p
 	workingSettingsPath := filepath.Join(appPaths.Config, "settings.json")
 	snapshotSettingsPath := filepath.Join(snapshotDir, "settings.json")
 	tr.Add(func() error {
-		if err := copyFile(workingSettingsPath, snapshotSettingsPath); err != nil {
+		if err := copyFile(workingSettingsPath, snapshotSettingsPath, "settings.json", report); err != nil {
 			return fmt.Errorf("failed to restore %q: %w", workingSettingsPath, err)
 		}
 		return nil
 	})
+
+	// copy the optional component directories in the snapshot back to
+	// their working locations
+	for _, dir := range selectedComponentDirectories(appPaths, contents.Components) {
+		tr.Add(func() error {
+			if err := replaceTree(dir.WorkingPath, filepath.Join(snapshotDir, dir.Name), dir.Name, report); err != nil {
+				return fmt.Errorf("failed to restore %q: %w", dir.WorkingPath, err)
+			}
+			return nil
+		})
+	}
 	if err := tr.Wait(); err != nil {
 		_ = os.Remove(workingSettingsPath)
 		_ = snapshotter.UnregisterDistros(ctx)
@@ -146,3 +232,15 @@ func (snapshotter SnapshotterImpl) RestoreFiles(ctx context.Context, appPaths *p
 	}
 	return nil
 }
+
+// WSL distros cannot be staged alongside the working ones, so RestoreFiles
+// replaces them in place and there is nothing to commit.
+func (snapshotter SnapshotterImpl) CommitRestore(appPaths *paths.Paths) error {
+	return nil
+}
+
+// WSL distros cannot be staged alongside the working ones, so RestoreFiles
+// replaces them in place and there is nothing to roll back to.
+func (snapshotter SnapshotterImpl) RollbackRestore(appPaths *paths.Paths) error {
+	return nil
+}
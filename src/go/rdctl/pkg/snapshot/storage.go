@@ -0,0 +1,208 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/s3"
+)
+
+// Each snapshot in a Storage is kept under a directory (or key prefix)
+// named after its ID, holding the archive and a copy of its metadata. The
+// metadata is written last, so a snapshot whose metadata is missing was not
+// stored completely and is ignored.
+const (
+	storageArchiveName  = "snapshot.tar.gz"
+	storageMetadataName = "metadata.json"
+)
+
+// Storage is a place outside the snapshots directory where snapshots can be
+// kept and shared, such as a network directory or an S3 bucket. Snapshots
+// are stored as archives in the format written by Export.
+type Storage interface {
+	// List returns the snapshots that have been stored completely.
+	List(ctx context.Context) ([]Snapshot, error)
+	// Put stores the archive of snapshot, read from archive, replacing any
+	// stored snapshot with the same ID.
+	Put(ctx context.Context, snapshot Snapshot, archive io.Reader) error
+	// Get returns the archive of a stored snapshot. The caller must close it.
+	Get(ctx context.Context, snapshot Snapshot) (io.ReadCloser, error)
+}
+
+// LocalStorage keeps snapshots in a directory, which may be on a network
+// file system.
+type LocalStorage struct {
+	Directory string
+}
+
+func (storage *LocalStorage) List(ctx context.Context) ([]Snapshot, error) {
+	dirEntries, err := os.ReadDir(storage.Directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snapshot{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", storage.Directory, err)
+	}
+	snapshots := []Snapshot{}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		contents, err := os.ReadFile(filepath.Join(storage.Directory, dirEntry.Name(), storageMetadataName))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read metadata of %q: %w", dirEntry.Name(), err)
+		}
+		snapshot, err := parseStoredMetadata(dirEntry.Name(), contents)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (storage *LocalStorage) Put(ctx context.Context, snapshot Snapshot, archive io.Reader) error {
+	snapshotDir := filepath.Join(storage.Directory, snapshot.ID)
+	if err := os.MkdirAll(snapshotDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %q: %w", snapshotDir, err)
+	}
+	// Remove any earlier metadata first, so that the snapshot is not listed
+	// while its archive is being replaced.
+	if err := os.Remove(filepath.Join(snapshotDir, storageMetadataName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove old metadata: %w", err)
+	}
+	if err := writeFileAtomically(filepath.Join(snapshotDir, storageArchiveName), archive); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+	metadata, err := json.Marshal(&snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := writeFileAtomically(filepath.Join(snapshotDir, storageMetadataName), strings.NewReader(string(metadata))); err != nil {
+		return fmt.Errorf("failed to store metadata: %w", err)
+	}
+	return nil
+}
+
+func (storage *LocalStorage) Get(ctx context.Context, snapshot Snapshot) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(storage.Directory, snapshot.ID, storageArchiveName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	return file, nil
+}
+
+// Writes the contents of reader to a temporary file next to filePath, then
+// moves it into place, so that filePath is never left truncated.
+func writeFileAtomically(filePath string, reader io.Reader) (err error) {
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), ".rdctl-snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tempFile.Close()
+		if err != nil {
+			_ = os.Remove(tempFile.Name())
+		}
+	}()
+	if _, err := io.Copy(tempFile, reader); err != nil {
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), filePath)
+}
+
+// S3Storage keeps snapshots in an S3-compatible object store, under a
+// common key prefix.
+type S3Storage struct {
+	Client *s3.Client
+	// The prefix of the keys of stored objects, without a trailing slash;
+	// may be empty.
+	Prefix string
+}
+
+func (storage *S3Storage) key(id, name string) string {
+	return strings.TrimPrefix(path.Join(storage.Prefix, id, name), "/")
+}
+
+func (storage *S3Storage) List(ctx context.Context) ([]Snapshot, error) {
+	prefix := storage.Prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := storage.Client.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []Snapshot{}
+	for _, object := range objects {
+		id, name, ok := strings.Cut(strings.TrimPrefix(object.Key, prefix), "/")
+		if !ok || name != storageMetadataName {
+			continue
+		}
+		contents, err := storage.readObject(ctx, object.Key)
+		if errors.Is(err, s3.ErrNotFound) {
+			// Deleted since it was listed.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		snapshot, err := parseStoredMetadata(id, contents)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (storage *S3Storage) readObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := storage.Client.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	contents, err := io.ReadAll(io.LimitReader(body, maxArchiveJSONSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", key, err)
+	}
+	return contents, nil
+}
+
+func (storage *S3Storage) Put(ctx context.Context, snapshot Snapshot, archive io.Reader) error {
+	if err := storage.Client.DeleteObject(ctx, storage.key(snapshot.ID, storageMetadataName)); err != nil && !errors.Is(err, s3.ErrNotFound) {
+		return err
+	}
+	if err := storage.Client.PutObject(ctx, storage.key(snapshot.ID, storageArchiveName), archive); err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(&snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return storage.Client.PutObject(ctx, storage.key(snapshot.ID, storageMetadataName), strings.NewReader(string(metadata)))
+}
+
+func (storage *S3Storage) Get(ctx context.Context, snapshot Snapshot) (io.ReadCloser, error) {
+	return storage.Client.GetObject(ctx, storage.key(snapshot.ID, storageArchiveName))
+}
+
+// Parses the stored metadata of the snapshot with the given ID.
+func parseStoredMetadata(id string, contents []byte) (Snapshot, error) {
+	snapshot := Snapshot{}
+	if err := json.Unmarshal(contents, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse metadata of %q: %w", id, err)
+	}
+	snapshot.ID = id
+	return snapshot, nil
+}
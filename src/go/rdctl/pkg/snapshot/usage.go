@@ -0,0 +1,219 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Returned by fileExtents when the physical layout of a file can't be
+// determined on this platform or filesystem.
+var errExtentsUnsupported = errors.New("file extents are not supported")
+
+// A range of a file's data on disk.
+type extent struct {
+	// The device that holds the data.
+	Device uint64
+	// The offset of the data on the device.
+	Physical int64
+	Length   int64
+	// Whether Physical is meaningful; data that is inline, delayed or
+	// encoded can't be compared with other extents.
+	Known bool
+}
+
+// DiskUsage describes how much disk space a snapshot uses.
+type DiskUsage struct {
+	Name string `json:"name"`
+	// The total size of the files in the snapshot.
+	Apparent int64 `json:"apparent"`
+	// The bytes stored only by this snapshot, and not shared with other
+	// snapshots or the working files through copy-on-write clones. This is
+	// the space that deleting the snapshot would reclaim. It is nil if it
+	// can't be determined.
+	Exclusive *int64 `json:"exclusive"`
+}
+
+// DiskUsageReport describes the disk space used by all snapshots.
+type DiskUsageReport struct {
+	Snapshots []DiskUsage `json:"snapshots"`
+	// The total size of the files in all snapshots.
+	Apparent int64 `json:"apparent"`
+	// The space that deleting every snapshot would reclaim. This includes
+	// data shared only between snapshots, so it can be more than the sum
+	// of their exclusive sizes. It is nil if it can't be determined.
+	Reclaimable *int64 `json:"reclaimable"`
+}
+
+// A snapshot, or the working files, and the extents of the files in it.
+type extentOwner struct {
+	Name     string
+	Apparent int64
+	Extents  []extent
+	// Whether the extents of every file were determined.
+	Complete bool
+}
+
+// DiskUsage reports the apparent and exclusive size of each complete
+// snapshot.
+func (manager *Manager) DiskUsage() (DiskUsageReport, error) {
+	report := DiskUsageReport{Snapshots: []DiskUsage{}}
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return report, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	owners := make([]extentOwner, 0, len(snapshots)+1)
+	for _, snapshot := range snapshots {
+		owner, err := collectExtents(snapshot.Name, []string{manager.SnapshotDirectory(snapshot)})
+		if err != nil {
+			return report, err
+		}
+		owners = append(owners, owner)
+	}
+	// The working files can share data with any of the snapshots.
+	var working extentOwner
+	if copiesShareExtents {
+		if working, err = collectExtents("", restoredPaths(manager.Paths, OptionalComponents)); err != nil {
+			return report, err
+		}
+	}
+
+	allComplete := true
+	allExtents := []extent{}
+	for i, owner := range owners {
+		usage := DiskUsage{Name: owner.Name, Apparent: owner.Apparent}
+		switch {
+		case !copiesShareExtents:
+			exclusive := owner.Apparent
+			usage.Exclusive = &exclusive
+		case owner.Complete:
+			others := append([]extent{}, working.Extents...)
+			for j, other := range owners {
+				if j != i {
+					others = append(others, other.Extents...)
+				}
+			}
+			exclusive := exclusiveBytes(owner.Extents, others)
+			usage.Exclusive = &exclusive
+		}
+		report.Snapshots = append(report.Snapshots, usage)
+		report.Apparent += owner.Apparent
+		allComplete = allComplete && owner.Complete
+		allExtents = append(allExtents, owner.Extents...)
+	}
+	switch {
+	case !copiesShareExtents:
+		reclaimable := report.Apparent
+		report.Reclaimable = &reclaimable
+	case allComplete:
+		// Merge the extents first, so that data shared between snapshots
+		// is only counted once.
+		own := []extent{}
+		for _, deviceExtents := range mergeExtents(allExtents) {
+			own = append(own, deviceExtents...)
+		}
+		for _, anExtent := range allExtents {
+			if !anExtent.Known {
+				own = append(own, anExtent)
+			}
+		}
+		reclaimable := exclusiveBytes(own, working.Extents)
+		report.Reclaimable = &reclaimable
+	}
+	return report, nil
+}
+
+// Collects the extents of the regular files in or at the given paths.
+// Missing paths are skipped.
+func collectExtents(name string, roots []string) (extentOwner, error) {
+	owner := extentOwner{Name: name, Complete: copiesShareExtents}
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if !dirEntry.Type().IsRegular() {
+				return nil
+			}
+			info, err := dirEntry.Info()
+			if err != nil {
+				return err
+			}
+			owner.Apparent += info.Size()
+			if !owner.Complete {
+				return nil
+			}
+			extents, err := fileExtents(path)
+			if errors.Is(err, errExtentsUnsupported) {
+				owner.Complete = false
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("failed to get extents of %q: %w", path, err)
+			}
+			owner.Extents = append(owner.Extents, extents...)
+			return nil
+		})
+		if err != nil {
+			return owner, err
+		}
+	}
+	return owner, nil
+}
+
+// Returns the number of bytes in own that do not overlap any extent in
+// others. Extents whose location is unknown are assumed not to overlap.
+func exclusiveBytes(own, others []extent) int64 {
+	shared := mergeExtents(others)
+	var total int64
+	for _, ownExtent := range own {
+		total += ownExtent.Length
+		if !ownExtent.Known {
+			continue
+		}
+		start := ownExtent.Physical
+		end := start + ownExtent.Length
+		candidates := shared[ownExtent.Device]
+		// Find the first merged extent that ends after this one starts.
+		i := sort.Search(len(candidates), func(i int) bool {
+			return candidates[i].Physical+candidates[i].Length > start
+		})
+		for ; i < len(candidates) && candidates[i].Physical < end; i++ {
+			overlapStart := max(start, candidates[i].Physical)
+			overlapEnd := min(end, candidates[i].Physical+candidates[i].Length)
+			total -= overlapEnd - overlapStart
+		}
+	}
+	return total
+}
+
+// Groups extents with known locations by device, sorted and with
+// overlapping or adjacent extents merged.
+func mergeExtents(extents []extent) map[uint64][]extent {
+	byDevice := map[uint64][]extent{}
+	for _, anExtent := range extents {
+		if anExtent.Known && anExtent.Length > 0 {
+			byDevice[anExtent.Device] = append(byDevice[anExtent.Device], anExtent)
+		}
+	}
+	for device, deviceExtents := range byDevice {
+		sort.Slice(deviceExtents, func(i, j int) bool {
+			return deviceExtents[i].Physical < deviceExtents[j].Physical
+		})
+		merged := deviceExtents[:1]
+		for _, anExtent := range deviceExtents[1:] {
+			last := &merged[len(merged)-1]
+			if anExtent.Physical <= last.Physical+last.Length {
+				last.Length = max(last.Length, anExtent.Physical+anExtent.Length-last.Physical)
+			} else {
+				merged = append(merged, anExtent)
+			}
+		}
+		byDevice[device] = merged
+	}
+	return byDevice
+}
@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusiveBytes(t *testing.T) {
+	own := []extent{
+		{Device: 1, Physical: 0, Length: 100, Known: true},
+		{Device: 1, Physical: 1000, Length: 100, Known: true},
+		{Device: 2, Physical: 0, Length: 50, Known: true},
+		{Device: 1, Physical: 5000, Length: 10, Known: false},
+	}
+	others := []extent{
+		// Overlaps the first 50 bytes of the first extent, in two pieces.
+		{Device: 1, Physical: 0, Length: 30, Known: true},
+		{Device: 1, Physical: 20, Length: 30, Known: true},
+		// Covers all of the second extent.
+		{Device: 1, Physical: 900, Length: 300, Known: true},
+		// Same offsets, but a different device.
+		{Device: 3, Physical: 0, Length: 50, Known: true},
+		// Unknown locations never count as shared.
+		{Device: 2, Physical: 0, Length: 50, Known: false},
+	}
+	assert.Equal(t, int64(50+0+50+10), exclusiveBytes(own, others))
+	assert.Equal(t, int64(260), exclusiveBytes(own, nil))
+}
+
+func TestDiskUsage(t *testing.T) {
+	appPaths, _ := populateFiles(t, true)
+	manager := newTestManager(appPaths)
+	for _, name := range []string{"first", "second"} {
+		_, err := manager.Create(context.Background(), name, "")
+		require.NoError(t, err)
+	}
+	report, err := manager.DiskUsage()
+	require.NoError(t, err)
+	require.Len(t, report.Snapshots, 2)
+	assert.Equal(t, report.Snapshots[0].Apparent+report.Snapshots[1].Apparent, report.Apparent)
+	for _, usage := range report.Snapshots {
+		snapshot, err := manager.Snapshot(usage.Name)
+		require.NoError(t, err)
+		var contentSize int64
+		for _, file := range snapshot.Files {
+			contentSize += file.Size
+		}
+		assert.Greater(t, usage.Apparent, contentSize, "apparent size should include metadata")
+		if usage.Exclusive != nil {
+			// Nothing is shared without copy-on-write support, but the
+			// allocated size can exceed the apparent size.
+			assert.Positive(t, *usage.Exclusive)
+		}
+	}
+}
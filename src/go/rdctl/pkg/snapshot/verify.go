@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
+)
+
+// Returned by Manager.Verify for snapshots that were created before
+// checksums were recorded, and so cannot be verified.
+var ErrNoChecksums = errors.New("snapshot has no recorded checksums")
+
+// Returned by Manager.Verify when a snapshot file does not match its
+// recorded size or checksum.
+var ErrCorrupt = errors.New("snapshot is corrupt")
+
+// Computes the size and checksum of each file in a snapshot directory,
+// including the regular files in any component directories. Files that are allowed
+// to be missing and are not present are skipped.
+func (manager *Manager) checksumFiles(ctx context.Context, snapshotDir string) ([]SnapshotFile, error) {
+	entries, err := expandContentFiles(snapshotDir, contentFiles(manager.Paths))
+	if err != nil {
+		return nil, err
+	}
+	files := []SnapshotFile{}
+	for _, entry := range entries {
+		if contextIsDone(ctx) {
+			return nil, runner.ErrContextDone
+		}
+		if entry.LinkTarget != "" {
+			// Links have no contents of their own.
+			continue
+		}
+		file, err := checksumFile(snapshotDir, entry.Name)
+		if errors.Is(err, os.ErrNotExist) && entry.MissingOk {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to checksum %q: %w", entry.Name, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Verify checks that each file in a snapshot has the size and checksum that
+// was recorded when the snapshot was created. Returns ErrNoChecksums if no
+// checksums were recorded.
+func (manager *Manager) Verify(ctx context.Context, snapshot Snapshot) error {
+	if len(snapshot.Files) == 0 {
+		return ErrNoChecksums
+	}
+	snapshotDir := manager.SnapshotDirectory(snapshot)
+	// Check the sizes first, as that is cheap compared to hashing.
+	for _, file := range snapshot.Files {
+		info, err := os.Stat(filepath.Join(snapshotDir, filepath.FromSlash(file.Name)))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrCorrupt, err)
+		}
+		if info.Size() != file.Size {
+			return fmt.Errorf("%w: %q has size %d, expected %d", ErrCorrupt, file.Name, info.Size(), file.Size)
+		}
+	}
+	for _, file := range snapshot.Files {
+		if contextIsDone(ctx) {
+			return runner.ErrContextDone
+		}
+		actual, err := checksumFile(snapshotDir, file.Name)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrCorrupt, err)
+		}
+		if actual.SHA256 != file.SHA256 {
+			return fmt.Errorf("%w: %q has checksum %s, expected %s", ErrCorrupt, file.Name, actual.SHA256, file.SHA256)
+		}
+	}
+	return nil
+}
+
+// Computes the size and checksum of the file with the given slash-separated
+// name in snapshotDir.
+func checksumFile(snapshotDir, name string) (SnapshotFile, error) {
+	file, err := os.Open(filepath.Join(snapshotDir, filepath.FromSlash(name)))
+	if err != nil {
+		return SnapshotFile{}, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return SnapshotFile{}, err
+	}
+	return SnapshotFile{
+		Name:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
@@ -1,6 +1,9 @@
 package wsl
 
-import "context"
+import (
+	"context"
+	"os"
+)
 
 type MockWSL struct{}
 
@@ -8,8 +11,10 @@ func (wsl MockWSL) UnregisterDistros(ctx context.Context) error {
 	return nil
 }
 
+// ExportDistro writes an empty file in place of the exported distro, so
+// that callers can treat it like a real export.
 func (wsl MockWSL) ExportDistro(ctx context.Context, distroName, fileName string) error {
-	return nil
+	return os.WriteFile(fileName, []byte{}, 0o644)
 }
 
 func (wsl MockWSL) ImportDistro(ctx context.Context, distroName, installLocation, fileName string) error {